@@ -0,0 +1,136 @@
+// Package contracts provides the run-time support for the code generated by gocontracts.
+package contracts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind distinguishes the kinds of the contract conditions.
+type Kind int
+
+const (
+	// Precondition needs to hold on entry of a function.
+	Precondition Kind = iota
+
+	// Postcondition needs to hold on exit of a function.
+	Postcondition
+
+	// Invariant of a type needs to hold on entry and exit of its every exported method.
+	Invariant
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Precondition:
+		return "pre-condition"
+	case Postcondition:
+		return "post-condition"
+	case Invariant:
+		return "invariant"
+	default:
+		return "unknown"
+	}
+}
+
+// ViolationInfo describes a violated condition of a contract.
+//
+// The generated code passes it to the violation handler.
+type ViolationInfo struct {
+	Kind Kind
+
+	// Package is the import path of the package of the function (e.g., "github.com/someone/util"). It is set
+	// only by the code generated with gocontracts -metrics.
+	Package string
+
+	// Function is the name of the function. The name of a method is prefixed with the name of
+	// its receiver's type (e.g., "SomeType.SomeMethod").
+	Function string
+
+	// Label of the condition, if any
+	Label string
+
+	// Condition is the condition as written in the documentation including its initialization
+	// or its quantifier, if any.
+	Condition string
+
+	// Offending identifies the element which violated a universally quantified condition (e.g., "i = 3").
+	// It is empty for the other conditions.
+	Offending string
+
+	// File is the base name of the file where the condition was documented.
+	File string
+
+	// Line is the line of the condition in the documentation.
+	Line int
+}
+
+// Message formats the violation the same way as the message of the panic in the code generated
+// without a handler (e.g., "Violated: some label: x > 0").
+func (v ViolationInfo) Message() string {
+	parts := make([]string, 0, 4)
+
+	parts = append(parts, "Violated: ")
+
+	if len(v.Label) > 0 {
+		parts = append(parts, v.Label+": ")
+	}
+
+	parts = append(parts, v.Condition)
+
+	if len(v.Offending) > 0 {
+		parts = append(parts, " ("+v.Offending+")")
+	}
+
+	return strings.Join(parts, "")
+}
+
+// Position gives the position of the condition in the documentation as "file:line".
+func (v ViolationInfo) Position() string {
+	return fmt.Sprintf("%s:%d", v.File, v.Line)
+}
+
+// ViolationError is the panic value of the code generated with Panic as the violation handler.
+//
+// Since it implements error, the callers can use errors.As after recovering from the panic.
+type ViolationError struct {
+	ViolationInfo
+}
+
+// Error gives the same message as the panic in the code generated without a handler.
+func (e *ViolationError) Error() string {
+	return e.Message()
+}
+
+// Panic panics with a *ViolationError.
+//
+// Use it as the violation handler (gocontracts -handler github.com/Parquery/gocontracts/contracts.Panic or
+// gocontracts -typed-panics) so that the violations can be told apart from the other panics.
+func Panic(info ViolationInfo) {
+	panic(&ViolationError{ViolationInfo: info})
+}
+
+// Recover handles the panic if it is caused by a contract violation. The other panics are propagated.
+//
+// Recover needs to be deferred directly:
+//
+//	defer contracts.Recover(func(err *contracts.ViolationError) {
+//		log.Printf("%s: %s", err.Position(), err.Error())
+//	})
+func Recover(handle func(err *ViolationError)) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	if err, ok := r.(error); ok {
+		var violation *ViolationError
+		if errors.As(err, &violation) {
+			handle(violation)
+			return
+		}
+	}
+
+	panic(r)
+}
@@ -0,0 +1,150 @@
+package contracts_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Parquery/gocontracts/contracts"
+)
+
+func TestKind_String(t *testing.T) {
+	expected := map[contracts.Kind]string{
+		contracts.Precondition:  "pre-condition",
+		contracts.Postcondition: "post-condition",
+		contracts.Invariant:     "invariant",
+		contracts.Kind(42):      "unknown",
+	}
+
+	for kind, exp := range expected {
+		if got := kind.String(); got != exp {
+			t.Errorf("expected %#v, got %#v", exp, got)
+		}
+	}
+}
+
+func TestViolationInfo_Message(t *testing.T) {
+	info := contracts.ViolationInfo{
+		Kind:      contracts.Precondition,
+		Function:  "SomeFunc",
+		Condition: "_, ok := someMap[3]; ok",
+		File:      "some.go",
+		Line:      7}
+
+	if got := info.Message(); got != "Violated: _, ok := someMap[3]; ok" {
+		t.Errorf("unexpected message without a label: %#v", got)
+	}
+
+	info.Label = "some label"
+	if got := info.Message(); got != "Violated: some label: _, ok := someMap[3]; ok" {
+		t.Errorf("unexpected message with a label: %#v", got)
+	}
+}
+
+func TestViolationInfo_Message_Offending(t *testing.T) {
+	info := contracts.ViolationInfo{
+		Kind:      contracts.Postcondition,
+		Function:  "SomeFunc",
+		Condition: "forall i, x in items: x > 0",
+		Offending: "i = 3",
+		File:      "some.go",
+		Line:      7}
+
+	if got := info.Message(); got != "Violated: forall i, x in items: x > 0 (i = 3)" {
+		t.Errorf("unexpected message: %#v", got)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	info := contracts.ViolationInfo{
+		Kind:      contracts.Postcondition,
+		Function:  "SomeType.SomeMethod",
+		Label:     "some label",
+		Condition: "x > 0",
+		File:      "some.go",
+		Line:      7}
+
+	var got *contracts.ViolationError
+	func() {
+		defer contracts.Recover(func(err *contracts.ViolationError) {
+			got = err
+		})
+
+		contracts.Panic(info)
+	}()
+
+	if got == nil {
+		t.Fatal("expected the violation to be recovered, but got nil")
+	}
+
+	if got.ViolationInfo != info {
+		t.Errorf("expected the violation info %#v, got %#v", info, got.ViolationInfo)
+	}
+
+	if got.Error() != "Violated: some label: x > 0" {
+		t.Errorf("unexpected error message: %#v", got.Error())
+	}
+
+	if got.Position() != "some.go:7" {
+		t.Errorf("unexpected position: %#v", got.Position())
+	}
+}
+
+func TestRecover_NoPanic(t *testing.T) {
+	called := false
+	func() {
+		defer contracts.Recover(func(err *contracts.ViolationError) {
+			called = true
+		})
+	}()
+
+	if called {
+		t.Fatal("expected the handle not to be called without a panic")
+	}
+}
+
+func TestRecover_OtherPanic(t *testing.T) {
+	var recovered interface{}
+	func() {
+		defer func() {
+			recovered = recover()
+		}()
+
+		defer contracts.Recover(func(err *contracts.ViolationError) {
+			t.Fatal("expected the handle not to be called on other panics")
+		})
+
+		panic("something else")
+	}()
+
+	if recovered != "something else" {
+		t.Fatalf("expected the other panic to be propagated, got %#v", recovered)
+	}
+}
+
+func TestViolationError_ErrorsAs(t *testing.T) {
+	var recovered interface{}
+	func() {
+		defer func() {
+			recovered = recover()
+		}()
+
+		contracts.Panic(contracts.ViolationInfo{Kind: contracts.Invariant, Condition: "s.x >= 0"})
+	}()
+
+	err, ok := recovered.(error)
+	if !ok {
+		t.Fatalf("expected the panic value to be an error, got %#v", recovered)
+	}
+
+	wrapped := fmt.Errorf("failed to do something: %w", err)
+
+	var violation *contracts.ViolationError
+	if !errors.As(wrapped, &violation) {
+		t.Fatal("expected errors.As to find the violation in the wrapped error")
+	}
+
+	if violation.Kind != contracts.Invariant {
+		t.Fatalf("expected the kind %s, got %s", contracts.Invariant, violation.Kind)
+	}
+}
@@ -0,0 +1,274 @@
+package contracts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter counts how often a condition was evaluated and how often it held.
+type Counter struct {
+	// Package is the name of the package. It is set by NewCoverage.
+	Package string
+
+	Kind Kind
+
+	// Function is the name of the function checking the condition. The name of a method is prefixed with
+	// the name of its receiver's type (e.g., "SomeType.SomeMethod").
+	Function string
+
+	// Label of the condition, if any
+	Label string
+
+	// Condition is the condition as written in the documentation.
+	Condition string
+
+	// File is the base name of the file where the condition was documented.
+	File string
+
+	// Line is the line of the condition in the documentation.
+	Line int
+
+	// Evaluated counts the evaluations of the condition. Every element of a quantified condition counts
+	// as an evaluation.
+	Evaluated uint64
+
+	// Passed counts the evaluations in which the condition held.
+	Passed uint64
+}
+
+// counterKey identifies the condition checked in a function.
+type counterKey struct {
+	function string
+	file     string
+	line     int
+}
+
+// Coverage counts the evaluations of the instrumented conditions of a package.
+//
+// The code generated by gocontracts -coverage declares the coverage of the package in the file
+// contracts_coverage.go and reports the evaluations of the conditions with Check.
+type Coverage struct {
+	counters []*Counter
+	index    map[counterKey]*Counter
+}
+
+// coverages lists all the registered coverages.
+var coverages struct {
+	sync.Mutex
+	all []*Coverage
+}
+
+// NewCoverage registers the conditions of the package so that the conditions which were never evaluated
+// are listed in the profile as well.
+func NewCoverage(pkg string, counters []Counter) *Coverage {
+	c := &Coverage{
+		counters: make([]*Counter, 0, len(counters)),
+		index:    make(map[counterKey]*Counter, len(counters))}
+
+	for i := range counters {
+		counter := counters[i]
+		counter.Package = pkg
+
+		c.counters = append(c.counters, &counter)
+		c.index[counterKey{function: counter.Function, file: counter.File, line: counter.Line}] = &counter
+	}
+
+	coverages.Lock()
+	defer coverages.Unlock()
+
+	coverages.all = append(coverages.all, c)
+
+	return c
+}
+
+// Check counts the evaluation of the condition documented on the line of the file and checked in
+// the function. It returns ok so that it can wrap the condition in the generated code.
+//
+// The conditions which were not registered are not counted.
+func (c *Coverage) Check(function string, file string, line int, ok bool) bool {
+	counter, found := c.index[counterKey{function: function, file: file, line: line}]
+	if found {
+		atomic.AddUint64(&counter.Evaluated, 1)
+		if ok {
+			atomic.AddUint64(&counter.Passed, 1)
+		}
+	}
+
+	return ok
+}
+
+// Counters gives a snapshot of the counters of all the registered coverages.
+func Counters() []Counter {
+	coverages.Lock()
+	defer coverages.Unlock()
+
+	result := []Counter{}
+	for _, c := range coverages.all {
+		for _, counter := range c.counters {
+			snapshot := *counter
+			snapshot.Evaluated = atomic.LoadUint64(&counter.Evaluated)
+			snapshot.Passed = atomic.LoadUint64(&counter.Passed)
+
+			result = append(result, snapshot)
+		}
+	}
+
+	return result
+}
+
+// profileMode is the first line of a profile.
+const profileMode = "mode: contracts"
+
+// kindNames maps the kinds as written in the profile to the kinds.
+var kindNames = map[string]Kind{
+	Precondition.String(): Precondition, Postcondition.String(): Postcondition, Invariant.String(): Invariant}
+
+// WriteProfile writes the counters of all the registered coverages as a profile.
+//
+// The profile starts with the line "mode: contracts" followed by a line for each condition with
+// the tab-separated package, file, line, kind, function, quoted label, quoted condition, number of evaluations
+// and number of passes.
+func WriteProfile(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	_, err := fmt.Fprintln(bw, profileMode)
+	if err != nil {
+		return err
+	}
+
+	for _, counter := range Counters() {
+		_, err = fmt.Fprintf(bw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%d\t%d\n",
+			counter.Package, counter.File, counter.Line, counter.Kind, counter.Function,
+			strconv.Quote(counter.Label), strconv.Quote(counter.Condition), counter.Evaluated, counter.Passed)
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteProfileFile writes the profile to the file at the given path.
+//
+// Call it at the end of TestMain so that the profile covers the whole test suite:
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		if err := contracts.WriteProfileFile("contracts.out"); err != nil {
+//			panic(err.Error())
+//		}
+//		os.Exit(code)
+//	}
+func WriteProfileFile(pth string) (err error) {
+	f, err := os.Create(pth)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	err = WriteProfile(f)
+	return
+}
+
+// parseProfileLine parses a line of the profile describing a single condition.
+func parseProfileLine(line string) (counter Counter, err error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 9 {
+		err = fmt.Errorf("expected 9 tab-separated fields, but got %d", len(fields))
+		return
+	}
+
+	counter.Package, counter.File, counter.Function = fields[0], fields[1], fields[4]
+
+	counter.Line, err = strconv.Atoi(fields[2])
+	if err != nil {
+		err = fmt.Errorf("invalid line %#v", fields[2])
+		return
+	}
+
+	var ok bool
+	counter.Kind, ok = kindNames[fields[3]]
+	if !ok {
+		err = fmt.Errorf("invalid kind %#v", fields[3])
+		return
+	}
+
+	counter.Label, err = strconv.Unquote(fields[5])
+	if err != nil {
+		err = fmt.Errorf("invalid label %s", fields[5])
+		return
+	}
+
+	counter.Condition, err = strconv.Unquote(fields[6])
+	if err != nil {
+		err = fmt.Errorf("invalid condition %s", fields[6])
+		return
+	}
+
+	counter.Evaluated, err = strconv.ParseUint(fields[7], 10, 64)
+	if err != nil {
+		err = fmt.Errorf("invalid number of evaluations %#v", fields[7])
+		return
+	}
+
+	counter.Passed, err = strconv.ParseUint(fields[8], 10, 64)
+	if err != nil {
+		err = fmt.Errorf("invalid number of passes %#v", fields[8])
+		return
+	}
+
+	return
+}
+
+// ReadProfile reads the counters from a profile written by WriteProfile.
+func ReadProfile(r io.Reader) (counters []Counter, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := scanner.Text()
+
+		if lineno == 1 {
+			if line != profileMode {
+				err = fmt.Errorf("expected the first line to be %#v, but got %#v", profileMode, line)
+				return
+			}
+
+			continue
+		}
+
+		if line == "" {
+			continue
+		}
+
+		var counter Counter
+		counter, err = parseProfileLine(line)
+		if err != nil {
+			err = fmt.Errorf("failed to parse the line %d: %s", lineno, err)
+			return
+		}
+
+		counters = append(counters, counter)
+	}
+
+	err = scanner.Err()
+	if err == nil && lineno == 0 {
+		err = fmt.Errorf("expected the first line to be %#v, but got an empty profile", profileMode)
+	}
+
+	return
+}
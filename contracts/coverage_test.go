@@ -0,0 +1,80 @@
+package contracts_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Parquery/gocontracts/contracts"
+)
+
+func TestCoverage(t *testing.T) {
+	coverage := contracts.NewCoverage("coveragepkg", []contracts.Counter{
+		{Kind: contracts.Precondition, Function: "SomeFunc", Label: "positive", Condition: "x > 0",
+			File: "some.go", Line: 6},
+		{Kind: contracts.Postcondition, Function: "SomeFunc", Condition: "result != \"\"",
+			File: "some.go", Line: 9}})
+
+	for _, x := range []int{1, 2, -1} {
+		if got := coverage.Check("SomeFunc", "some.go", 6, x > 0); got != (x > 0) {
+			t.Fatalf("expected Check to return %v, got %v", x > 0, got)
+		}
+	}
+
+	// The conditions which were not registered are ignored.
+	coverage.Check("OtherFunc", "some.go", 6, true)
+
+	buf := &bytes.Buffer{}
+	err := contracts.WriteProfile(buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !strings.HasPrefix(buf.String(), "mode: contracts\n") {
+		t.Fatalf("expected the profile to start with the mode, got %#v", buf.String())
+	}
+
+	counters, err := contracts.ReadProfile(buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var got []contracts.Counter
+	for _, counter := range counters {
+		if counter.Package == "coveragepkg" {
+			got = append(got, counter)
+		}
+	}
+
+	expected := []contracts.Counter{
+		{Package: "coveragepkg", Kind: contracts.Precondition, Function: "SomeFunc", Label: "positive",
+			Condition: "x > 0", File: "some.go", Line: 6, Evaluated: 3, Passed: 2},
+		{Package: "coveragepkg", Kind: contracts.Postcondition, Function: "SomeFunc", Condition: "result != \"\"",
+			File: "some.go", Line: 9}}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected the counters %#v, got %#v", expected, got)
+	}
+}
+
+func TestReadProfile_Error(t *testing.T) {
+	tests := []struct {
+		profile  string
+		expected string
+	}{
+		{profile: "", expected: "expected the first line to be \"mode: contracts\", but got an empty profile"},
+		{profile: "mode: set\n", expected: "expected the first line to be \"mode: contracts\", but got \"mode: set\""},
+		{profile: "mode: contracts\nsomepkg\tsome.go\n",
+			expected: "failed to parse the line 2: expected 9 tab-separated fields, but got 2"},
+		{profile: "mode: contracts\nsomepkg\tsome.go\t6\tsomething\tSomeFunc\t\"\"\t\"x > 0\"\t1\t1\n",
+			expected: "failed to parse the line 2: invalid kind \"something\""},
+	}
+
+	for _, tt := range tests {
+		_, err := contracts.ReadProfile(strings.NewReader(tt.profile))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected the error %#v, got %v", tt.expected, err)
+		}
+	}
+}
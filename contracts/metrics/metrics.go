@@ -0,0 +1,84 @@
+// Package metrics exposes the counts of the contract violations reported by the code generated with
+// gocontracts -metrics.
+//
+// Importing the package publishes the counts as the expvar variable "contracts_violations" (served at
+// /debug/vars by the default HTTP mux). Serve Handler to scrape the counts in the Prometheus text format:
+//
+//	http.Handle("/metrics", metrics.Handler())
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Parquery/gocontracts/contracts"
+)
+
+// ExpvarName is the name of the expvar variable holding the counts of the violations.
+const ExpvarName = "contracts_violations"
+
+// MetricName is the name of the Prometheus counter of the violations.
+const MetricName = "gocontracts_violations_total"
+
+// violation is the JSON representation of a count in the expvar variable.
+type violation struct {
+	Package  string `json:"package"`
+	Function string `json:"function"`
+	Kind     string `json:"kind"`
+	Label    string `json:"label"`
+	Count    uint64 `json:"count"`
+}
+
+func init() {
+	expvar.Publish(ExpvarName, expvar.Func(func() interface{} {
+		counts := contracts.ViolationCounts()
+
+		violations := make([]violation, 0, len(counts))
+		for _, count := range counts {
+			violations = append(violations, violation{Package: count.Package, Function: count.Function,
+				Kind: count.Kind.String(), Label: count.Label, Count: count.Count})
+		}
+
+		return violations
+	}))
+}
+
+// labelEscaper escapes the label values as required by the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteText writes the counts of the violations in the Prometheus text format.
+//
+// There is a sample for each package, function, kind and label which were violated at least once.
+func WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s Number of the violated contract conditions.\n# TYPE %s counter\n",
+		MetricName, MetricName)
+	if err != nil {
+		return err
+	}
+
+	for _, count := range contracts.ViolationCounts() {
+		_, err = fmt.Fprintf(w, "%s{package=\"%s\",function=\"%s\",kind=\"%s\",label=\"%s\"} %d\n", MetricName,
+			labelEscaper.Replace(count.Package), labelEscaper.Replace(count.Function),
+			labelEscaper.Replace(count.Kind.String()), labelEscaper.Replace(count.Label), count.Count)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handler serves the counts of the violations in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		err := WriteText(w)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package metrics_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Parquery/gocontracts/contracts"
+	"github.com/Parquery/gocontracts/contracts/metrics"
+)
+
+func TestHandler(t *testing.T) {
+	contracts.Counted(contracts.ViolationInfo{Kind: contracts.Precondition, Package: "example.com/some/util",
+		Function: "SomeType.SomeMethod", Label: `some "quoted" label`, Condition: "x > 0"})
+	contracts.Counted(contracts.ViolationInfo{Kind: contracts.Postcondition, Package: "example.com/some/util",
+		Function: "SomeFunc", Condition: "result != 0"})
+
+	srv := httptest.NewServer(metrics.Handler())
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := `# HELP gocontracts_violations_total Number of the violated contract conditions.
+# TYPE gocontracts_violations_total counter
+gocontracts_violations_total{package="example.com/some/util",function="SomeFunc",kind="post-condition",label=""} 1
+gocontracts_violations_total{package="example.com/some/util",function="SomeType.SomeMethod",kind="pre-condition",label="some \"quoted\" label"} 1
+`
+
+	if string(body) != expected {
+		t.Errorf("expected the response:\n%s\ngot:\n%s", expected, string(body))
+	}
+
+	v := expvar.Get(metrics.ExpvarName)
+	if v == nil {
+		t.Fatalf("expected the expvar variable %#v to be published", metrics.ExpvarName)
+	}
+
+	var violations []map[string]interface{}
+	err = json.Unmarshal([]byte(v.String()), &violations)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(violations) != 2 || violations[0]["function"] != "SomeFunc" || violations[0]["count"] != 1.0 {
+		t.Errorf("unexpected expvar variable: %s", v.String())
+	}
+}
@@ -0,0 +1,28 @@
+package contracts
+
+import "sync/atomic"
+
+// Sampler decides on which calls of a function the conditions are checked.
+//
+// The code generated for the functions marked with "SomeFunc contracts: sample 1/N" asks the sampler of
+// the function once per call. The samplers are declared in the generated file contracts_sampling.go of
+// the package.
+type Sampler struct {
+	rate  uint64
+	calls uint64
+}
+
+// NewSampler creates a sampler which selects the first call and then every rate-th call.
+// A rate below 1 selects every call.
+func NewSampler(rate uint64) *Sampler {
+	if rate < 1 {
+		rate = 1
+	}
+
+	return &Sampler{rate: rate}
+}
+
+// Sample counts the call and reports whether its conditions are checked. It is safe to call it concurrently.
+func (s *Sampler) Sample() bool {
+	return (atomic.AddUint64(&s.calls, 1)-1)%s.rate == 0
+}
@@ -0,0 +1,28 @@
+package contracts_test
+
+import (
+	"testing"
+
+	"github.com/Parquery/gocontracts/contracts"
+)
+
+func TestSampler(t *testing.T) {
+	s := contracts.NewSampler(3)
+
+	got := []bool{}
+	for i := 0; i < 7; i++ {
+		got = append(got, s.Sample())
+	}
+
+	expected := []bool{true, false, false, true, false, false, true}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected the samples %v, got %v", expected, got)
+		}
+	}
+
+	always := contracts.NewSampler(0)
+	if !always.Sample() || !always.Sample() {
+		t.Fatal("expected every call to be sampled at the rate 0")
+	}
+}
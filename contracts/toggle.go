@@ -0,0 +1,75 @@
+package contracts
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// Level selects which conditions are checked at run time by the code generated with gocontracts -toggle.
+type Level int32
+
+const (
+	// LevelOff disables all the checks.
+	LevelOff Level = iota
+
+	// LevelPre checks only the pre-conditions.
+	LevelPre
+
+	// LevelAll checks the pre-conditions, the post-conditions and the invariants.
+	LevelAll
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelOff:
+		return "off"
+	case LevelPre:
+		return "pre"
+	case LevelAll:
+		return "all"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLevel parses the level given as "off", "pre" or "all".
+func ParseLevel(s string) (Level, error) {
+	for _, l := range []Level{LevelOff, LevelPre, LevelAll} {
+		if s == l.String() {
+			return l, nil
+		}
+	}
+
+	return LevelAll, fmt.Errorf("expected the level of the checks to be \"off\", \"pre\" or \"all\", but got %#v", s)
+}
+
+// EnvVar is the environment variable which sets the initial level of the checks.
+const EnvVar = "GOCONTRACTS"
+
+// level is the current level of the checks accessed atomically.
+var level = int32(levelFromEnv())
+
+// levelFromEnv reads the initial level of the checks from the environment variable.
+//
+// All the conditions are checked if the variable is not set or invalid so that a typo can not disable the checks.
+func levelFromEnv() Level {
+	l, err := ParseLevel(os.Getenv(EnvVar))
+	if err != nil {
+		return LevelAll
+	}
+
+	return l
+}
+
+// SetLevel changes the level of the checks at run time. It is safe to call it concurrently with the checks.
+func SetLevel(l Level) {
+	atomic.StoreInt32(&level, int32(l))
+}
+
+// CurrentLevel gives the current level of the checks.
+//
+// The generated code loads the level once on entry to the function and guards the blocks of checks with it.
+func CurrentLevel() Level {
+	return Level(atomic.LoadInt32(&level))
+}
@@ -0,0 +1,37 @@
+package contracts_test
+
+import (
+	"testing"
+
+	"github.com/Parquery/gocontracts/contracts"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, s := range []string{"off", "pre", "all"} {
+		l, err := contracts.ParseLevel(s)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if l.String() != s {
+			t.Errorf("expected the level %#v, got %#v", s, l.String())
+		}
+	}
+
+	l, err := contracts.ParseLevel("none")
+	if err == nil || l != contracts.LevelAll {
+		t.Errorf("expected an error and all the checks for an invalid level, got %v and %v", l, err)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	defer contracts.SetLevel(contracts.CurrentLevel())
+
+	for _, l := range []contracts.Level{contracts.LevelOff, contracts.LevelPre, contracts.LevelAll} {
+		contracts.SetLevel(l)
+
+		if contracts.CurrentLevel() != l {
+			t.Fatalf("expected the current level %v, got %v", l, contracts.CurrentLevel())
+		}
+	}
+}
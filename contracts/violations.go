@@ -0,0 +1,73 @@
+package contracts
+
+import (
+	"sort"
+	"sync"
+)
+
+// ViolationCount gives how many times the conditions of a function with the same kind and label were violated.
+type ViolationCount struct {
+	// Package is the import path of the package so that the packages of the same name are told apart.
+	Package string
+
+	Function string
+	Kind     Kind
+	Label    string
+	Count    uint64
+}
+
+// violationKey identifies a counter of the violations.
+type violationKey struct {
+	pkg      string
+	function string
+	kind     Kind
+	label    string
+}
+
+// violations counts the violations reported by the generated code.
+var violations = struct {
+	sync.Mutex
+	counts map[violationKey]uint64
+}{counts: make(map[violationKey]uint64)}
+
+// Counted counts the violation and gives it back unchanged.
+//
+// The code generated with gocontracts -metrics passes every violation through Counted before it is handled
+// so that the violations are counted even if they do not panic (e.g., with a logging handler or returned
+// as errors). Expose the counts with the package github.com/Parquery/gocontracts/contracts/metrics.
+func Counted(info ViolationInfo) ViolationInfo {
+	k := violationKey{pkg: info.Package, function: info.Function, kind: info.Kind, label: info.Label}
+
+	violations.Lock()
+	violations.counts[k]++
+	violations.Unlock()
+
+	return info
+}
+
+// ViolationCounts gives the counts of the violations so far sorted by package, function, kind and label.
+func ViolationCounts() []ViolationCount {
+	violations.Lock()
+	counts := make([]ViolationCount, 0, len(violations.counts))
+	for k, count := range violations.counts {
+		counts = append(counts, ViolationCount{
+			Package: k.pkg, Function: k.function, Kind: k.kind, Label: k.label, Count: count})
+	}
+	violations.Unlock()
+
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		switch {
+		case a.Package != b.Package:
+			return a.Package < b.Package
+		case a.Function != b.Function:
+			return a.Function < b.Function
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		default:
+			return a.Label < b.Label
+		}
+	})
+
+	return counts
+}
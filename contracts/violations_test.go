@@ -0,0 +1,34 @@
+package contracts_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Parquery/gocontracts/contracts"
+)
+
+func TestCounted(t *testing.T) {
+	pre := contracts.ViolationInfo{Kind: contracts.Precondition, Package: "example.com/some/util", Function: "SomeFunc",
+		Label: "positive", Condition: "x > 0", File: "some.go", Line: 3}
+	post := contracts.ViolationInfo{Kind: contracts.Postcondition, Package: "example.com/some/util", Function: "SomeFunc",
+		Condition: "result != 0", File: "some.go", Line: 5}
+
+	if got := contracts.Counted(pre); got != pre {
+		t.Fatalf("expected the violation to be given back unchanged, got %#v", got)
+	}
+
+	contracts.Counted(post)
+
+	// The counts do not depend on the offending element and the position.
+	pre.Offending = "i = 2"
+	pre.Line = 4
+	contracts.Counted(pre)
+
+	expected := []contracts.ViolationCount{
+		{Package: "example.com/some/util", Function: "SomeFunc", Kind: contracts.Precondition, Label: "positive", Count: 2},
+		{Package: "example.com/some/util", Function: "SomeFunc", Kind: contracts.Postcondition, Count: 1}}
+
+	if got := contracts.ViolationCounts(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the counts %#v, got %#v", expected, got)
+	}
+}
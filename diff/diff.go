@@ -0,0 +1,284 @@
+// Package diff computes line-based differences between texts.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// context defines the number of unchanged lines shown around the changes.
+const context = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is a single operation of an edit script.
+type op struct {
+	kind opKind
+
+	// aPos is the index of the line in the original text at which the operation applies.
+	aPos int
+
+	// bPos is the index of the line in the updated text at which the operation applies.
+	bPos int
+}
+
+// splitLines splits the text into lines. The lines keep their new-line characters.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// window is a part of the furthest reaching x-values on the diagonals -d-1 to d+1
+// as defined in Myers' algorithm.
+type window struct {
+	d  int
+	xs []int
+}
+
+func (w window) at(k int) int {
+	return w.xs[k+w.d+1]
+}
+
+// editScript computes the shortest edit script transforming a into b following Myers' algorithm
+// (see "An O(ND) Difference Algorithm and Its Variations", 1986).
+func editScript(a []string, b []string) (ops []op) {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+
+	v := make([]int, 2*max+3)
+	trace := make([]window, 0, 16)
+
+	var finalD int
+
+search:
+	for d := 0; d <= max; d++ {
+		// Keep only the part of the x-values needed to backtrack.
+		w := window{d: d, xs: make([]int, 2*d+3)}
+		copy(w.xs, v[offset-d-1:offset+d+2])
+		trace = append(trace, w)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				finalD = d
+				break search
+			}
+		}
+	}
+
+	// Backtrack
+	x, y := n, m
+	reversed := make([]op, 0, n+m)
+
+	for d := finalD; d >= 0; d-- {
+		w := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && w.at(k-1) < w.at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := w.at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, op{kind: opEqual, aPos: x, bPos: y})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, op{kind: opInsert, aPos: x, bPos: prevY})
+			} else {
+				reversed = append(reversed, op{kind: opDelete, aPos: prevX, bPos: y})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	ops = make([]op, 0, len(reversed))
+	for i := len(reversed) - 1; i >= 0; i-- {
+		ops = append(ops, reversed[i])
+	}
+
+	return
+}
+
+// writeLine writes a line of a hunk prefixed with the marker.
+func writeLine(buf *bytes.Buffer, marker byte, line string) {
+	buf.WriteByte(marker)
+	buf.WriteString(line)
+
+	if !strings.HasSuffix(line, "\n") {
+		buf.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// hunkRange formats the range of a hunk as expected in the hunk header.
+func hunkRange(start int, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+// Unified computes the difference between the texts in unified format.
+//
+// If the texts are equal, an empty string is returned.
+func Unified(fromName string, toName string, from string, to string) string {
+	if from == to {
+		return ""
+	}
+
+	a := splitLines(from)
+	b := splitLines(to)
+
+	ops := editScript(a, b)
+
+	changes := make([]int, 0, len(ops))
+	for i, o := range ops {
+		if o.kind != opEqual {
+			changes = append(changes, i)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+
+	for i := 0; i < len(changes); {
+		// Merge the changes whose contexts overlap in a single hunk.
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context {
+			j++
+		}
+
+		start := changes[i] - context
+		if start < 0 {
+			start = 0
+		}
+
+		end := changes[j] + context + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		aCount, bCount := 0, 0
+		for _, o := range ops[start:end] {
+			if o.kind != opInsert {
+				aCount++
+			}
+			if o.kind != opDelete {
+				bCount++
+			}
+		}
+
+		buf.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(ops[start].aPos, aCount), hunkRange(ops[start].bPos, bCount)))
+
+		for _, o := range ops[start:end] {
+			switch o.kind {
+			case opEqual:
+				writeLine(&buf, ' ', a[o.aPos])
+			case opDelete:
+				writeLine(&buf, '-', a[o.aPos])
+			case opInsert:
+				writeLine(&buf, '+', b[o.bPos])
+			default:
+				panic(fmt.Sprintf("unhandled operation: %d", o.kind))
+			}
+		}
+
+		i = j + 1
+	}
+
+	return buf.String()
+}
+
+// Edit replaces a range of lines in the original text.
+type Edit struct {
+	// Start is the index of the first replaced line in the original text.
+	Start int
+
+	// End is the index of the line after the last replaced line in the original text.
+	// If End equals Start, the new text is inserted before the line Start.
+	End int
+
+	// NewText replaces the lines. The lines keep their new-line characters.
+	NewText string
+}
+
+// Edits computes the line edits transforming the text from into the text to.
+//
+// The edits are sorted and do not overlap. If the texts are equal, there are no edits.
+func Edits(from string, to string) (edits []Edit) {
+	if from == to {
+		return
+	}
+
+	a := splitLines(from)
+	b := splitLines(to)
+
+	ops := editScript(a, b)
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+
+		// Merge the consecutive deletions and insertions in a single edit.
+		e := Edit{Start: ops[i].aPos, End: ops[i].aPos}
+
+		var buf bytes.Buffer
+		for ; i < len(ops) && ops[i].kind != opEqual; i++ {
+			switch ops[i].kind {
+			case opDelete:
+				e.End = ops[i].aPos + 1
+			case opInsert:
+				buf.WriteString(b[ops[i].bPos])
+			default:
+				panic(fmt.Sprintf("unhandled operation: %d", ops[i].kind))
+			}
+		}
+
+		e.NewText = buf.String()
+		edits = append(edits, e)
+	}
+
+	return
+}
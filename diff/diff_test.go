@@ -0,0 +1,121 @@
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Parquery/gocontracts/diff"
+)
+
+func TestUnified(t *testing.T) {
+	type TestCase struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}
+
+	cases := []TestCase{
+		{
+			name:     "equal",
+			from:     "a\nb\n",
+			to:       "a\nb\n",
+			expected: ""},
+		{
+			name: "insertion",
+			from: "a\nb\nc\n",
+			to:   "a\nb\nx\nc\n",
+			expected: "--- from\n+++ to\n" +
+				"@@ -1,3 +1,4 @@\n" +
+				" a\n b\n+x\n c\n"},
+		{
+			name: "deletion",
+			from: "a\nb\nc\n",
+			to:   "a\nc\n",
+			expected: "--- from\n+++ to\n" +
+				"@@ -1,3 +1,2 @@\n" +
+				" a\n-b\n c\n"},
+		{
+			name: "from empty",
+			from: "",
+			to:   "a\n",
+			expected: "--- from\n+++ to\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+a\n"},
+		{
+			name: "no new line at the end",
+			from: "a\nb",
+			to:   "a\nc",
+			expected: "--- from\n+++ to\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				" a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"},
+		{
+			name: "separate hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			to:   "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			expected: "--- from\n+++ to\n" +
+				"@@ -1,3 +1,4 @@\n" +
+				"+0\n 1\n 2\n 3\n" +
+				"@@ -9,4 +10,3 @@\n" +
+				" 9\n 10\n 11\n-12\n"},
+		{
+			name: "merged hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n",
+			to:   "1\nx\n3\n4\n5\n6\ny\n",
+			expected: "--- from\n+++ to\n" +
+				"@@ -1,7 +1,7 @@\n" +
+				" 1\n-2\n+x\n 3\n 4\n 5\n 6\n-7\n+y\n"},
+	}
+
+	for _, c := range cases {
+		got := diff.Unified("from", "to", c.from, c.to)
+		if got != c.expected {
+			t.Errorf("Failed at case %#v: expected:\n%s\ngot:\n%s", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestEdits(t *testing.T) {
+	type TestCase struct {
+		name     string
+		from     string
+		to       string
+		expected []diff.Edit
+	}
+
+	cases := []TestCase{
+		{
+			name: "equal",
+			from: "a\nb\n",
+			to:   "a\nb\n"},
+		{
+			name:     "insertion",
+			from:     "a\nb\nc\n",
+			to:       "a\nb\nx\ny\nc\n",
+			expected: []diff.Edit{{Start: 2, End: 2, NewText: "x\ny\n"}}},
+		{
+			name:     "deletion",
+			from:     "a\nb\nc\n",
+			to:       "a\nc\n",
+			expected: []diff.Edit{{Start: 1, End: 2}}},
+		{
+			name:     "replacement",
+			from:     "a\nb\nc\n",
+			to:       "a\nx\nc\n",
+			expected: []diff.Edit{{Start: 1, End: 2, NewText: "x\n"}}},
+		{
+			name: "separate edits",
+			from: "1\n2\n3\n4\n",
+			to:   "0\n1\n2\n3\n",
+			expected: []diff.Edit{
+				{Start: 0, End: 0, NewText: "0\n"},
+				{Start: 3, End: 4}}},
+	}
+
+	for _, c := range cases {
+		got := diff.Edits(c.from, c.to)
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("Failed at case %#v: expected %#v, got %#v", c.name, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package gocontracts
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/Parquery/gocontracts/parsecomment"
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// isDirective mirrors go/ast in deciding whether the text of a //-style comment (without the slashes)
+// is a directive such as //go:generate or //line.
+func isDirective(c string) bool {
+	if strings.HasPrefix(c, "line ") || strings.HasPrefix(c, "extern ") || strings.HasPrefix(c, "export ") {
+		return true
+	}
+
+	colon := strings.Index(c, ":")
+	if colon <= 0 || colon+1 >= len(c) {
+		return false
+	}
+
+	for i := 0; i <= colon+1; i++ {
+		if i == colon {
+			continue
+		}
+
+		b := c[i]
+		if !('a' <= b && b <= 'z' || '0' <= b && b <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// commentLines splits the text of the comment group into lines exactly as strings.Split(cg.Text(), "\n")
+// would, but additionally gives the line in the file of each split line.
+func commentLines(fset *token.FileSet, cg *ast.CommentGroup) (lines []string, fileLines []int) {
+	if cg == nil {
+		return []string{""}, []int{0}
+	}
+
+	for _, c := range cg.List {
+		text := c.Text
+		line := fset.Position(c.Pos()).Line
+
+		switch text[1] {
+		case '/':
+			text = text[2:]
+			if len(text) > 0 && text[0] == ' ' {
+				text = text[1:]
+			} else if len(text) > 0 && isDirective(text) {
+				continue
+			}
+		case '*':
+			text = text[2 : len(text)-2]
+		}
+
+		for i, l := range strings.Split(text, "\n") {
+			lines = append(lines, strings.TrimRight(l, " \t\n\r"))
+			fileLines = append(fileLines, line+i)
+		}
+	}
+
+	// Remove the leading empty lines and collapse the runs of empty lines as ast.CommentGroup.Text does.
+	n := 0
+	for i, l := range lines {
+		if l != "" || n > 0 && lines[n-1] != "" {
+			lines[n] = l
+			fileLines[n] = fileLines[i]
+			n++
+		}
+	}
+	lines = lines[:n]
+	fileLines = fileLines[:n]
+
+	if n > 0 && lines[n-1] != "" {
+		lines = append(lines, "")
+		fileLines = append(fileLines, fileLines[n-1]+1)
+	}
+
+	if n == 0 {
+		lines = []string{""}
+		fileLines = []int{fset.Position(cg.Pos()).Line}
+	}
+
+	return
+}
+
+// locateConditions maps the indices of the comment lines of the conditions to the lines in the file.
+func locateConditions(conditions []parsecond.Condition, fileLines []int) {
+	for i := range conditions {
+		conditions[i].Line = fileLines[conditions[i].Line]
+	}
+}
+
+// locateContract maps the indices of the comment lines in the contract to the lines in the file.
+func locateContract(contract *parsecomment.Contract, fileLines []int) {
+	locateConditions(contract.Pres, fileLines)
+	locateConditions(contract.Posts, fileLines)
+
+	if contract.Preamble != "" {
+		contract.PreambleLine = fileLines[contract.PreambleLine]
+	}
+}
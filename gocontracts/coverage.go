@@ -0,0 +1,303 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Parquery/gocontracts/contracts"
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// coverageVar is the name of the variable holding the coverage registry of the package.
+const coverageVar = "contractsCoverage"
+
+// coverageFileName is the name of the generated file declaring the coverage registry of the package.
+const coverageFileName = "contracts_coverage.go"
+
+// coveredCode generates the code of the check which counts the evaluations of the condition in
+// the coverage registry.
+//
+// The code corresponds to check.Code: every element of a quantified condition counts as an evaluation.
+func coveredCode(code parsecond.Condition, documented parsecond.Condition, s site) string {
+	cond := fmt.Sprintf("%s.Check(%s, %s, %d, %s)", coverageVar, strconv.Quote(s.function),
+		strconv.Quote(s.fileOf(documented)), documented.Line, strings.Trim(code.CondStr, " \t"))
+
+	switch {
+	case code.Quantifier == nil:
+		notCond := guarded("!"+cond, code.Family)
+		if code.InitStr == "" {
+			return notCond
+		}
+
+		return fmt.Sprintf("%s; %s", code.InitStr, notCond)
+
+	case code.Quantifier.Exists:
+		return cond
+
+	default:
+		return "!" + cond
+	}
+}
+
+// instrumented checks whether the body of the function counts the evaluations of its conditions.
+func instrumented(fn *ast.FuncDecl) bool {
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "Check" {
+			if ident, isIdent := sel.X.(*ast.Ident); isIdent && ident.Name == coverageVar {
+				found = true
+			}
+		}
+
+		return !found
+	})
+
+	return found
+}
+
+// coverageCounters generates the entries of the coverage registry for the instrumented functions of the file.
+//
+// If the file can not be parsed or belongs to an external test package, the counters are empty.
+func coverageCounters(text string, filename string, scope *packageScope) (pkgName string, counters []string) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, filename, text, parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	if strings.HasSuffix(filename, "_test.go") && strings.HasSuffix(node.Name.Name, "_test") {
+		return
+	}
+
+	pkgName = node.Name.Name
+
+	siblings := parseSiblings(fset, node, filename, scope)
+
+	inh, err := packageInheritance(fset, node, siblings)
+	if err != nil {
+		return
+	}
+
+	updates, err := collectUpdates(fset, node, false, inh, siblingInvariants(fset, siblings))
+	if err != nil {
+		return
+	}
+
+	for _, up := range stripDocOnly(updates) {
+		if !instrumented(up.fn) {
+			continue
+		}
+
+		s := site{function: funcName(up.fn), file: filepath.Base(filename)}
+
+		groups := []struct {
+			kind       string
+			conditions []parsecond.Condition
+		}{
+			{kind: "Precondition", conditions: up.contractInDoc.Pres},
+			{kind: "Invariant", conditions: up.invariants},
+			{kind: "Postcondition", conditions: up.contractInDoc.Posts}}
+
+		for _, group := range groups {
+			for _, c := range group.conditions {
+				fields := []string{
+					fmt.Sprintf("Kind: %s.%s", runtimePackage, group.kind),
+					fmt.Sprintf("Function: %s", strconv.Quote(s.function))}
+
+				if c.Label != "" {
+					fields = append(fields, fmt.Sprintf("Label: %s", strconv.Quote(c.Label)))
+				}
+
+				fields = append(fields,
+					fmt.Sprintf("Condition: %s", strconv.Quote(c.Text())),
+					fmt.Sprintf("File: %s", strconv.Quote(s.fileOf(c))),
+					fmt.Sprintf("Line: %d", c.Line))
+
+				counters = append(counters, "{"+strings.Join(fields, ", ")+"}")
+			}
+		}
+	}
+
+	return
+}
+
+// coverageFileCode generates the code of the file declaring the coverage registry of the package.
+func coverageFileCode(pkgName string, counters []string) (string, error) {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by gocontracts. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+	fmt.Fprintf(&b, "import %s\n\n", strconv.Quote(runtimeImportPath))
+	fmt.Fprintf(&b, "// %s counts the evaluations of the instrumented conditions of the package.\n", coverageVar)
+	fmt.Fprintf(&b, "var %s = %s.NewCoverage(%s, []%s.Counter{\n", coverageVar, runtimePackage,
+		strconv.Quote(pkgName), runtimePackage)
+
+	for _, counter := range counters {
+		b.WriteString(counter + ",\n")
+	}
+
+	b.WriteString("})\n")
+
+	code, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format the coverage registry: %s", err)
+	}
+
+	return string(code), nil
+}
+
+// coverageFile generates the file declaring the coverage registry of the package in the directory if
+// the conditions are instrumented.
+//
+// The registry lists the conditions of all the instrumented functions of the package so that the conditions
+// which are never evaluated show up in the profile.
+// If the conditions are removed or there is nothing to register, no file is generated.
+func coverageFile(dir string, results []Result, opts Options, scope *packageScope) (coverageResults []Result) {
+	if !opts.Coverage || opts.Remove {
+		return
+	}
+
+	pkgName := ""
+	counters := []string{}
+	for _, file := range packageTexts(results, scope) {
+		name, fileCounters := coverageCounters(file.text, file.path, scope)
+		if name != "" {
+			pkgName = name
+		}
+
+		counters = append(counters, fileCounters...)
+	}
+
+	pth := filepath.Join(dir, coverageFileName)
+	if pkgName == "" || (len(counters) == 0 && !exists(pth)) {
+		return
+	}
+
+	code, err := coverageFileCode(pkgName, counters)
+	if err != nil {
+		coverageResults = []Result{{Path: pth, Err: err}}
+		return
+	}
+
+	result := generatedFileResult(pth, code)
+	result.Generated = true
+
+	coverageResults = []Result{result}
+	return
+}
+
+// ReadCoverageProfiles reads the profiles written by contracts.WriteProfile and merges them.
+//
+// The counters of the same condition in different profiles (e.g., of different test binaries) are summed up.
+// The counters are sorted by package, file, line and function.
+func ReadCoverageProfiles(paths []string) (counters []contracts.Counter, err error) {
+	type key struct {
+		pkg      string
+		file     string
+		line     int
+		function string
+		kind     contracts.Kind
+	}
+
+	index := make(map[key]int)
+	for _, pth := range paths {
+		var f *os.File
+		f, err = os.Open(pth)
+		if err != nil {
+			return
+		}
+
+		var profile []contracts.Counter
+		profile, err = contracts.ReadProfile(f)
+
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			err = fmt.Errorf("failed to read the profile %s: %s", pth, err)
+			return
+		}
+
+		for _, counter := range profile {
+			k := key{pkg: counter.Package, file: counter.File, line: counter.Line, function: counter.Function,
+				kind: counter.Kind}
+
+			if i, ok := index[k]; ok {
+				counters[i].Evaluated += counter.Evaluated
+				counters[i].Passed += counter.Passed
+				continue
+			}
+
+			index[k] = len(counters)
+			counters = append(counters, counter)
+		}
+	}
+
+	sort.SliceStable(counters, func(i, j int) bool {
+		a, b := counters[i], counters[j]
+		switch {
+		case a.Package != b.Package:
+			return a.Package < b.Package
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		default:
+			return a.Function < b.Function
+		}
+	})
+
+	return
+}
+
+// ReportCoverage writes a line for each condition with its position, function, kind, label and condition
+// followed by the number of evaluations and failures. The conditions which were never evaluated are marked
+// with "NEVER EVALUATED". The last line gives the percentage of the evaluated conditions.
+func ReportCoverage(w io.Writer, counters []contracts.Counter) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	evaluated := 0
+	for _, counter := range counters {
+		condition := counter.Condition
+		if counter.Label != "" {
+			condition = counter.Label + ": " + condition
+		}
+
+		result := "NEVER EVALUATED"
+		if counter.Evaluated > 0 {
+			evaluated++
+			result = fmt.Sprintf("%d evaluated, %d failed", counter.Evaluated, counter.Evaluated-counter.Passed)
+		}
+
+		_, err := fmt.Fprintf(tw, "%s/%s:%d:\t%s\t%s\t%s\t%s\n", counter.Package, counter.File, counter.Line,
+			counter.Function, counter.Kind, condition, result)
+		if err != nil {
+			return err
+		}
+	}
+
+	percentage := 0.0
+	if len(counters) > 0 {
+		percentage = 100 * float64(evaluated) / float64(len(counters))
+	}
+
+	_, err := fmt.Fprintf(tw, "total:\t\t\t\t%.1f%% (%d of %d conditions evaluated)\n", percentage, evaluated,
+		len(counters))
+	if err != nil {
+		return err
+	}
+
+	return tw.Flush()
+}
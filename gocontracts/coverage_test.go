@@ -0,0 +1,68 @@
+package gocontracts
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Parquery/gocontracts/contracts"
+)
+
+func TestReadCoverageProfiles(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "coverage_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{
+		"first.out": "mode: contracts\n" +
+			"somepkg\tsome.go\t9\tpost-condition\tSomeFunc\t\"\"\t\"result != \\\"\\\"\"\t0\t0\n" +
+			"somepkg\tsome.go\t6\tpre-condition\tSomeFunc\t\"positive\"\t\"x > 0\"\t3\t2\n",
+		"second.out": "mode: contracts\n" +
+			"somepkg\tsome.go\t6\tpre-condition\tSomeFunc\t\"positive\"\t\"x > 0\"\t2\t2\n",
+		"broken.out": "mode: set\n"})
+
+	counters, err := ReadCoverageProfiles(
+		[]string{filepath.Join(tmpdir, "first.out"), filepath.Join(tmpdir, "second.out")})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	buf := &bytes.Buffer{}
+	err = ReportCoverage(buf, counters)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := "somepkg/some.go:6:  SomeFunc  pre-condition   positive: x > 0  5 evaluated, 1 failed\n" +
+		"somepkg/some.go:9:  SomeFunc  post-condition  result != \"\"     NEVER EVALUATED\n" +
+		"total:                                                         50.0% (1 of 2 conditions evaluated)\n"
+	if buf.String() != expected {
+		t.Fatalf("Expected the report %#v, got %#v", expected, buf.String())
+	}
+
+	_, err = ReadCoverageProfiles([]string{filepath.Join(tmpdir, "broken.out")})
+	if err == nil {
+		t.Fatal("Expected an error on the broken profile, but got nil")
+	}
+}
+
+func TestReportCoverage_Empty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := ReportCoverage(buf, []contracts.Counter{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if buf.String() != "total:        0.0% (0 of 0 conditions evaluated)\n" {
+		t.Fatalf("Unexpected report: %#v", buf.String())
+	}
+}
@@ -0,0 +1,134 @@
+package gocontracts
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+)
+
+// declPos gives the position where the problems with the contract of the declaration are reported:
+// the start of its documentation or, if undocumented, the declaration itself.
+func declPos(fset *token.FileSet, decl ast.Decl) token.Position {
+	var doc *ast.CommentGroup
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		doc = d.Doc
+	case *ast.GenDecl:
+		doc = d.Doc
+	}
+
+	if doc != nil {
+		return fset.Position(doc.Pos())
+	}
+
+	return fset.Position(decl.Pos())
+}
+
+// Diagnose reports the problems with the contracts of the file as Process would, but does not stop at
+// the first problem.
+//
+// The syntax errors are reported at their positions. The problems with parsing the documentation and
+// the condition checks are reported at the declarations they concern. If there are none, the file is processed
+// and its problems are reported as well. The contracts are not type-checked.
+func Diagnose(text string, filename string, opts Options) Diagnostics {
+	return diagnose(text, filename, opts, nil)
+}
+
+// DiagnoseInPackage reports the problems as Diagnose does, but processes the file in the context of the other
+// files of its package in the directory of the file as ProcessInPackage does.
+func DiagnoseInPackage(text string, filename string, opts Options) Diagnostics {
+	return diagnose(text, filename, opts, newPackageScope(siblingFiles(filename)))
+}
+
+// diagnose reports the problems with the contracts of the file. The scope gives the names declared in the other
+// files of the package. It can be nil.
+func diagnose(text string, filename string, opts Options, scope *packageScope) (diagnostics Diagnostics) {
+	diagnostics = Diagnostics{}
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, filename, text, parser.ParseComments)
+	if err != nil {
+		if errList, ok := err.(scanner.ErrorList); ok {
+			for _, e := range errList {
+				diagnostics = append(diagnostics, Diagnostic{Position: e.Pos, Message: e.Msg})
+			}
+		} else {
+			diagnostics = append(diagnostics, Diagnostic{
+				Position: token.Position{Filename: filename, Line: 1}, Message: err.Error()})
+		}
+
+		return
+	}
+
+	if opts.Remove {
+		// Only the condition checks in the function bodies need to be parsed to remove them.
+		_, _, err = process(text, filename, opts, scope)
+		if err != nil {
+			diagnostics = append(diagnostics, asDiagnostics(err, filename)...)
+		}
+
+		return
+	}
+
+	// Check every declaration on its own so that all the problems are reported. The functions are checked
+	// together with the type declarations so that the invariants are taken into account.
+	single := *node
+
+	typeDecls := []ast.Decl{}
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		single.Decls = []ast.Decl{decl}
+
+		_, err = collectInvariants(fset, &single)
+		if err == nil {
+			_, err = collectInterfaces(fset, &single)
+		}
+
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{Position: declPos(fset, decl), Message: err.Error()})
+			continue
+		}
+
+		typeDecls = append(typeDecls, decl)
+	}
+
+	for _, decl := range node.Decls {
+		// The other declarations are diagnosed only if they assign function literals to variables.
+		if genDecl, ok := decl.(*ast.GenDecl); ok && len(literalFuncs(genDecl)) == 0 {
+			continue
+		}
+
+		single.Decls = append(append([]ast.Decl{}, typeDecls...), decl)
+
+		_, err = collectUpdates(fset, &single, false, nil, nil)
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{Position: declPos(fset, decl), Message: err.Error()})
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		return
+	}
+
+	_, _, err = process(text, filename, opts, scope)
+	if err != nil {
+		diagnostics = append(diagnostics, asDiagnostics(err, filename)...)
+	}
+
+	return
+}
+
+// asDiagnostics converts the error of processing a file to diagnostics. The errors without a position are
+// reported on the first line of the file.
+func asDiagnostics(err error, filename string) Diagnostics {
+	if diagnostics, ok := err.(Diagnostics); ok {
+		return diagnostics
+	}
+
+	return Diagnostics{{Position: token.Position{Filename: filename, Line: 1}, Message: err.Error()}}
+}
@@ -0,0 +1,109 @@
+package gocontracts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	text := `package somepkg
+
+// SomeStruct represents something.
+//
+// SomeStruct invariants:
+//  * s.x >
+type SomeStruct struct {
+	x int
+}
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x >
+func SomeFunc(x int) {}
+
+// AnotherFunc does something.
+//
+// AnotherFunc requires:
+//  * x > 0
+func AnotherFunc(x int) {
+	// Pre-condition
+	x++
+}
+
+// ValidFunc does something.
+//
+// ValidFunc requires:
+//  * x > 0
+func ValidFunc(x int) {}
+`
+
+	diagnostics := Diagnose(text, "some.go", Options{})
+
+	lines := []int{}
+	for _, d := range diagnostics {
+		lines = append(lines, d.Position.Line)
+	}
+
+	if len(lines) != 3 || lines[0] != 3 || lines[1] != 11 || lines[2] != 17 {
+		t.Fatalf("Expected diagnostics on the lines 3, 11 and 17, got %#v", diagnostics)
+	}
+
+	if !strings.HasPrefix(diagnostics[0].Message, "failed to parse comments of the type SomeStruct") ||
+		!strings.HasPrefix(diagnostics[1].Message, "failed to parse comments of the function SomeFunc") ||
+		!strings.HasPrefix(diagnostics[2].Message, "expected an 'if' or a 'for' statement after the comment") {
+		t.Fatalf("Unexpected diagnostics: %#v", diagnostics)
+	}
+}
+
+func TestDiagnose_SyntaxError(t *testing.T) {
+	diagnostics := Diagnose("package somepkg\n\nfunc SomeFunc( {\n}\n", "some.go", Options{})
+
+	if len(diagnostics) == 0 || diagnostics[0].Position.Line != 3 {
+		t.Fatalf("Expected a syntax error on the line 3, got %#v", diagnostics)
+	}
+}
+
+func TestDiagnose_Processing(t *testing.T) {
+	text := `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * result > 0
+func SomeFunc() int {
+	return 1
+}
+`
+
+	diagnostics := Diagnose(text, "some.go", Options{})
+	if len(diagnostics) != 1 || diagnostics[0].Position.Line != 6 {
+		t.Fatalf("Expected a diagnostic about the unnamed result on the line 6, got %#v", diagnostics)
+	}
+
+	diagnostics = Diagnose(text, "some.go", Options{NameResults: true})
+	if len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics if the results are named automatically, got %#v", diagnostics)
+	}
+}
+
+func TestDiagnose_FuncLiteral(t *testing.T) {
+	text := `package somepkg
+
+var (
+	// Parse parses the text.
+	//
+	// Parse requires:
+	//  * s !=
+	Parse = func(s string) int {
+		return len(s)
+	}
+)
+`
+
+	diagnostics := Diagnose(text, "some.go", Options{})
+	if len(diagnostics) != 1 || diagnostics[0].Position.Line != 3 {
+		t.Fatalf("Expected a diagnostic about the condition of Parse at the declaration on the line 3, got %#v",
+			diagnostics)
+	}
+}
@@ -0,0 +1,87 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// directivePrefix starts the comments which configure the processing of a single file.
+//
+// The directives are written without a space after the slashes like the directives of the go tool
+// (e.g., "//gocontracts:disable") so that they do not show up in the documentation.
+const directivePrefix = "//gocontracts:"
+
+// applyDirectives overrides the options with the directives given in the comments of the file.
+//
+// The directive "//gocontracts:disable" removes the condition checks from the file and does not generate any
+// (as if the file were processed with Options.Remove). The directive "//gocontracts:handler <spec>" sets
+// the Options.Handler and "//gocontracts:name-results" sets the Options.NameResults.
+func applyDirectives(fset *token.FileSet, node *ast.File, opts Options) (result Options, err error) {
+	result = opts
+
+	handlerLine := 0
+	for _, group := range node.Comments {
+		for _, comment := range group.List {
+			if !strings.HasPrefix(comment.Text, directivePrefix) {
+				continue
+			}
+
+			line := fset.Position(comment.Pos()).Line
+			fields := strings.Fields(strings.TrimPrefix(comment.Text, directivePrefix))
+
+			name := ""
+			if len(fields) > 0 {
+				name = fields[0]
+			}
+
+			switch {
+			case name == "disable" && len(fields) == 1:
+				result.Remove = true
+
+			case name == "name-results" && len(fields) == 1:
+				result.NameResults = true
+
+			case name == "handler" && len(fields) == 2:
+				if handlerLine != 0 {
+					err = fmt.Errorf("the directive %s on line %d repeats the handler given on line %d",
+						comment.Text, line, handlerLine)
+					return
+				}
+
+				handlerLine = line
+				result.Handler = fields[1]
+
+			case name == "disable" || name == "name-results":
+				err = fmt.Errorf("expected no arguments in the directive %s on line %d", comment.Text, line)
+				return
+
+			case name == "handler":
+				err = fmt.Errorf("expected a single handler in the directive %s on line %d", comment.Text, line)
+				return
+
+			default:
+				err = fmt.Errorf("unknown directive %s on line %d; expected %sdisable, %shandler or %sname-results",
+					comment.Text, line, directivePrefix, directivePrefix, directivePrefix)
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// directiveOptions parses the file and overrides the options with its directives.
+// The syntax errors are left to the processing to report.
+func directiveOptions(text string, filename string, opts Options) (result Options, err error) {
+	fset := token.NewFileSet()
+	node, parseErr := parser.ParseFile(fset, filename, text, parser.ParseComments)
+	if parseErr != nil {
+		result = opts
+		return
+	}
+
+	return applyDirectives(fset, node, opts)
+}
@@ -0,0 +1,369 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// Position locates a character in a file.
+type Position struct {
+	// Offset is the byte offset starting at 0.
+	Offset int `json:"offset"`
+
+	// Line starts at 1.
+	Line int `json:"line"`
+
+	// Column is the byte offset in the line starting at 1.
+	Column int `json:"column"`
+}
+
+// Span locates a range of characters in a file. The end is exclusive.
+type Span struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// ConditionInfo describes a condition as documented.
+type ConditionInfo struct {
+	Label  string `json:"label,omitempty"`
+	Family string `json:"family,omitempty"`
+
+	// Quantifier is given as "forall|exists key[, value] in collection".
+	Quantifier string `json:"quantifier,omitempty"`
+
+	Init      string `json:"init,omitempty"`
+	Condition string `json:"condition"`
+
+	// Line is the line of the condition in the file.
+	Line int `json:"line"`
+}
+
+// FunctionContract describes the contract of a function, a method or an interface method.
+type FunctionContract struct {
+	// Function is the name of the function qualified by the receiver type or the interface if any
+	// (e.g., "SomeType.SomeMethod").
+	Function string `json:"function"`
+
+	// Interface is set if the function is a method of an interface.
+	Interface bool `json:"interface,omitempty"`
+
+	// Declaration locates the declaration of the function.
+	Declaration Span `json:"declaration"`
+
+	// Doc locates the documentation of the function.
+	Doc *Span `json:"doc,omitempty"`
+
+	Requires []ConditionInfo `json:"requires"`
+	Ensures  []ConditionInfo `json:"ensures"`
+
+	// ReturnErrors is set if the violated pre-conditions are returned as errors.
+	ReturnErrors bool `json:"return_errors,omitempty"`
+
+	// Checks is the marker of the contracts ("doc-only" or "checked"), if any.
+	Checks string `json:"checks,omitempty"`
+
+	// Sample is N if the conditions are checked only on every N-th call ("sample 1/N"). It is zero if
+	// the conditions are checked on every call.
+	Sample int `json:"sample,omitempty"`
+
+	Preamble string `json:"preamble,omitempty"`
+
+	// PreambleLine is the line in the file where the preamble starts. It is zero if there is no preamble.
+	PreambleLine int `json:"preamble_line,omitempty"`
+
+	// Generated locates the condition checks in the function body. It is nil if there are no checks.
+	Generated *Span `json:"generated,omitempty"`
+}
+
+// TypeContract describes the invariants of a type.
+type TypeContract struct {
+	Type string `json:"type"`
+
+	// Declaration locates the declaration of the type.
+	Declaration Span `json:"declaration"`
+
+	Invariants []ConditionInfo `json:"invariants"`
+}
+
+// Extraction bundles the contracts extracted from a single file.
+type Extraction struct {
+	Path      string             `json:"path"`
+	Functions []FunctionContract `json:"functions"`
+	Types     []TypeContract     `json:"types"`
+
+	// Error is set if the contracts could not be extracted.
+	Error string `json:"error,omitempty"`
+}
+
+// newPosition converts the position in the file set to a position in the file.
+func newPosition(fset *token.FileSet, pos token.Pos) Position {
+	position := fset.Position(pos)
+	return Position{Offset: position.Offset, Line: position.Line, Column: position.Column}
+}
+
+// newSpan converts the range in the file set to a span in the file.
+func newSpan(fset *token.FileSet, start token.Pos, end token.Pos) Span {
+	return Span{Start: newPosition(fset, start), End: newPosition(fset, end)}
+}
+
+// docSpan locates the documentation. If there is no documentation, the span is nil.
+func docSpan(fset *token.FileSet, doc *ast.CommentGroup) *Span {
+	if doc == nil {
+		return nil
+	}
+
+	span := newSpan(fset, doc.Pos(), doc.End())
+	return &span
+}
+
+// newExtraction creates an empty extraction of the file.
+func newExtraction(pth string) Extraction {
+	return Extraction{Path: pth, Functions: []FunctionContract{}, Types: []TypeContract{}}
+}
+
+// conditionInfos describes the conditions as documented.
+func conditionInfos(conditions []parsecond.Condition) []ConditionInfo {
+	infos := make([]ConditionInfo, 0, len(conditions))
+	for _, c := range conditions {
+		info := ConditionInfo{
+			Label:     c.Label,
+			Family:    c.Family,
+			Init:      c.InitStr,
+			Condition: c.CondStr,
+			Line:      c.Line}
+
+		if c.Quantifier != nil {
+			info.Quantifier = c.Quantifier.String()
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+// Extract parses the contracts documented in the file without modifying it.
+//
+// The functions and the interface methods are listed if they have a contract or if their bodies contain
+// condition checks. The types are listed if they have invariants. The inherited contracts are not merged into
+// the contracts of the implementations.
+func Extract(text string, filename string) (extraction Extraction, err error) {
+	extraction = newExtraction(filename)
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, filename, text, parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	invariants, err := collectInvariants(fset, node)
+	if err != nil {
+		return
+	}
+
+	interfaces, err := collectInterfaces(fset, node)
+	if err != nil {
+		return
+	}
+
+	contractedMethods := make(map[string][]interfaceMethod)
+	for _, ci := range interfaces {
+		contractedMethods[ci.name] = ci.contracted
+	}
+
+	updates, err := collectUpdates(fset, node, false, nil, nil)
+	if err != nil {
+		return
+	}
+
+	// The function literals are represented by new declarations on every call of literalFuncs, but the declarations
+	// share the bodies of the literals.
+	updateOf := make(map[*ast.BlockStmt]funcUpdate)
+	for _, up := range updates {
+		updateOf[up.fn.Body] = up
+	}
+
+	appendFunction := func(fn *ast.FuncDecl, start token.Pos) {
+		up, ok := updateOf[fn.Body]
+		if !ok || (len(up.contractInDoc.Pres) == 0 && len(up.contractInDoc.Posts) == 0 &&
+			up.contractInDoc.Preamble == "" && up.contractInBody.Start == token.NoPos) {
+			// Functions are updated to check only the invariants which are listed with the types.
+			return
+		}
+
+		fc := FunctionContract{
+			Function:     funcName(fn),
+			Declaration:  newSpan(fset, start, fn.End()),
+			Doc:          docSpan(fset, fn.Doc),
+			Requires:     conditionInfos(up.contractInDoc.Pres),
+			Ensures:      conditionInfos(up.contractInDoc.Posts),
+			ReturnErrors: up.contractInDoc.ReturnErrors && len(up.contractInDoc.Pres) > 0,
+			Checks:       up.contractInDoc.Checks.String(),
+			Sample:       up.contractInDoc.Sample,
+			Preamble:     up.contractInDoc.Preamble}
+
+		if fc.Preamble != "" {
+			fc.PreambleLine = up.contractInDoc.PreambleLine
+		}
+
+		if up.contractInBody.Start != token.NoPos {
+			span := newSpan(fset, up.contractInBody.Start, up.contractInBody.End)
+			fc.Generated = &span
+		}
+
+		extraction.Functions = append(extraction.Functions, fc)
+	}
+
+	// Follow the order of the declarations.
+	for _, decl := range node.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			appendFunction(d, d.Pos())
+
+		case *ast.GenDecl:
+			if d.Tok == token.VAR {
+				// The declaration of a function literal spans from the name of its variable to its body.
+				for _, fn := range literalFuncs(d) {
+					appendFunction(fn, fn.Name.Pos())
+				}
+
+				continue
+			}
+
+			if d.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range d.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				name := typeSpec.Name.Name
+
+				if invs := invariants[name]; len(invs) > 0 {
+					extraction.Types = append(extraction.Types, TypeContract{
+						Type:        name,
+						Declaration: newSpan(fset, typeSpec.Pos(), typeSpec.End()),
+						Invariants:  conditionInfos(invs)})
+				}
+
+				for _, m := range contractedMethods[name] {
+					extraction.Functions = append(extraction.Functions, FunctionContract{
+						Function:     name + "." + m.name,
+						Interface:    true,
+						Declaration:  newSpan(fset, m.field.Names[0].Pos(), m.field.End()),
+						Doc:          docSpan(fset, m.field.Doc),
+						Requires:     conditionInfos(m.contract.Pres),
+						Ensures:      conditionInfos(m.contract.Posts),
+						ReturnErrors: m.contract.ReturnErrors && len(m.contract.Pres) > 0})
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// extractFile loads the file and extracts its contracts. The errors are recorded in the extraction.
+//
+// If skipGenerated is set, the generated files are not extracted and skip is set.
+func extractFile(pth string, skipGenerated bool) (extraction Extraction, skip bool) {
+	data, err := ioutil.ReadFile(pth)
+	if err != nil {
+		extraction = newExtraction(pth)
+		extraction.Error = fmt.Sprintf("failed to read: %s", err)
+		return
+	}
+
+	text := string(data)
+	if skipGenerated && isGenerated(text, pth) {
+		skip = true
+		return
+	}
+
+	extraction, err = Extract(text, pth)
+	if err != nil {
+		extraction.Error = err.Error()
+	}
+
+	return
+}
+
+// extractDir extracts the contracts of all the Go files of the package in the directory.
+//
+// The files excluded by the build constraints and the generated files are skipped.
+func extractDir(dir string) (extractions []Extraction, err error) {
+	paths, err := packageFiles(dir)
+	if err != nil {
+		return
+	}
+
+	extractions = make([]Extraction, 0, len(paths))
+	for _, pth := range paths {
+		extraction, skip := extractFile(pth, true)
+		if !skip {
+			extractions = append(extractions, extraction)
+		}
+	}
+
+	return
+}
+
+// ExtractPackages extracts the contracts of the Go files given as patterns.
+//
+// The patterns are resolved as in ProcessPackages. The errors in individual files are recorded in
+// the extractions and do not abort the extraction.
+func ExtractPackages(patterns []string) (extractions []Extraction, err error) {
+	for _, pattern := range patterns {
+		switch {
+		case pattern == "..." || strings.HasSuffix(pattern, "/..."):
+			root := strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+			if root == "" {
+				root = "."
+			}
+
+			err = walkPackages(root, func(dir string) {
+				dirExtractions, dirErr := extractDir(dir)
+				if dirErr != nil {
+					extraction := newExtraction(dir)
+					extraction.Error = dirErr.Error()
+					extractions = append(extractions, extraction)
+				} else {
+					extractions = append(extractions, dirExtractions...)
+				}
+			})
+
+		default:
+			var info os.FileInfo
+			info, err = os.Stat(pattern)
+			switch {
+			case err != nil:
+				extraction := newExtraction(pattern)
+				extraction.Error = fmt.Sprintf("failed to read: %s", err)
+				extractions = append(extractions, extraction)
+				err = nil
+
+			case info.IsDir():
+				var dirExtractions []Extraction
+				dirExtractions, err = extractDir(pattern)
+				extractions = append(extractions, dirExtractions...)
+
+			default:
+				// Explicitly given files are always extracted.
+				extraction, _ := extractFile(pattern, false)
+				extractions = append(extractions, extraction)
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,237 @@
+package gocontracts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	text := `package somepkg
+
+// SomeStruct represents something.
+//
+// SomeStruct invariants:
+//  * s.x >= 0
+type SomeStruct struct {
+	x     int
+	items []int
+}
+
+// Shape defines a geometric shape.
+type Shape interface {
+	// Scale scales the shape.
+	//
+	// Scale requires (error):
+	//  * factor > 0
+	Scale(factor float64) error
+}
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * positive: x > 0
+//  * [test] _, ok := m[x]; ok
+//
+// SomeFunc preamble:
+//  y := x
+//
+// SomeFunc ensures:
+//  * forall i in items: items[i] >= y
+func SomeFunc(x int, m map[int]bool, items []int) {
+	// Pre-condition
+	if !(x > 0) {
+		panic("Violated: positive: x > 0")
+	}
+
+	return
+}
+
+// Increase increases x.
+func (s *SomeStruct) Increase(delta int) {
+	s.x += delta
+}
+`
+
+	extraction, err := Extract(text, "some.go")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	names := []string{}
+	for _, fc := range extraction.Functions {
+		names = append(names, fc.Function)
+	}
+
+	expectedNames := []string{"Shape.Scale", "SomeFunc"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("Expected the functions %#v, got %#v", expectedNames, names)
+	}
+
+	if len(extraction.Types) != 1 || extraction.Types[0].Type != "SomeStruct" ||
+		!reflect.DeepEqual(extraction.Types[0].Invariants, []ConditionInfo{{Condition: "s.x >= 0", Line: 6}}) {
+		t.Fatalf("Expected the invariants of SomeStruct, got %#v", extraction.Types)
+	}
+
+	scale := extraction.Functions[0]
+	if !scale.Interface || !scale.ReturnErrors || scale.Declaration.Start.Line != 18 ||
+		scale.Doc == nil || scale.Doc.Start.Line != 14 || scale.Generated != nil {
+		t.Fatalf("Unexpected extraction of the interface method: %#v", scale)
+	}
+
+	someFunc := extraction.Functions[1]
+
+	expectedRequires := []ConditionInfo{
+		{Label: "positive", Condition: "x > 0", Line: 24},
+		{Family: "test", Init: "_, ok := m[x]", Condition: "ok", Line: 25}}
+	if !reflect.DeepEqual(someFunc.Requires, expectedRequires) {
+		t.Fatalf("Expected the pre-conditions %#v, got %#v", expectedRequires, someFunc.Requires)
+	}
+
+	expectedEnsures := []ConditionInfo{{Quantifier: "forall i in items", Condition: "items[i] >= y", Line: 31}}
+	if !reflect.DeepEqual(someFunc.Ensures, expectedEnsures) {
+		t.Fatalf("Expected the post-conditions %#v, got %#v", expectedEnsures, someFunc.Ensures)
+	}
+
+	if someFunc.Preamble != "y := x" || someFunc.PreambleLine != 28 {
+		t.Fatalf("Expected the preamble on line 28, got %#v on line %d", someFunc.Preamble, someFunc.PreambleLine)
+	}
+
+	if someFunc.Declaration.Start.Line != 32 || someFunc.Declaration.End.Line != 39 ||
+		someFunc.Doc == nil || someFunc.Doc.Start.Line != 21 || someFunc.Doc.End.Line != 31 {
+		t.Fatalf("Unexpected positions of the declaration and the documentation: %#v, %#v",
+			someFunc.Declaration, someFunc.Doc)
+	}
+
+	if someFunc.Generated == nil || someFunc.Generated.Start.Line != 33 || someFunc.Generated.End.Line != 36 {
+		t.Fatalf("Expected the condition checks on the lines 33-36, got %#v", someFunc.Generated)
+	}
+
+	if text[someFunc.Generated.Start.Offset:someFunc.Generated.End.Offset] !=
+		"// Pre-condition\n\tif !(x > 0) {\n\t\tpanic(\"Violated: positive: x > 0\")\n\t}" {
+		t.Fatalf("Unexpected offsets of the condition checks: %#v", someFunc.Generated)
+	}
+}
+
+func TestExtract_Error(t *testing.T) {
+	text := `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x >
+func SomeFunc(x int) {}
+`
+
+	extraction, err := Extract(text, "some.go")
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+
+	if extraction.Path != "some.go" || extraction.Functions == nil || extraction.Types == nil {
+		t.Fatalf("Expected an empty extraction, got %#v", extraction)
+	}
+}
+
+func TestExtract_DocOnly(t *testing.T) {
+	text := `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//
+// SomeFunc contracts: doc-only
+func SomeFunc(x int) {}
+`
+
+	extraction, err := Extract(text, "some.go")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(extraction.Functions) != 1 || extraction.Functions[0].Checks != "doc-only" ||
+		len(extraction.Functions[0].Requires) != 1 || extraction.Functions[0].Generated != nil {
+		t.Fatalf("Expected the documented contract of SomeFunc, got %#v", extraction.Functions)
+	}
+}
+
+func TestExtract_FuncLiteral(t *testing.T) {
+	text := `package somepkg
+
+// Parse parses the text.
+//
+// Parse requires:
+//  * s != ""
+var Parse = func(s string) int {
+	return len(s)
+}
+`
+
+	extraction, err := Extract(text, "some.go")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(extraction.Functions) != 1 || extraction.Functions[0].Function != "Parse" ||
+		len(extraction.Functions[0].Requires) != 1 || extraction.Functions[0].Declaration.Start.Line != 7 {
+		t.Fatalf("Expected the documented contract of the variable Parse, got %#v", extraction.Functions)
+	}
+}
+
+func TestExtractPackages(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "extract_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{
+		"some.go": "package somepkg\n\n// SomeFunc does something.\n//\n// SomeFunc requires:\n" +
+			"//  * x > 0\nfunc SomeFunc(x int) {}\n",
+		"generated.go": "// Code generated by some tool. DO NOT EDIT.\n\npackage somepkg\n\n" +
+			"// OtherFunc does something.\n//\n// OtherFunc requires:\n//  * x > 0\nfunc OtherFunc(x int) {}\n",
+		"sub/broken.go": "package sub\n\n// Broken is broken.\n//\n// Broken requires:\n//  * x >\n" +
+			"func Broken(x int) {}\n"})
+
+	extractions, err := ExtractPackages([]string{tmpdir + "/..."})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	paths := []string{}
+	for _, extraction := range extractions {
+		paths = append(paths, extraction.Path)
+	}
+
+	expected := []string{filepath.Join(tmpdir, "some.go"), filepath.Join(tmpdir, "sub", "broken.go")}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected the extractions %#v, got %#v", expected, paths)
+	}
+
+	if len(extractions[0].Functions) != 1 || extractions[0].Error != "" {
+		t.Fatalf("Expected a single function in %s, got %#v", paths[0], extractions[0])
+	}
+
+	if !strings.HasPrefix(extractions[1].Error, "failed to parse comments of the function Broken") {
+		t.Fatalf("Expected an error in %s, got %#v", paths[1], extractions[1].Error)
+	}
+
+	data, err := json.Marshal(extractions[0].Functions[0].Requires)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if string(data) != `[{"condition":"x \u003e 0","line":6}]` {
+		t.Fatalf("Unexpected JSON: %s", data)
+	}
+}
@@ -0,0 +1,189 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// familyConstant gives the name of the constant which enables the conditions of the family (e.g., "InTest").
+func familyConstant(family string) string {
+	r, size := utf8.DecodeRuneInString(family)
+	return "In" + string(unicode.ToUpper(r)) + family[size:]
+}
+
+// guarded guards the negated condition by the constant of its family so that the condition is only checked
+// if the family is enabled. The negated condition is returned as-is if the family is empty.
+func guarded(notCond string, family string) string {
+	if family == "" {
+		return notCond
+	}
+
+	// && binds stronger than ||.
+	if expr, err := parser.ParseExpr(notCond); err == nil {
+		if binary, ok := expr.(*ast.BinaryExpr); ok && binary.Op == token.LOR {
+			notCond = "(" + notCond + ")"
+		}
+	}
+
+	return fmt.Sprintf("%s && %s", familyConstant(family), notCond)
+}
+
+// familiesOf lists the families of the conditions in the contracts of the file.
+//
+// If the file can not be parsed, the families are empty.
+func familiesOf(text string, filename string) (pkgName string, families []string) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, filename, text, parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	pkgName = node.Name.Name
+
+	updates, err := collectUpdates(fset, node, false, nil, nil)
+	if err != nil {
+		return
+	}
+
+	updates = stripDocOnly(updates)
+
+	// The conditions of the interface methods are listed in the file of the interface even though they are
+	// checked in the implementations.
+	interfaces, err := collectInterfaces(fset, node)
+	if err != nil {
+		return
+	}
+
+	groups := [][]parsecond.Condition{}
+	for _, up := range updates {
+		groups = append(groups, up.contractInDoc.Pres, up.invariants, up.contractInDoc.Posts)
+	}
+
+	for _, ci := range interfaces {
+		for _, m := range ci.contracted {
+			groups = append(groups, m.contract.Pres, m.contract.Posts)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, conditions := range groups {
+		for _, c := range conditions {
+			if c.Family != "" && !seen[c.Family] {
+				seen[c.Family] = true
+				families = append(families, c.Family)
+			}
+		}
+	}
+
+	sort.Strings(families)
+	return
+}
+
+// familyFileCode generates the code of the file defining the constant of the family when the family is enabled
+// or disabled, respectively.
+func familyFileCode(pkgName string, family string, enabled bool) string {
+	constraint := family
+	if !enabled {
+		constraint = "!" + family
+	}
+
+	return fmt.Sprintf("// Code generated by gocontracts. DO NOT EDIT.\n\n"+
+		"//go:build %s\n\n"+
+		"package %s\n\n"+
+		"// %s enables the conditions of the contract family %#v. Build with the tag %#v to enable them.\n"+
+		"const %s = %t\n", constraint, pkgName, familyConstant(family), family, family,
+		familyConstant(family), enabled)
+}
+
+// generatedFileResult gives the result of generating the file at the given path.
+func generatedFileResult(pth string, code string) (result Result) {
+	result.Path = pth
+	result.Updated = code
+
+	data, err := ioutil.ReadFile(pth)
+	switch {
+	case os.IsNotExist(err):
+		// The file will be created.
+
+	case err != nil:
+		result.Err = fmt.Errorf("failed to read: %s", err)
+
+	default:
+		result.Text = string(data)
+	}
+
+	return
+}
+
+// exists checks whether the file exists.
+func exists(pth string) bool {
+	_, err := os.Stat(pth)
+	return err == nil
+}
+
+// familyFiles generates the files which define the constants of the families used in the processed files of
+// the package in the directory so that the families can be toggled with the build tags.
+//
+// The files named contracts_{family}_enabled.go and contracts_{family}_disabled.go are generated in
+// the directory. The families whose constants are declared manually in the package are skipped.
+// The external test packages are ignored since they can not share the constants with the package.
+// If the conditions are removed, no files are generated.
+func familyFiles(dir string, results []Result, opts Options, scope *packageScope) (familyResults []Result) {
+	if opts.Remove {
+		return
+	}
+
+	pkgName := ""
+	families := []string{}
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		name, fileFamilies := familiesOf(result.Updated, result.Path)
+		if len(fileFamilies) == 0 ||
+			(strings.HasSuffix(result.Path, "_test.go") && strings.HasSuffix(name, "_test")) {
+			continue
+		}
+
+		pkgName = name
+		for _, family := range fileFamilies {
+			if !seen[family] {
+				seen[family] = true
+				families = append(families, family)
+			}
+		}
+	}
+
+	sort.Strings(families)
+
+	for _, family := range families {
+		enabledPth := filepath.Join(dir, fmt.Sprintf("contracts_%s_enabled.go", family))
+		disabledPth := filepath.Join(dir, fmt.Sprintf("contracts_%s_disabled.go", family))
+
+		if !exists(enabledPth) && !exists(disabledPth) && scope.declared(pkgName, familyConstant(family)) {
+			// The constant is managed by the user.
+			continue
+		}
+
+		enabled := generatedFileResult(enabledPth, familyFileCode(pkgName, family, true))
+		disabled := generatedFileResult(disabledPth, familyFileCode(pkgName, family, false))
+		enabled.Generated, disabled.Generated = true, true
+
+		familyResults = append(familyResults, enabled, disabled)
+	}
+
+	return
+}
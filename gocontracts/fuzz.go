@@ -0,0 +1,266 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// fuzzTypes are the predeclared types which can be fuzzed natively with testing.F.
+var fuzzTypes = map[string]bool{
+	"bool": true, "string": true, "byte": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true}
+
+// fuzzableType checks whether the type can be fuzzed natively. The type is fuzzable if it is a predeclared
+// type (not shadowed in the file) listed in fuzzTypes or []byte.
+func fuzzableType(expr ast.Expr, node *ast.File) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return fuzzTypes[t.Name] && node.Scope.Lookup(t.Name) == nil
+
+	case *ast.ArrayType:
+		elt, ok := t.Elt.(*ast.Ident)
+		return t.Len == nil && ok && elt.Name == "byte" && node.Scope.Lookup(elt.Name) == nil
+
+	default:
+		return false
+	}
+}
+
+// intRange gives the range of the integer type. The sizes of int and uint are assumed to be 32 bits so that
+// the seeds are valid on all the platforms.
+func intRange(typ string) (min constant.Value, max constant.Value, ok bool) {
+	signed := map[string]uint{"int": 32, "int8": 8, "int16": 16, "int32": 32, "rune": 32, "int64": 64}
+	unsigned := map[string]uint{"uint": 32, "uint8": 8, "byte": 8, "uint16": 16, "uint32": 32, "uint64": 64}
+
+	one := constant.MakeInt64(1)
+	if bits, found := signed[typ]; found {
+		max = constant.BinaryOp(constant.Shift(one, token.SHL, bits-1), token.SUB, one)
+		min = constant.UnaryOp(token.SUB, constant.BinaryOp(max, token.ADD, one), 0)
+		ok = true
+		return
+	}
+
+	if bits, found := unsigned[typ]; found {
+		max = constant.BinaryOp(constant.Shift(one, token.SHL, bits), token.SUB, one)
+		min = constant.MakeInt64(0)
+		ok = true
+	}
+
+	return
+}
+
+// seedCode gives the value as an argument of testing.F.Add for a parameter of the given type. If the value can not
+// be represented by the type, ok is false.
+func seedCode(v constant.Value, typ string) (code string, ok bool) {
+	switch typ {
+	case "string":
+		if v.Kind() != constant.String {
+			return
+		}
+
+		return v.ExactString(), true
+
+	case "float32", "float64":
+		if v.Kind() != constant.Int && v.Kind() != constant.Float {
+			return
+		}
+
+		f, _ := constant.Float64Val(v)
+
+		return fmt.Sprintf("%s(%s)", typ, strconv.FormatFloat(f, 'g', -1, 64)), true
+	}
+
+	min, max, isInt := intRange(typ)
+	if !isInt {
+		return
+	}
+
+	v = constant.ToInt(v)
+	if v.Kind() != constant.Int || constant.Compare(v, token.LSS, min) || constant.Compare(v, token.GTR, max) {
+		return
+	}
+
+	return fmt.Sprintf("%s(%s)", typ, v.ExactString()), true
+}
+
+// literalValue evaluates the basic literal, possibly negated.
+func literalValue(expr ast.Expr) (v constant.Value, ok bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		v = constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		return v, v.Kind() != constant.Unknown
+
+	case *ast.ParenExpr:
+		return literalValue(e.X)
+
+	case *ast.UnaryExpr:
+		if e.Op != token.SUB {
+			return
+		}
+
+		v, ok = literalValue(e.X)
+		if ok && v.Kind() != constant.String {
+			return constant.UnaryOp(token.SUB, v, 0), true
+		}
+
+		return nil, false
+
+	default:
+		return
+	}
+}
+
+// mirrored gives the comparison with the swapped operands (e.g., ">" for "<").
+var mirrored = map[token.Token]token.Token{
+	token.LSS: token.GTR, token.GTR: token.LSS, token.LEQ: token.GEQ, token.GEQ: token.LEQ,
+	token.EQL: token.EQL, token.NEQ: token.NEQ}
+
+// boundaries gives the values on both sides of the boundary where the comparison of the parameter
+// with the constant switches (e.g., 99 and 100 for "x < 100"). Only the constant itself is given if the parameter
+// is not an integer.
+func boundaries(op token.Token, v constant.Value, integer bool) []constant.Value {
+	if !integer || op == token.EQL || op == token.NEQ {
+		return []constant.Value{v}
+	}
+
+	one := constant.MakeInt64(1)
+	switch op {
+	case token.LSS, token.GEQ:
+		return []constant.Value{constant.BinaryOp(v, token.SUB, one), v}
+	default:
+		return []constant.Value{v, constant.BinaryOp(v, token.ADD, one)}
+	}
+}
+
+// boundarySeeds derives the seeds of the parameter from the constants which the parameter is compared to
+// in the conditions. The quantified conditions and the conditions with an initialization are ignored.
+func boundarySeeds(conditions []parsecond.Condition, name string, typ string) (seeds []string) {
+	seen := make(map[string]bool)
+
+	for _, c := range conditions {
+		if c.Quantifier != nil || c.InitStr != "" {
+			continue
+		}
+
+		ast.Inspect(c.Cond, func(n ast.Node) bool {
+			bin, ok := n.(*ast.BinaryExpr)
+			if !ok {
+				return true
+			}
+
+			op, isComparison := mirrored[bin.Op]
+			if !isComparison {
+				return true
+			}
+
+			operand, other := bin.X, bin.Y
+			if ident, isIdent := bin.Y.(*ast.Ident); isIdent && ident.Name == name {
+				operand, other = bin.Y, bin.X
+			} else {
+				op = bin.Op
+			}
+
+			if ident, isIdent := operand.(*ast.Ident); !isIdent || ident.Name != name {
+				return true
+			}
+
+			v, isLiteral := literalValue(other)
+			if !isLiteral {
+				return true
+			}
+
+			_, _, integer := intRange(typ)
+			for _, boundary := range boundaries(op, v, integer) {
+				code, representable := seedCode(boundary, typ)
+				if representable && !seen[code] {
+					seen[code] = true
+					seeds = append(seeds, code)
+				}
+			}
+
+			return true
+		})
+	}
+
+	return
+}
+
+// zeroSeed gives the zero value of the fuzzable type as an argument of testing.F.Add.
+func zeroSeed(typ string) string {
+	switch typ {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	case "[]byte":
+		return `[]byte("")`
+	default:
+		return typ + "(0)"
+	}
+}
+
+// fuzzFunc generates the fuzz target of the function.
+//
+// A seed is added for each boundary value of a parameter derived from the pre-conditions, while the other
+// parameters are set to their zero values.
+func fuzzFunc(up funcUpdate, names []string, types []string) string {
+	fn := up.fn
+
+	var b strings.Builder
+
+	fuzzName := "FuzzContract_" + fn.Name.Name
+	fmt.Fprintf(&b, "// %s calls %s with the fuzzed inputs satisfying its pre-conditions so that\n", fuzzName,
+		fn.Name.Name)
+	b.WriteString("// the checks of its post-conditions can catch bugs.\n")
+	fmt.Fprintf(&b, "func %s(f *testing.F) {\n", fuzzName)
+
+	seeded := false
+	for i := range names {
+		for _, seed := range boundarySeeds(up.contractInDoc.Pres, names[i], types[i]) {
+			args := make([]string, 0, len(names))
+			for j := range names {
+				if j == i {
+					args = append(args, seed)
+				} else {
+					args = append(args, zeroSeed(types[j]))
+				}
+			}
+
+			fmt.Fprintf(&b, "f.Add(%s)\n", strings.Join(args, ", "))
+			seeded = true
+		}
+	}
+
+	if seeded {
+		b.WriteString("\n")
+	}
+
+	params := make([]string, 0, len(names))
+	for i := range names {
+		params = append(params, names[i]+" "+types[i])
+	}
+
+	t := unshadowed("t", names)
+
+	fmt.Fprintf(&b, "f.Fuzz(func(%s *testing.T, %s) {\n", t, strings.Join(params, ", "))
+
+	if len(up.contractInDoc.Pres) > 0 {
+		b.WriteString("// Skip the inputs violating the pre-conditions\n")
+		b.WriteString(discardCode(up.contractInDoc.Pres, t+".Skip()"))
+		b.WriteString("\n\n")
+	}
+
+	fmt.Fprintf(&b, "%s(%s)\n", fn.Name.Name, strings.Join(names, ", "))
+	b.WriteString("})\n")
+	b.WriteString("}\n")
+
+	return b.String()
+}
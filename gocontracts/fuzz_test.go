@@ -0,0 +1,119 @@
+package gocontracts
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+func TestBoundarySeeds(t *testing.T) {
+	type testCase struct {
+		condition string
+		typ       string
+		expected  []string
+	}
+
+	testCases := []testCase{
+		{condition: "* x < 100", typ: "int", expected: []string{"int(99)", "int(100)"}},
+		{condition: "* 100 >= x", typ: "int", expected: []string{"int(100)", "int(101)"}},
+		{condition: "* x > 0 && x != -3", typ: "int8", expected: []string{"int8(0)", "int8(1)", "int8(-3)"}},
+		{condition: "* x <= 255", typ: "uint8", expected: []string{"uint8(255)"}},
+		{condition: "* x >= 0", typ: "uint", expected: []string{"uint(0)"}},
+		{condition: "* x != 'z'", typ: "rune", expected: []string{"rune(122)"}},
+		{condition: "* x >= 0.5", typ: "float64", expected: []string{"float64(0.5)"}},
+		{condition: "* x != \"\"", typ: "string", expected: []string{"\"\""}},
+		{condition: "* x > y", typ: "int", expected: nil},
+		{condition: "* v, ok := m[x]; ok && x > 0", typ: "int", expected: nil},
+		{condition: "* forall _, x in xs: x > 0", typ: "int", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		cond, err := parsecond.ToCondition(tc.condition)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		got := boundarySeeds([]parsecond.Condition{*cond}, "x", tc.typ)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Expected the seeds %#v for %#v, got %#v", tc.expected, tc.condition, got)
+		}
+	}
+}
+
+func TestGenerateTests_Fuzz(t *testing.T) {
+	text := `package somepkg
+
+// Parse parses the data.
+//
+// Parse requires:
+//  * ratio >= 0.5
+//
+// Parse ensures:
+//  * result >= 0
+func Parse(data []byte, ratio float32) (result int) {
+	return len(data)
+}
+`
+
+	expected := `// Code generated by gocontracts gentest. DO NOT EDIT.
+
+package somepkg
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+// TestContract_Parse calls Parse with random inputs satisfying its pre-conditions so that
+// the checks of its post-conditions can catch bugs.
+func TestContract_Parse(t *testing.T) {
+	property := func(data []byte, ratio float32) (passed bool) {
+		// Discard the inputs violating the pre-conditions
+		if !(ratio >= 0.5) {
+			return true
+		}
+
+		// Report the violation so that testing/quick reports the inputs
+		defer func() {
+			if r := recover(); r != nil {
+				t.Log(r)
+				passed = false
+			}
+		}()
+
+		Parse(data, ratio)
+		return true
+	}
+
+	err := quick.Check(property, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// FuzzContract_Parse calls Parse with the fuzzed inputs satisfying its pre-conditions so that
+// the checks of its post-conditions can catch bugs.
+func FuzzContract_Parse(f *testing.F) {
+	f.Add([]byte(""), float32(0.5))
+
+	f.Fuzz(func(t *testing.T, data []byte, ratio float32) {
+		// Skip the inputs violating the pre-conditions
+		if !(ratio >= 0.5) {
+			t.Skip()
+		}
+
+		Parse(data, ratio)
+	})
+}
+`
+
+	code, err := GenerateTests(text, "some.go")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if code != expected {
+		t.Fatalf("Expected the generated tests:\n%s\ngot:\n%s", expected, code)
+	}
+}
@@ -0,0 +1,438 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Parquery/gocontracts/parsecomment"
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// quickTypes are the predeclared types for which testing/quick generates random values.
+var quickTypes = map[string]bool{
+	"bool": true, "string": true, "byte": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true}
+
+// simpleType checks whether testing/quick can generate random values of the type. The type is simple if it is
+// a predeclared type (not shadowed in the file) or a slice, an array, a map or a pointer of simple types.
+func simpleType(expr ast.Expr, node *ast.File) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return quickTypes[t.Name] && node.Scope.Lookup(t.Name) == nil
+
+	case *ast.ArrayType:
+		if t.Len != nil {
+			if _, ok := t.Len.(*ast.BasicLit); !ok {
+				return false
+			}
+		}
+
+		return simpleType(t.Elt, node)
+
+	case *ast.MapType:
+		return simpleType(t.Key, node) && simpleType(t.Value, node)
+
+	case *ast.StarExpr:
+		return simpleType(t.X, node)
+
+	default:
+		return false
+	}
+}
+
+// generatedParams lists the parameters of the function together with their types if the values of all of them
+// can be generated (as determined by generatable). The unnamed and blank parameters are named after their position
+// (e.g., "arg0").
+func generatedParams(fset *token.FileSet, text string, fn *ast.FuncDecl, node *ast.File,
+	generatable func(ast.Expr, *ast.File) bool) (names []string, types []string, ok bool) {
+
+	for _, field := range fn.Type.Params.List {
+		if !generatable(field.Type, node) {
+			return
+		}
+
+		typ := sourceOf(fset, text, field.Type)
+
+		if len(field.Names) == 0 {
+			names = append(names, fmt.Sprintf("arg%d", len(names)))
+			types = append(types, typ)
+			continue
+		}
+
+		for _, ident := range field.Names {
+			name := ident.Name
+			if name == "_" {
+				name = fmt.Sprintf("arg%d", len(names))
+			}
+
+			names = append(names, name)
+			types = append(types, typ)
+		}
+	}
+
+	ok = len(names) > 0
+	return
+}
+
+// testable checks whether a property-based test can be generated for the function.
+//
+// Only the functions whose post-conditions are checked in the code are tested since the post-conditions are
+// the properties which the tests verify. The methods and the generic functions are skipped as the receivers and
+// the type arguments can not be generated at random. The sampled functions are skipped as well since their
+// post-conditions are checked only on a fraction of the calls.
+func testable(up funcUpdate) bool {
+	fn := up.fn
+	return fn.Recv == nil && fn.Type.TypeParams == nil && fn.Name.Name != "init" && fn.Name.Name != "main" &&
+		len(up.contractInDoc.Posts) > 0 && up.contractInDoc.Checks != parsecomment.DocOnly &&
+		up.contractInDoc.Sample == 0
+}
+
+// discardCode generates the statements which discard the inputs violating the pre-conditions of the function
+// with the given statement.
+//
+// The families are ignored so that the inputs violate no pre-condition regardless of the build tags.
+func discardCode(pres []parsecond.Condition, discard string) string {
+	lines := []string{}
+	for _, c := range pres {
+		c.Family = ""
+		lines = append(lines, checkWithViolation(c, discard).statement()...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// unshadowed gives the name suffixed with underscores so that it differs from the names of the parameters.
+func unshadowed(name string, names []string) string {
+	taken := make(map[string]bool)
+	for _, n := range names {
+		taken[n] = true
+	}
+
+	for taken[name] {
+		name += "_"
+	}
+
+	return name
+}
+
+// testFunc generates the test function of the function.
+func testFunc(up funcUpdate, names []string, types []string) string {
+	fn := up.fn
+
+	params := make([]string, 0, len(names))
+	for i := range names {
+		params = append(params, names[i]+" "+types[i])
+	}
+
+	t := unshadowed("t", names)
+	passed := unshadowed("passed", names)
+
+	var b strings.Builder
+
+	testName := "TestContract_" + fn.Name.Name
+	fmt.Fprintf(&b, "// %s calls %s with random inputs satisfying its pre-conditions so that\n", testName,
+		fn.Name.Name)
+	b.WriteString("// the checks of its post-conditions can catch bugs.\n")
+	fmt.Fprintf(&b, "func %s(%s *testing.T) {\n", testName, t)
+	fmt.Fprintf(&b, "property := func(%s) (%s bool) {\n", strings.Join(params, ", "), passed)
+
+	if len(up.contractInDoc.Pres) > 0 {
+		b.WriteString("// Discard the inputs violating the pre-conditions\n")
+		b.WriteString(discardCode(up.contractInDoc.Pres, "return true"))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString("// Report the violation so that testing/quick reports the inputs\n")
+	b.WriteString("defer func() {\n")
+	b.WriteString("if r := recover(); r != nil {\n")
+	fmt.Fprintf(&b, "%s.Log(r)\n", t)
+	fmt.Fprintf(&b, "%s = false\n", passed)
+	b.WriteString("}\n")
+	b.WriteString("}()\n\n")
+	fmt.Fprintf(&b, "%s(%s)\n", fn.Name.Name, strings.Join(names, ", "))
+	b.WriteString("return true\n")
+	b.WriteString("}\n\n")
+	b.WriteString("err := quick.Check(property, nil)\n")
+	b.WriteString("if err != nil {\n")
+	fmt.Fprintf(&b, "%s.Fatal(err.Error())\n", t)
+	b.WriteString("}\n")
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// buildConstraints gives the "//go:build" lines of the file so that the tests are built together with the file.
+func buildConstraints(node *ast.File) []string {
+	constraints := []string{}
+	for _, group := range node.Comments {
+		if group.Pos() >= node.Package {
+			break
+		}
+
+		for _, comment := range group.List {
+			if strings.HasPrefix(comment.Text, "//go:build ") {
+				constraints = append(constraints, comment.Text)
+			}
+		}
+	}
+
+	return constraints
+}
+
+// GenerateTests generates the property-based tests of the functions in the file.
+//
+// A test is generated for each function whose post-conditions are checked and whose parameters are of simple types
+// (predeclared types as well as slices, arrays, maps and pointers thereof). The test calls the function with
+// random inputs generated by testing/quick, discards the inputs violating the pre-conditions and relies on
+// the condition checks in the function to catch the violated post-conditions.
+//
+// If all the parameters can be fuzzed natively (predeclared types except for complex numbers and uintptr as well
+// as []byte), a fuzz target is generated as well. The target skips the inputs violating the pre-conditions and is
+// seeded with the boundary values of the constants which the parameters are compared to in the pre-conditions
+// (e.g., 99 and 100 for "x < 100").
+//
+// The tests are generated in the package of the file. If no function can be tested, the code is empty.
+func GenerateTests(text string, filename string) (code string, err error) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, filename, text, parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	updates, err := collectUpdates(fset, node, false, nil, nil)
+	if err != nil {
+		return
+	}
+
+	funcs := []string{}
+	referred := []string{}
+	for _, up := range updates {
+		if !testable(up) {
+			continue
+		}
+
+		names, types, ok := generatedParams(fset, text, up.fn, node, simpleType)
+		if ok {
+			funcs = append(funcs, testFunc(up, names, types))
+		}
+
+		names, types, fuzzable := generatedParams(fset, text, up.fn, node, fuzzableType)
+		if fuzzable {
+			funcs = append(funcs, fuzzFunc(up, names, types))
+		}
+
+		if !ok && !fuzzable {
+			continue
+		}
+
+		var pkgs []string
+		pkgs, err = packagesInContract(up, node, nil)
+		if err != nil {
+			return
+		}
+
+		referred = append(referred, pkgs...)
+	}
+
+	if len(funcs) == 0 {
+		return
+	}
+
+	body := strings.Join(funcs, "\n")
+
+	imports, err := testImports(node, filename, body, referred)
+	if err != nil {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by gocontracts gentest. DO NOT EDIT.\n\n")
+	for _, constraint := range buildConstraints(node) {
+		b.WriteString(constraint + "\n\n")
+	}
+	fmt.Fprintf(&b, "package %s\n\n", node.Name.Name)
+	fmt.Fprintf(&b, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	b.WriteString(body)
+
+	generated, err := format.Source([]byte(b.String()))
+	if err != nil {
+		err = fmt.Errorf("failed to format the generated tests:\n%s\nThe error was: %s", b.String(), err)
+		return
+	}
+
+	code = string(generated)
+	return
+}
+
+// testImports gives the import specs of the generated tests.
+//
+// The packages referred to in the pre-conditions are imported under the same names as in the file.
+func testImports(node *ast.File, filename string, body string, referred []string) (imports []string, err error) {
+	var parsed *ast.File
+	parsed, err = parser.ParseFile(token.NewFileSet(), "", "package p\n\n"+body, 0)
+	if err != nil {
+		err = fmt.Errorf("failed to parse the generated tests:\n%s\nThe error was: %s", body, err)
+		return
+	}
+
+	known := make(map[string]string)
+	for _, spec := range node.Imports {
+		importPath, unquoteErr := strconv.Unquote(spec.Path.Value)
+		if unquoteErr == nil {
+			known[importName(spec, filename)] = importPath
+		}
+	}
+
+	imports = []string{`"testing"`}
+
+	used := usedImports(parsed)
+	if used["quick"] {
+		imports = append(imports, `"testing/quick"`)
+	}
+
+	added := make(map[string]bool)
+	for _, name := range referred {
+		if !used[name] || added[name] || name == "testing" || name == "quick" {
+			continue
+		}
+
+		importPath, ok := known[name]
+		if !ok {
+			importPath, ok = resolveImport(name, filename)
+		}
+
+		if !ok {
+			continue
+		}
+
+		spec := strconv.Quote(importPath)
+		if importName(&ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: spec}}, filename) != name {
+			spec = name + " " + spec
+		}
+
+		imports = append(imports, spec)
+		added[name] = true
+	}
+
+	return
+}
+
+// testPath gives the path of the file with the tests generated for the file at the given path
+// (e.g., "some_contracts_test.go" for "some.go").
+func testPath(pth string) string {
+	return strings.TrimSuffix(pth, ".go") + "_contracts_test.go"
+}
+
+// generateTestsToResult generates the tests of the file at the given path. The result refers to the test file.
+//
+// The test files, the generated files and the files without testable functions are skipped.
+func generateTestsToResult(pth string) (result Result, skip bool) {
+	if strings.HasSuffix(pth, "_test.go") {
+		skip = true
+		return
+	}
+
+	data, err := ioutil.ReadFile(pth)
+	if err != nil {
+		result = Result{Path: pth, Err: fmt.Errorf("failed to read: %s", err)}
+		return
+	}
+
+	text := string(data)
+	if isGenerated(text, pth) {
+		skip = true
+		return
+	}
+
+	code, err := GenerateTests(text, pth)
+	if err != nil {
+		result = Result{Path: pth, Err: err}
+		return
+	}
+
+	if code == "" {
+		skip = true
+		return
+	}
+
+	result = generatedFileResult(testPath(pth), code)
+	return
+}
+
+// generateTestsInDir generates the tests of all the files of the package in the directory.
+func generateTestsInDir(dir string) (results []Result, err error) {
+	paths, err := packageFiles(dir)
+	if err != nil {
+		return
+	}
+
+	for _, pth := range paths {
+		result, skip := generateTestsToResult(pth)
+		if !skip {
+			results = append(results, result)
+		}
+	}
+
+	return
+}
+
+// GenerateTestPackages generates the property-based tests of the Go files given as patterns.
+//
+// The patterns are resolved as in ProcessPackages. Each result refers to the generated test file
+// (see GenerateTests) and holds its current content as Text and the generated code as Updated.
+// The errors in individual files are recorded in the results and do not abort the generation.
+func GenerateTestPackages(patterns []string) (results []Result, err error) {
+	for _, pattern := range patterns {
+		switch {
+		case pattern == "..." || strings.HasSuffix(pattern, "/..."):
+			root := strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+			if root == "" {
+				root = "."
+			}
+
+			err = walkPackages(root, func(dir string) {
+				dirResults, dirErr := generateTestsInDir(dir)
+				if dirErr != nil {
+					results = append(results, Result{Path: dir, Err: dirErr})
+				} else {
+					results = append(results, dirResults...)
+				}
+			})
+
+		default:
+			var info os.FileInfo
+			info, err = os.Stat(pattern)
+			switch {
+			case err != nil:
+				results = append(results, Result{Path: pattern, Err: fmt.Errorf("failed to read: %s", err)})
+				err = nil
+
+			case info.IsDir():
+				var dirResults []Result
+				dirResults, err = generateTestsInDir(pattern)
+				results = append(results, dirResults...)
+
+			default:
+				result, skip := generateTestsToResult(pattern)
+				if !skip {
+					results = append(results, result)
+				}
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
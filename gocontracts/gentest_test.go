@@ -0,0 +1,170 @@
+package gocontracts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateTests(t *testing.T) {
+	text := `package somepkg
+
+import (
+	"fmt"
+	str "strings"
+)
+
+// Pad pads the text.
+//
+// Pad requires:
+//  * str.HasPrefix(s, "a")
+//  * [test] forall _, n in counts: n >= 0
+//
+// Pad ensures:
+//  * len(result) > len(s)
+func Pad(s string, counts map[string]int, _ bool) (result string) {
+	return s + " "
+}
+
+// Format has a parameter of a type which can not be generated.
+//
+// Format ensures:
+//  * result != ""
+func Format(v fmt.Stringer) (result string) {
+	return v.String()
+}
+
+// Requires has no post-conditions.
+//
+// Requires requires:
+//  * x > 0
+func Requires(x int) {}
+`
+
+	expected := `// Code generated by gocontracts gentest. DO NOT EDIT.
+
+package somepkg
+
+import (
+	str "strings"
+	"testing"
+	"testing/quick"
+)
+
+// TestContract_Pad calls Pad with random inputs satisfying its pre-conditions so that
+// the checks of its post-conditions can catch bugs.
+func TestContract_Pad(t *testing.T) {
+	property := func(s string, counts map[string]int, arg2 bool) (passed bool) {
+		// Discard the inputs violating the pre-conditions
+		if !(str.HasPrefix(s, "a")) {
+			return true
+		}
+		for _, n := range counts {
+			if !(n >= 0) {
+				return true
+			}
+		}
+
+		// Report the violation so that testing/quick reports the inputs
+		defer func() {
+			if r := recover(); r != nil {
+				t.Log(r)
+				passed = false
+			}
+		}()
+
+		Pad(s, counts, arg2)
+		return true
+	}
+
+	err := quick.Check(property, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+`
+
+	code, err := GenerateTests(text, "some.go")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if code != expected {
+		t.Fatalf("Expected the generated tests:\n%s\ngot:\n%s", expected, code)
+	}
+}
+
+func TestGenerateTests_NoTestable(t *testing.T) {
+	text := `package somepkg
+
+// SomeType defines something.
+type SomeType struct{}
+
+// SomeMethod is a method.
+//
+// SomeMethod ensures:
+//  * result > 0
+func (s *SomeType) SomeMethod(x int) (result int) {
+	return 1
+}
+
+// SomeFunc is documented only.
+//
+// SomeFunc ensures:
+//  * result > 0
+//
+// SomeFunc contracts: doc-only
+func SomeFunc(x int) (result int) {
+	return 1
+}
+`
+
+	code, err := GenerateTests(text, "some.go")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if code != "" {
+		t.Fatalf("Expected no tests, got:\n%s", code)
+	}
+}
+
+func TestGenerateTestPackages(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "gentest_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{
+		"somepkg/some.go": `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * result > x
+func SomeFunc(x int) (result int) {
+	return x + 1
+}
+`,
+		"somepkg/other.go": "package somepkg\n\nfunc other() {}\n",
+		"somepkg/some_contracts_test.go": "// Code generated by gocontracts gentest. DO NOT EDIT.\n\n" +
+			"package somepkg\n",
+	})
+
+	results, err := GenerateTestPackages([]string{filepath.Join(tmpdir, "...")})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	testPth := filepath.Join(tmpdir, "somepkg", "some_contracts_test.go")
+	if len(results) != 1 || results[0].Path != testPth || results[0].Err != nil || !results[0].Changed() {
+		t.Fatalf("Expected a single changed test file %s, got %#v", testPth, results)
+	}
+}
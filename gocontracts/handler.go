@@ -0,0 +1,221 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// runtimeImportPath is the import path of the package providing the run-time support for the generated code.
+const runtimeImportPath = "github.com/Parquery/gocontracts/contracts"
+
+// runtimePackage is the name of the package providing the run-time support for the generated code.
+const runtimePackage = "contracts"
+
+// TypedPanicHandler is the violation handler which panics with a *contracts.ViolationError instead of a string
+// so that the violations can be recovered with contracts.Recover or inspected with errors.As.
+const TypedPanicHandler = runtimeImportPath + ".Panic"
+
+// handler defines the function called in the generated code when a condition is violated.
+type handler struct {
+	// call is the function as referred to in the code (e.g., "somepkg.Handle").
+	call string
+
+	// pkgName is the name of the handler's package. It is empty if the handler is declared in the processed
+	// package.
+	pkgName string
+
+	// importPath of the handler's package. It is empty if it needs to be resolved from pkgName.
+	importPath string
+}
+
+var handlerRe = regexp.MustCompile(`^(([^\s"]+)\.)?([a-zA-Z_][a-zA-Z_0-9]*)$`)
+
+// parseHandler parses the handler given as "Func", "pkg.Func" or "import/path/pkg.Func".
+// If the spec is empty, the handler is nil.
+func parseHandler(spec string, filename string) (h *handler, err error) {
+	if spec == "" {
+		return
+	}
+
+	mtchs := handlerRe.FindStringSubmatch(spec)
+	if len(mtchs) == 0 {
+		err = fmt.Errorf("expected the handler as Func, pkg.Func or import/path/pkg.Func, but got %#v", spec)
+		return
+	}
+
+	h = &handler{call: mtchs[3]}
+
+	pkg := mtchs[2]
+	switch {
+	case pkg == "":
+		// The handler is declared in the processed package.
+
+	case strings.Contains(pkg, "/"):
+		h.importPath = pkg
+		h.pkgName = importName(&ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(pkg)}},
+			filename)
+		h.call = h.pkgName + "." + h.call
+
+	default:
+		h.pkgName = pkg
+		h.call = pkg + "." + h.call
+	}
+
+	return
+}
+
+// knownImports maps the names of the packages referred to in the generated code to their import paths.
+func (h *handler) knownImports() map[string]string {
+	known := map[string]string{runtimePackage: runtimeImportPath}
+	if h == nil {
+		return known
+	}
+
+	if h.importPath != "" {
+		known[h.pkgName] = h.importPath
+	}
+
+	return known
+}
+
+// site identifies the function whose conditions are checked so that the violations can be reported.
+type site struct {
+	// handler is called on violations. If nil, the generated code panics.
+	handler *handler
+
+	// function is the name of the function as reported in the violations.
+	function string
+
+	// file is the base name of the file.
+	file string
+
+	// names are the identifiers used in the function which the generated variables must not shadow.
+	names map[string]bool
+
+	// returnErrors indicates that the violated pre-conditions are returned as errors.
+	returnErrors bool
+
+	// zeros are the zero values of the results preceding the error returned on violated pre-conditions.
+	zeros []string
+
+	// coverage indicates that the evaluations of the conditions are counted in the coverage registry.
+	coverage bool
+
+	// toggle indicates that the blocks of checks are guarded by the run-time switches of the contracts package.
+	toggle bool
+
+	// level is the name of the variable holding the level of the checks if the site is toggled.
+	level string
+
+	// sampling indicates that the functions can be sampled since the file can refer to the samplers of
+	// the package.
+	sampling bool
+
+	// sampler is the name of the variable holding the sampler of the function if its conditions are checked
+	// only on the sampled calls.
+	sampler string
+
+	// sampled is the name of the variable holding the decision whether the conditions are checked on the call
+	// if the function is sampled.
+	sampled string
+
+	// metrics indicates that the violations are counted with contracts.Counted before they are handled.
+	metrics bool
+
+	// pkgPath is the import path of the package as reported in the counted violations.
+	pkgPath string
+}
+
+// fileOf gives the base name of the file where the condition was documented.
+func (s site) fileOf(c parsecond.Condition) string {
+	// The inherited conditions are documented in the file of the interface.
+	if c.File != "" {
+		return filepath.Base(c.File)
+	}
+
+	return s.file
+}
+
+// violation generates the statement executed when the condition is violated.
+//
+// The kind refers to the constant of the contracts package (e.g., "Precondition").
+//
+// The violations of the universally quantified conditions report the offending element by its key.
+func (s site) violation(kind string, c parsecond.Condition) string {
+	key := offendingKey(c)
+
+	if kind == "Precondition" && s.returnErrors {
+		err := fmt.Sprintf("errors.New(%s)", violationMsg(c))
+		switch {
+		case s.metrics:
+			err = fmt.Sprintf("errors.New(%s.Message())", s.counted(kind, c))
+		case key != "":
+			err = fmt.Sprintf("fmt.Errorf(%s, %s)", violationFormat(c), key)
+		}
+
+		values := make([]string, 0, len(s.zeros)+1)
+		values = append(values, s.zeros...)
+		values = append(values, err)
+
+		return fmt.Sprintf("return %s", strings.Join(values, ", "))
+	}
+
+	if s.handler == nil {
+		switch {
+		case s.metrics:
+			return fmt.Sprintf("panic(%s.Message())", s.counted(kind, c))
+		case key != "":
+			return fmt.Sprintf("panic(fmt.Sprintf(%s, %s))", violationFormat(c), key)
+		}
+
+		return fmt.Sprintf("panic(%s)", violationMsg(c))
+	}
+
+	if s.metrics {
+		return fmt.Sprintf("%s(%s)", s.handler.call, s.counted(kind, c))
+	}
+
+	return fmt.Sprintf("%s(%s)", s.handler.call, s.violationInfo(kind, c))
+}
+
+// violationInfo generates the composite literal of contracts.ViolationInfo describing the violated condition.
+func (s site) violationInfo(kind string, c parsecond.Condition) string {
+	key := offendingKey(c)
+
+	fields := []string{fmt.Sprintf("Kind: %s.%s", runtimePackage, kind)}
+
+	if s.metrics {
+		fields = append(fields, fmt.Sprintf("Package: %s", strconv.Quote(s.pkgPath)))
+	}
+
+	fields = append(fields, fmt.Sprintf("Function: %s", strconv.Quote(s.function)))
+
+	if c.Label != "" {
+		fields = append(fields, fmt.Sprintf("Label: %s", strconv.Quote(c.Label)))
+	}
+
+	fields = append(fields,
+		fmt.Sprintf("Condition: %s", strconv.Quote(c.Text())))
+
+	if key != "" {
+		fields = append(fields, fmt.Sprintf("Offending: fmt.Sprintf(%s, %s)", strconv.Quote(key+" = %v"), key))
+	}
+
+	fields = append(fields,
+		fmt.Sprintf("File: %s", strconv.Quote(s.fileOf(c))),
+		fmt.Sprintf("Line: %d", c.Line))
+
+	return fmt.Sprintf("%s.ViolationInfo{%s}", runtimePackage, strings.Join(fields, ", "))
+}
+
+// counted generates the call to contracts.Counted which counts the violated condition in the violation metrics.
+func (s site) counted(kind string, c parsecond.Condition) string {
+	return fmt.Sprintf("%s.Counted(%s)", runtimePackage, s.violationInfo(kind, c))
+}
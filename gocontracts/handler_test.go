@@ -0,0 +1,57 @@
+package gocontracts
+
+import (
+	"testing"
+)
+
+func TestParseHandler(t *testing.T) {
+	type testCase struct {
+		spec     string
+		expected handler
+	}
+
+	testCases := []testCase{
+		{spec: "report", expected: handler{call: "report"}},
+		{spec: "log.Report", expected: handler{call: "log.Report", pkgName: "log"}},
+		{spec: "github.com/some/violations.Report",
+			expected: handler{
+				call: "violations.Report", pkgName: "violations", importPath: "github.com/some/violations"}},
+		{spec: "github.com/some/go-violations/v2.Report",
+			expected: handler{
+				call: "violations.Report", pkgName: "violations", importPath: "github.com/some/go-violations/v2"}},
+	}
+
+	for _, tc := range testCases {
+		h, err := parseHandler(tc.spec, "some.go")
+		if err != nil {
+			t.Fatalf("failed to parse the handler %#v: %s", tc.spec, err.Error())
+		}
+
+		if *h != tc.expected {
+			t.Errorf("expected the handler %#v to be parsed as %#v, got %#v", tc.spec, tc.expected, *h)
+		}
+	}
+}
+
+func TestParseHandler_Invalid(t *testing.T) {
+	_, err := parseHandler("some handler", "some.go")
+	if err == nil {
+		t.Fatal("expected an error, but got none")
+	}
+
+	expected := `expected the handler as Func, pkg.Func or import/path/pkg.Func, but got "some handler"`
+	if err.Error() != expected {
+		t.Fatalf("expected the error %#v, got %#v", expected, err.Error())
+	}
+}
+
+func TestParseHandler_Empty(t *testing.T) {
+	h, err := parseHandler("", "some.go")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if h != nil {
+		t.Fatalf("expected no handler, got %#v", *h)
+	}
+}
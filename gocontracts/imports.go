@@ -0,0 +1,646 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// packageIndex maps package names to the import paths of the packages.
+type packageIndex map[string][]string
+
+// add registers the package in the index.
+func (idx packageIndex) add(name string, importPath string) {
+	idx[name] = append(idx[name], importPath)
+}
+
+// preferredImports resolves the ambiguous package names in the standard library.
+var preferredImports = map[string]string{
+	"rand":     "math/rand",
+	"template": "text/template",
+	"scanner":  "text/scanner",
+}
+
+// resolve finds the import path of the package given its name.
+//
+// If there are multiple candidates, the one with the fewest path elements is preferred.
+func (idx packageIndex) resolve(name string) (importPath string, ok bool) {
+	candidates := idx[name]
+	if len(candidates) == 0 {
+		return
+	}
+
+	if preferred, has := preferredImports[name]; has {
+		for _, candidate := range candidates {
+			if candidate == preferred {
+				return candidate, true
+			}
+		}
+	}
+
+	sorted := append([]string{}, candidates...)
+	sort.Slice(sorted, func(i, j int) bool {
+		ci, cj := strings.Count(sorted[i], "/"), strings.Count(sorted[j], "/")
+		if ci != cj {
+			return ci < cj
+		}
+
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) < len(sorted[j])
+		}
+
+		return sorted[i] < sorted[j]
+	})
+
+	return sorted[0], true
+}
+
+// packageName parses the name of the package in the directory.
+// The test files and the main packages are ignored. If no package could be found, ok is false.
+func packageName(dir string) (name string, ok bool) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") || strings.HasSuffix(info.Name(), "_test.go") {
+			continue
+		}
+
+		fset := token.NewFileSet()
+		node, err := parser.ParseFile(fset, filepath.Join(dir, info.Name()), nil, parser.PackageClauseOnly)
+		if err != nil || node.Name.Name == "main" {
+			continue
+		}
+
+		return node.Name.Name, true
+	}
+
+	return
+}
+
+// indexTree indexes all the packages in the directory tree under the import path of the root.
+//
+// The internal, vendor and testdata directories as well as the nested modules are skipped.
+func indexTree(idx packageIndex, root string, rootImportPath string, skipInternal bool) {
+	_ = filepath.Walk(root, func(pth string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+
+		if pth != root {
+			if skipDir(info.Name()) || (skipInternal && info.Name() == "internal") {
+				return filepath.SkipDir
+			}
+
+			if _, statErr := os.Stat(filepath.Join(pth, "go.mod")); statErr == nil {
+				// Nested modules have their own import paths.
+				return filepath.SkipDir
+			}
+		}
+
+		name, ok := packageName(pth)
+		if !ok {
+			return nil
+		}
+
+		rel, relErr := filepath.Rel(root, pth)
+		if relErr != nil {
+			return nil
+		}
+
+		importPath := path.Join(rootImportPath, filepath.ToSlash(rel))
+		if importPath == "." || importPath == "" {
+			return nil
+		}
+
+		idx.add(name, importPath)
+		return nil
+	})
+}
+
+var stdlibOnce sync.Once
+var stdlib packageIndex
+
+// stdlibIndex indexes the packages of the standard library from the sources in GOROOT.
+func stdlibIndex() packageIndex {
+	stdlibOnce.Do(func() {
+		stdlib = make(packageIndex)
+
+		src := filepath.Join(build.Default.GOROOT, "src")
+		infos, err := ioutil.ReadDir(src)
+		if err != nil {
+			return
+		}
+
+		for _, info := range infos {
+			if !info.IsDir() || info.Name() == "cmd" || skipDir(info.Name()) || info.Name() == "internal" {
+				continue
+			}
+
+			indexTree(stdlib, filepath.Join(src, info.Name()), info.Name(), true)
+		}
+	})
+
+	return stdlib
+}
+
+var moduleRe = regexp.MustCompile(`(?m)^\s*module\s+"?([^"\s]+)"?\s*$`)
+
+// findModule searches for the go.mod file from the directory upwards and parses the module path.
+// If no module could be found, root is empty.
+func findModule(dir string) (root string, modulePath string) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+
+	for {
+		data, readErr := ioutil.ReadFile(filepath.Join(abs, "go.mod"))
+		if readErr == nil {
+			mtchs := moduleRe.FindSubmatch(data)
+			if len(mtchs) > 0 {
+				return abs, string(mtchs[1])
+			}
+
+			return
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return
+		}
+		abs = parent
+	}
+}
+
+// packagePath determines the import path of the package of the file from the module or the GOPATH containing
+// the file. The path of an external test package is suffixed with "_test" as in the go tool.
+//
+// If the path can not be determined, the name of the package is given.
+func packagePath(filename string, pkgName string) string {
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return pkgName
+	}
+
+	importPath := ""
+	if root, modulePath := findModule(dir); root != "" {
+		if rel, relErr := filepath.Rel(root, dir); relErr == nil {
+			importPath = path.Join(modulePath, filepath.ToSlash(rel))
+		}
+	} else {
+		for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+			rel, relErr := filepath.Rel(filepath.Join(gopath, "src"), dir)
+			if relErr == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				importPath = filepath.ToSlash(rel)
+				break
+			}
+		}
+	}
+
+	if importPath == "" {
+		return pkgName
+	}
+
+	if strings.HasSuffix(pkgName, "_test") {
+		importPath += "_test"
+	}
+
+	return importPath
+}
+
+var moduleIndicesMu sync.Mutex
+var moduleIndices = make(map[string]packageIndex)
+
+// moduleIndex indexes the packages of the module containing the directory.
+// If the directory does not belong to a module, an empty index is returned.
+func moduleIndex(dir string) packageIndex {
+	root, modulePath := findModule(dir)
+	if root == "" {
+		return packageIndex{}
+	}
+
+	moduleIndicesMu.Lock()
+	defer moduleIndicesMu.Unlock()
+
+	idx, ok := moduleIndices[root]
+	if !ok {
+		idx = make(packageIndex)
+		indexTree(idx, root, modulePath, false)
+		moduleIndices[root] = idx
+	}
+
+	return idx
+}
+
+// resolveImport finds the import path of the package given its name.
+//
+// The standard library takes precedence over the packages of the module containing the file.
+func resolveImport(name string, filename string) (importPath string, ok bool) {
+	importPath, ok = stdlibIndex().resolve(name)
+	if ok {
+		return
+	}
+
+	return moduleIndex(filepath.Dir(filename)).resolve(name)
+}
+
+var majorVersionRe = regexp.MustCompile(`^v[0-9]+$`)
+
+// importName determines the name under which the package is referred to in the file.
+func importName(spec *ast.ImportSpec, filename string) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+
+	importPath, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+
+	for _, idx := range []packageIndex{stdlibIndex(), moduleIndex(filepath.Dir(filename))} {
+		for name, paths := range idx {
+			for _, p := range paths {
+				if p == importPath {
+					return name
+				}
+			}
+		}
+	}
+
+	// Follow the convention that the package name corresponds to the last element of the path
+	// without the major version.
+	elements := strings.Split(importPath, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && majorVersionRe.MatchString(name) {
+		name = elements[len(elements)-2]
+	}
+
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i >= 0 {
+		name = name[:i]
+	}
+
+	return name
+}
+
+// unresolvedSelectors collects the names used as the package in selector expressions which could not be
+// resolved in the scope of the node.
+func unresolvedSelectors(node ast.Node) (names map[string]bool) {
+	names = make(map[string]bool)
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+			names[ident.Name] = true
+		}
+
+		return true
+	})
+
+	return
+}
+
+// fieldNames collects the names of the fields.
+func fieldNames(fields *ast.FieldList, names map[string]bool) {
+	if fields == nil {
+		return
+	}
+
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			names[name.Name] = true
+		}
+	}
+}
+
+// contractPlayground generates a function body so that the identifiers declared in the preamble and
+// in the initialization of the conditions can be resolved.
+func contractPlayground(up funcUpdate) string {
+	var b strings.Builder
+	b.WriteString("package p\n\nfunc _() {\n")
+
+	if up.contractInDoc.Preamble != "" {
+		b.WriteString(up.contractInDoc.Preamble)
+		b.WriteString("\n")
+	}
+
+	for _, conditions := range [][]parsecond.Condition{up.contractInDoc.Pres, up.invariants, up.contractInDoc.Posts} {
+		for _, c := range conditions {
+			if c.InitStr != "" {
+				b.WriteString(fmt.Sprintf("if %s; %s {\n}\n", c.InitStr, c.ExprStr()))
+			} else {
+				b.WriteString(fmt.Sprintf("if %s {\n}\n", c.ExprStr()))
+			}
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// packagesInContract lists the names of the packages referred to in the contract of the function.
+//
+// The names declared in the function signature, at the file level and in the package scope are excluded.
+// The scope can be nil.
+func packagesInContract(up funcUpdate, node *ast.File, scope *packageScope) (names []string, err error) {
+	playground := contractPlayground(up)
+
+	fset := token.NewFileSet()
+	var parsed *ast.File
+	parsed, err = parser.ParseFile(fset, "", playground, 0)
+	if err != nil {
+		err = fmt.Errorf("failed to parse the contract of the function %s in the following playground:\n%s\n"+
+			"The error was: %s", up.fn.Name.Name, playground, err)
+		return
+	}
+
+	declared := make(map[string]bool)
+	fieldNames(up.fn.Recv, declared)
+	fieldNames(up.fn.Type.Params, declared)
+	fieldNames(up.fn.Type.Results, declared)
+
+	for name := range unresolvedSelectors(parsed) {
+		if declared[name] || node.Scope.Lookup(name) != nil || scope.declared(node.Name.Name, name) ||
+			types.Universe.Lookup(name) != nil {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return
+}
+
+// usedImports collects the names of the imported packages referred to in the file.
+func usedImports(node *ast.File) map[string]bool {
+	return unresolvedSelectors(node)
+}
+
+// importEdit defines a replacement of a part of the text.
+type importEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// applyEdits applies the non-overlapping edits to the text.
+func applyEdits(text string, edits []importEdit) string {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var b strings.Builder
+	cursor := 0
+	for _, e := range edits {
+		b.WriteString(text[cursor:e.start])
+		b.WriteString(e.text)
+		cursor = e.end
+	}
+	b.WriteString(text[cursor:])
+
+	return b.String()
+}
+
+// lineStart returns the offset of the beginning of the line containing the offset.
+func lineStart(text string, offset int) int {
+	return strings.LastIndex(text[:offset], "\n") + 1
+}
+
+// lineEnd returns the offset just after the new-line character ending the line containing the offset.
+func lineEnd(text string, offset int) int {
+	i := strings.Index(text[offset:], "\n")
+	if i < 0 {
+		return len(text)
+	}
+
+	return offset + i + 1
+}
+
+// isCImport checks whether the import declaration imports the pseudo-package "C" of cgo.
+func isCImport(decl *ast.GenDecl) bool {
+	for _, spec := range decl.Specs {
+		if spec.(*ast.ImportSpec).Path.Value == `"C"` {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateImports adds the given imports to the file and removes the given ones from it.
+func updateImports(text string, filename string, add []string, remove []string) (updated string, err error) {
+	if len(add) == 0 && len(remove) == 0 {
+		updated = text
+		return
+	}
+
+	fset := token.NewFileSet()
+	var node *ast.File
+	node, err = parser.ParseFile(fset, filename, text, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	toRemove := make(map[string]bool)
+	for _, p := range remove {
+		toRemove[strconv.Quote(p)] = true
+	}
+
+	edits := []importEdit{}
+
+	// Remove the imports
+	var target *ast.GenDecl
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+
+		removed := 0
+		for _, spec := range genDecl.Specs {
+			if toRemove[spec.(*ast.ImportSpec).Path.Value] {
+				removed++
+			}
+		}
+
+		if removed == len(genDecl.Specs) {
+			start := lineStart(text, offset(genDecl.Pos()))
+			end := lineEnd(text, offset(genDecl.End()))
+
+			// Remove the empty line following the declaration as well.
+			if end < len(text) && text[end] == '\n' {
+				end++
+			}
+
+			edits = append(edits, importEdit{start: start, end: end})
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			if toRemove[spec.(*ast.ImportSpec).Path.Value] {
+				edits = append(edits, importEdit{
+					start: lineStart(text, offset(spec.Pos())),
+					end:   lineEnd(text, offset(spec.End()))})
+			}
+		}
+
+		if target == nil && !isCImport(genDecl) {
+			target = genDecl
+		}
+	}
+
+	// Add the imports
+	sort.Strings(add)
+
+	switch {
+	case len(add) == 0:
+		// Nothing to add.
+
+	case target == nil:
+		// Insert a new import declaration after the package clause or after the last import declaration.
+		pos := node.Name.End()
+		for _, decl := range node.Decls {
+			if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.IMPORT {
+				pos = genDecl.End()
+			}
+		}
+
+		var b strings.Builder
+		if len(add) == 1 {
+			b.WriteString(fmt.Sprintf("\n\nimport %s", strconv.Quote(add[0])))
+		} else {
+			b.WriteString("\n\nimport (")
+			for _, p := range add {
+				b.WriteString(fmt.Sprintf("\n\t%s", strconv.Quote(p)))
+			}
+			b.WriteString("\n)")
+		}
+
+		at := lineEnd(text, offset(pos))
+		if at > 0 && text[at-1] == '\n' {
+			at--
+		}
+
+		edits = append(edits, importEdit{start: at, end: at, text: b.String()})
+
+	case !target.Lparen.IsValid():
+		// Convert the single import into a group.
+		spec := target.Specs[0]
+
+		var b strings.Builder
+		b.WriteString("import (\n\t")
+		b.WriteString(text[offset(spec.Pos()):offset(spec.End())])
+		for _, p := range add {
+			b.WriteString(fmt.Sprintf("\n\t%s", strconv.Quote(p)))
+		}
+		b.WriteString("\n)")
+
+		edits = append(edits, importEdit{start: offset(target.Pos()), end: offset(target.End()), text: b.String()})
+
+	default:
+		// Insert the imports in the group while keeping it sorted as far as possible.
+		for _, p := range add {
+			quoted := strconv.Quote(p)
+
+			at := lineStart(text, offset(target.Rparen))
+			for _, spec := range target.Specs {
+				importSpec := spec.(*ast.ImportSpec)
+				if !toRemove[importSpec.Path.Value] && importSpec.Path.Value > quoted {
+					at = lineStart(text, offset(spec.Pos()))
+					break
+				}
+			}
+
+			edits = append(edits, importEdit{start: at, end: at, text: fmt.Sprintf("\t%s\n", quoted)})
+		}
+	}
+
+	updated = applyEdits(text, edits)
+	return
+}
+
+// manageImports adds the imports of the packages referred to in the contracts, but missing in the file, and
+// removes the imports which became unused by updating the file.
+//
+// original is the parsed text before the update. The known import paths of the packages take precedence
+// over resolving the packages by their names.
+func manageImports(original *ast.File, updated string, filename string, referred []string,
+	known map[string]string) (result string, err error) {
+
+	fset := token.NewFileSet()
+	var node *ast.File
+	node, err = parser.ParseFile(fset, filename, updated, parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	imported := make(map[string]bool)
+	for _, spec := range node.Imports {
+		imported[importName(spec, filename)] = true
+	}
+
+	// Determine the imports which became unused
+	usedBefore := usedImports(original)
+	usedAfter := usedImports(node)
+
+	remove := []string{}
+	for _, spec := range node.Imports {
+		name := importName(spec, filename)
+		if name == "_" || name == "." || spec.Path.Value == `"C"` {
+			continue
+		}
+
+		if usedBefore[name] && !usedAfter[name] {
+			importPath, unquoteErr := strconv.Unquote(spec.Path.Value)
+			if unquoteErr == nil {
+				remove = append(remove, importPath)
+			}
+		}
+	}
+
+	// Determine the missing imports
+	add := []string{}
+	added := make(map[string]bool)
+	for _, name := range referred {
+		if imported[name] || added[name] || !usedAfter[name] {
+			continue
+		}
+
+		importPath, ok := known[name]
+		if !ok {
+			importPath, ok = resolveImport(name, filename)
+		}
+
+		if !ok {
+			continue
+		}
+
+		add = append(add, importPath)
+		added[name] = true
+	}
+
+	result, err = updateImports(updated, filename, add, remove)
+	return
+}
@@ -0,0 +1,586 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/Parquery/gocontracts/parsecomment"
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// interfaceMethod defines a method of an interface whose contract is inherited by the implementations.
+type interfaceMethod struct {
+	// iface is the name of the interface.
+	iface string
+
+	name     string
+	fn       *ast.FuncType
+	contract parsecomment.Contract
+
+	// field declares the method in the interface.
+	field *ast.Field
+
+	// filename is the path of the file where the interface is declared.
+	filename string
+}
+
+// contractedInterface defines an interface with at least one contracted method.
+type contractedInterface struct {
+	name string
+
+	// methods maps the names of all the methods declared explicitly in the interface to their signatures.
+	methods map[string]*ast.FuncType
+
+	contracted []interfaceMethod
+}
+
+// collectInterfaces parses the contracts of the interface methods declared in the file.
+//
+// The lines of the conditions refer to the lines in the file. Only the interfaces with at least one
+// contracted method are listed.
+func collectInterfaces(fset *token.FileSet, node *ast.File) (interfaces []contractedInterface, err error) {
+	filename := fset.Position(node.Package).Filename
+
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+
+			ifaceType, ok := typeSpec.Type.(*ast.InterfaceType)
+			if !ok || typeSpec.TypeParams != nil {
+				continue
+			}
+
+			ci := contractedInterface{name: typeSpec.Name.Name, methods: make(map[string]*ast.FuncType)}
+
+			for _, field := range ifaceType.Methods.List {
+				funcType, ok := field.Type.(*ast.FuncType)
+				if !ok || len(field.Names) != 1 {
+					// Embedded interfaces and type constraints do not define methods on their own.
+					continue
+				}
+
+				name := field.Names[0].Name
+				ci.methods[name] = funcType
+
+				lines, fileLines := commentLines(fset, field.Doc)
+
+				var contract parsecomment.Contract
+				contract, err = parsecomment.ToContract(name, lines)
+				if err != nil {
+					err = fmt.Errorf("failed to parse comments of the method %s.%s on line %d: %s",
+						ci.name, name, fset.Position(field.Doc.Pos()).Line, err)
+					return
+				}
+
+				locateContract(&contract, fileLines)
+
+				if contract.Preamble != "" {
+					err = fmt.Errorf("unexpected preamble in the comments of the method %s.%s on line %d; "+
+						"the interface methods can only define pre- and post-conditions",
+						ci.name, name, fset.Position(field.Doc.Pos()).Line)
+					return
+				}
+
+				if len(contract.Pres) == 0 && len(contract.Posts) == 0 {
+					continue
+				}
+
+				ci.contracted = append(ci.contracted, interfaceMethod{
+					iface:    ci.name,
+					name:     name,
+					fn:       funcType,
+					contract: contract,
+					field:    field,
+					filename: filename})
+			}
+
+			if len(ci.contracted) > 0 {
+				interfaces = append(interfaces, ci)
+			}
+		}
+	}
+
+	return
+}
+
+// inheritance maps the qualified names of the methods (e.g., "SomeType.SomeMethod") to the interface methods
+// whose contracts they inherit.
+type inheritance map[string][]interfaceMethod
+
+// implementersByTypes lists the types of the package implementing the interfaces as determined by go/types.
+//
+// If the package does not type-check, ok is false.
+func implementersByTypes(fset *token.FileSet, files []*ast.File, interfaces []contractedInterface) (
+	implementers map[string][]string, ok bool) {
+
+	failed := false
+	conf := types.Config{
+		Importer:    importer.ForCompiler(fset, "source", nil),
+		FakeImportC: true,
+		Error:       func(err error) { failed = true }}
+
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, nil)
+	if failed || pkg == nil {
+		return
+	}
+
+	implementers = make(map[string][]string)
+	for _, ci := range interfaces {
+		ifaceObj, isTypeName := pkg.Scope().Lookup(ci.name).(*types.TypeName)
+		if !isTypeName {
+			return
+		}
+
+		iface, isInterface := ifaceObj.Type().Underlying().(*types.Interface)
+		if !isInterface {
+			return
+		}
+
+		for _, name := range pkg.Scope().Names() {
+			obj, isTypeName := pkg.Scope().Lookup(name).(*types.TypeName)
+			if !isTypeName {
+				continue
+			}
+
+			named, isNamed := obj.Type().(*types.Named)
+			if !isNamed || named.TypeParams().Len() > 0 || types.IsInterface(named) {
+				continue
+			}
+
+			if types.Implements(named, iface) || types.Implements(types.NewPointer(named), iface) {
+				implementers[ci.name] = append(implementers[ci.name], name)
+			}
+		}
+	}
+
+	ok = true
+	return
+}
+
+// sameArity checks that the signatures have the same number of parameters and results.
+func sameArity(a *ast.FuncType, b *ast.FuncType) bool {
+	return len(flattenNames(a.Params)) == len(flattenNames(b.Params)) &&
+		len(flattenNames(a.Results)) == len(flattenNames(b.Results))
+}
+
+// implementersByNames lists the types of the package which declare all the methods of the interfaces.
+//
+// Only the names and the number of parameters and results of the methods are compared so that
+// the implementers can be determined even if the package does not type-check.
+func implementersByNames(files []*ast.File, interfaces []contractedInterface) (implementers map[string][]string) {
+	methods := make(map[string]map[string]*ast.FuncType)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+
+			typeName := ReceiverType(fn)
+			if typeName == "" {
+				continue
+			}
+
+			if methods[typeName] == nil {
+				methods[typeName] = make(map[string]*ast.FuncType)
+			}
+			methods[typeName][fn.Name.Name] = fn.Type
+		}
+	}
+
+	typeNames := make([]string, 0, len(methods))
+	for typeName := range methods {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+
+	implementers = make(map[string][]string)
+	for _, ci := range interfaces {
+		for _, typeName := range typeNames {
+			all := true
+			for name, funcType := range ci.methods {
+				implType, ok := methods[typeName][name]
+				all = all && ok && sameArity(funcType, implType)
+			}
+
+			if all {
+				implementers[ci.name] = append(implementers[ci.name], typeName)
+			}
+		}
+	}
+
+	return
+}
+
+// collectInheritance determines which methods of the package inherit the contracts of the interface methods.
+//
+// The files need to belong to the same package. The implementers are determined with go/types. If the package
+// does not type-check, the types declaring all the methods of an interface are considered its implementers.
+func collectInheritance(fset *token.FileSet, files []*ast.File) (inh inheritance, err error) {
+	inh = make(inheritance)
+
+	var interfaces []contractedInterface
+	for _, file := range files {
+		var fileInterfaces []contractedInterface
+		fileInterfaces, err = collectInterfaces(fset, file)
+		if err != nil {
+			return
+		}
+
+		interfaces = append(interfaces, fileInterfaces...)
+	}
+
+	if len(interfaces) == 0 {
+		return
+	}
+
+	implementers, ok := implementersByTypes(fset, files, interfaces)
+	if !ok {
+		implementers = implementersByNames(files, interfaces)
+	}
+
+	for _, ci := range interfaces {
+		for _, typeName := range implementers[ci.name] {
+			for _, m := range ci.contracted {
+				key := typeName + "." + m.name
+				inh[key] = append(inh[key], m)
+			}
+		}
+	}
+
+	return
+}
+
+// parseSiblings parses the other files of the package of the parsed file into the same file set.
+//
+// The files which can not be read or parsed as well as the files of the other packages (e.g., external tests)
+// are ignored.
+func parseSiblings(fset *token.FileSet, node *ast.File, filename string, scope *packageScope) (
+	siblings []*ast.File) {
+
+	for _, pth := range scope.siblings(filename) {
+		data, readErr := ioutil.ReadFile(pth)
+		if readErr != nil {
+			continue
+		}
+
+		sibling, parseErr := parser.ParseFile(fset, pth, data, parser.ParseComments)
+		if parseErr != nil || sibling.Name.Name != node.Name.Name {
+			continue
+		}
+
+		siblings = append(siblings, sibling)
+	}
+
+	return
+}
+
+// packageInheritance determines the inheritance of the contracts in the package of the parsed file given
+// the other files of the package.
+func packageInheritance(fset *token.FileSet, node *ast.File, siblings []*ast.File) (inh inheritance, err error) {
+	files := append([]*ast.File{node}, siblings...)
+	return collectInheritance(fset, files)
+}
+
+// flattenNames lists the names of the fields in order. An unnamed field is listed as an empty string.
+func flattenNames(fields *ast.FieldList) (names []string) {
+	if fields == nil {
+		return
+	}
+
+	for _, field := range fields.List {
+		if len(field.Names) == 0 {
+			names = append(names, "")
+			continue
+		}
+
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+
+	return
+}
+
+// renameIdentifiers renames the identifiers in the code and reports the identifiers which were referred to.
+//
+// The selected fields and methods (e.g., "x" in "s.x") are neither renamed nor reported.
+func renameIdentifiers(code string, names map[string]string) (renamed string, referred map[string]bool) {
+	referred = make(map[string]bool)
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(code))
+
+	var s scanner.Scanner
+	s.Init(file, []byte(code), nil, 0)
+
+	type replacement struct {
+		offset int
+		old    string
+		new    string
+	}
+	replacements := []replacement{}
+
+	prev := token.ILLEGAL
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		if tok == token.IDENT && prev != token.PERIOD {
+			referred[lit] = true
+
+			if newName, ok := names[lit]; ok {
+				replacements = append(replacements, replacement{offset: file.Offset(pos), old: lit, new: newName})
+			}
+		}
+
+		prev = tok
+	}
+
+	renamed = code
+	for i := len(replacements) - 1; i >= 0; i-- {
+		r := replacements[i]
+		renamed = renamed[:r.offset] + r.new + renamed[r.offset+len(r.old):]
+	}
+
+	return
+}
+
+// renameCondition renames the identifiers in the condition and reports the identifiers which were referred to.
+func renameCondition(c parsecond.Condition, names map[string]string) (
+	renamed parsecond.Condition, referred map[string]bool, err error) {
+
+	renamed = c
+	referred = make(map[string]bool)
+
+	rename := func(code string) string {
+		result, refs := renameIdentifiers(code, names)
+		for name := range refs {
+			referred[name] = true
+		}
+
+		return result
+	}
+
+	renamed.InitStr = rename(c.InitStr)
+	renamed.CondStr = rename(c.CondStr)
+
+	renamed.Cond, err = parser.ParseExpr(renamed.CondStr)
+	if err != nil {
+		err = fmt.Errorf("failed to parse the renamed condition %#v: %s", renamed.CondStr, err)
+		return
+	}
+
+	if c.Quantifier != nil {
+		q := *c.Quantifier
+		q.Key = rename(q.Key)
+		q.Value = rename(q.Value)
+		q.CollectionStr = rename(q.CollectionStr)
+
+		q.Collection, err = parser.ParseExpr(q.CollectionStr)
+		if err != nil {
+			err = fmt.Errorf("failed to parse the renamed collection %#v: %s", q.CollectionStr, err)
+			return
+		}
+
+		renamed.Quantifier = &q
+	}
+
+	return
+}
+
+// adaptContract renames the parameters and the results of the interface method in its contract to
+// the names used by the implementing method.
+//
+// The results which are unnamed in the implementation keep the names of the interface so that they can be
+// named automatically or reported.
+func adaptContract(m interfaceMethod, fn *ast.FuncDecl, filename string) (contract parsecomment.Contract, err error) {
+	contract = m.contract
+
+	ifaceParams, implParams := flattenNames(m.fn.Params), flattenNames(fn.Type.Params)
+	ifaceResults, implResults := flattenNames(m.fn.Results), flattenNames(fn.Type.Results)
+
+	if len(ifaceParams) != len(implParams) || len(ifaceResults) != len(implResults) {
+		err = fmt.Errorf("the signature does not correspond to the interface method %s.%s", m.iface, m.name)
+		return
+	}
+
+	names := make(map[string]string)
+	unnamed := make(map[string]bool)
+
+	for i, name := range ifaceParams {
+		switch {
+		case name == "" || name == "_":
+			// The parameter can not be referred to in the contract.
+
+		case implParams[i] == "" || implParams[i] == "_":
+			unnamed[name] = true
+
+		default:
+			names[name] = implParams[i]
+		}
+	}
+
+	for i, name := range ifaceResults {
+		if name != "" && name != "_" && implResults[i] != "" && implResults[i] != "_" {
+			names[name] = implResults[i]
+		}
+	}
+
+	adapt := func(conditions []parsecond.Condition) (adapted []parsecond.Condition, err error) {
+		adapted = make([]parsecond.Condition, 0, len(conditions))
+		for _, c := range conditions {
+			var renamed parsecond.Condition
+			var referred map[string]bool
+			renamed, referred, err = renameCondition(c, names)
+			if err != nil {
+				return
+			}
+
+			for name := range referred {
+				if unnamed[name] {
+					err = fmt.Errorf("the parameter %s needs to be named in order to check "+
+						"the condition %#v inherited from %s.%s", name, c.Text(), m.iface, m.name)
+					return
+				}
+			}
+
+			if m.filename != filename {
+				renamed.File = m.filename
+			}
+
+			adapted = append(adapted, renamed)
+		}
+
+		return
+	}
+
+	contract.Pres, err = adapt(m.contract.Pres)
+	if err != nil {
+		return
+	}
+
+	contract.Posts, err = adapt(m.contract.Posts)
+	return
+}
+
+// documentedIn gives the path of the file where the condition is documented. The inherited conditions are
+// documented in the file of the interface, while all the other conditions are documented in the given file.
+func documentedIn(c parsecond.Condition, filename string) string {
+	if c.File != "" {
+		return c.File
+	}
+
+	return filename
+}
+
+// conditionExpr expresses the condition as a single Go boolean expression including its initialization,
+// its quantifier and the guard of its family.
+func conditionExpr(c parsecond.Condition) string {
+	expr := c.ExprStr()
+	if c.InitStr != "" {
+		expr = fmt.Sprintf("func() bool { %s; return %s }()", c.InitStr, expr)
+	}
+
+	if c.Family != "" {
+		expr = fmt.Sprintf("!%s || (%s)", familyConstant(c.Family), expr)
+	}
+
+	return expr
+}
+
+// disjunction combines the sets of pre-conditions into a single condition which holds if all the conditions
+// of at least one set hold.
+func disjunction(sets [][]parsecond.Condition) (c parsecond.Condition, err error) {
+	parts := make([]string, 0, len(sets))
+	for _, set := range sets {
+		conjuncts := make([]string, 0, len(set))
+		for _, cond := range set {
+			conjuncts = append(conjuncts, "("+conditionExpr(cond)+")")
+		}
+
+		part := strings.Join(conjuncts, " && ")
+		if len(conjuncts) > 1 {
+			part = "(" + part + ")"
+		}
+
+		parts = append(parts, part)
+	}
+
+	c.CondStr = strings.Join(parts, " || ")
+	c.Cond, err = parser.ParseExpr(c.CondStr)
+	if err != nil {
+		err = fmt.Errorf("failed to parse the combined pre-condition %#v: %s", c.CondStr, err)
+		return
+	}
+
+	c.Line = sets[0][0].Line
+	c.File = sets[0][0].File
+	return
+}
+
+// inheritContract merges the contracts of the interface methods into the contract of the implementing method.
+//
+// Following the Liskov substitution principle, the pre-conditions are weakened and the post-conditions are
+// strengthened. If the implementation and the interfaces specify pre-conditions, a single combined pre-condition
+// is checked which holds if either all the pre-conditions of the implementation or all the pre-conditions of
+// one of the interfaces hold. The post-conditions of the implementation and of the interfaces all need to hold.
+func inheritContract(fn *ast.FuncDecl, filename string, own parsecomment.Contract, methods []interfaceMethod) (
+	contract parsecomment.Contract, err error) {
+
+	contract = own
+
+	sets := [][]parsecond.Condition{}
+	if len(own.Pres) > 0 {
+		sets = append(sets, own.Pres)
+	}
+
+	for _, m := range methods {
+		var adapted parsecomment.Contract
+		adapted, err = adaptContract(m, fn, filename)
+		if err != nil {
+			return
+		}
+
+		if len(adapted.Pres) > 0 {
+			sets = append(sets, adapted.Pres)
+			contract.ReturnErrors = contract.ReturnErrors || adapted.ReturnErrors
+		}
+
+		contract.Posts = append(contract.Posts, adapted.Posts...)
+	}
+
+	switch len(sets) {
+	case 0:
+		// There are no pre-conditions.
+
+	case 1:
+		contract.Pres = sets[0]
+
+	default:
+		var combined parsecond.Condition
+		combined, err = disjunction(sets)
+		if err != nil {
+			return
+		}
+
+		contract.Pres = []parsecond.Condition{combined}
+	}
+
+	return
+}
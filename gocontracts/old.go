@@ -0,0 +1,383 @@
+package gocontracts
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// snapshot defines a variable capturing the value of an expression before the function execution.
+type snapshot struct {
+	// Name of the variable
+	Name string
+
+	// Expr is the code of the captured expression.
+	Expr string
+}
+
+// findOldCallsIn lists all the calls to old() in the node.
+//
+// The calls nested in other calls to old() are not listed.
+func findOldCallsIn(node ast.Node) (calls []*ast.CallExpr) {
+	if node == nil {
+		return
+	}
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		ident, ok := call.Fun.(*ast.Ident)
+		if !ok || ident.Name != "old" {
+			return true
+		}
+
+		calls = append(calls, call)
+		return false
+	})
+
+	return
+}
+
+// findOldCalls lists all the calls to old() in the condition including its initialization.
+//
+// The calls nested in other calls to old() are not listed.
+func findOldCalls(c parsecond.Condition) (calls []*ast.CallExpr) {
+	calls = findOldCallsIn(c.Cond)
+
+	if c.InitStr != "" {
+		if init, _, err := parseInit(c.InitStr); err == nil {
+			calls = append(calls, findOldCallsIn(init)...)
+		}
+	}
+
+	return
+}
+
+// referredName gives the first of the names referred to in the node. The selected fields and methods
+// are not considered references. If none of the names is referred to, an empty string is returned.
+func referredName(node ast.Node, names map[string]bool) (name string) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if name != "" {
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			name = referredName(n.X, names)
+			return false
+
+		case *ast.Ident:
+			if names[n.Name] {
+				name = n.Name
+			}
+		}
+
+		return name == ""
+	})
+
+	return
+}
+
+// initPrefix precedes the initialization of a condition so that it can be parsed as a statement.
+const initPrefix = "package p\n\nfunc _() {\n"
+
+// parseInit parses the initialization of a condition. The positions in the statement are offset by
+// the length of initPrefix.
+func parseInit(initStr string) (init ast.Stmt, fset *token.FileSet, err error) {
+	fset = token.NewFileSet()
+
+	var node *ast.File
+	node, err = parser.ParseFile(fset, "", initPrefix+initStr+"\n}\n", 0)
+	if err != nil {
+		return
+	}
+
+	body := node.Decls[0].(*ast.FuncDecl).Body
+	if len(body.List) != 1 {
+		err = fmt.Errorf("expected a single statement, but got %d", len(body.List))
+		return
+	}
+
+	init = body.List[0]
+	return
+}
+
+// printNode prints the node in the canonical format of gofmt.
+func printNode(fset *token.FileSet, node ast.Node) (string, error) {
+	var buf bytes.Buffer
+	err := printer.Fprint(&buf, fset, node)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// oldRewriter replaces the calls to old() with the snapshot variables.
+type oldRewriter struct {
+	// taken are the names which the snapshot variables must not shadow.
+	taken map[string]bool
+
+	nameOf    map[string]string
+	snapshots []snapshot
+	counter   int
+}
+
+// name gives the next name of a snapshot variable which is not taken.
+func (o *oldRewriter) name() string {
+	for {
+		o.counter++
+		name := fmt.Sprintf("old%d", o.counter)
+		if !o.taken[name] {
+			return name
+		}
+	}
+}
+
+// replace replaces the calls to old() in the code parsed as the node. The positions of the node are offset by
+// the given number of bytes with respect to the code. The condition is only used in the error messages.
+func (o *oldRewriter) replace(code string, fset *token.FileSet, node ast.Node, offset int, condStr string) (
+	replaced string, err error) {
+
+	calls := findOldCallsIn(node)
+
+	// Replace from the end so that the offsets of the preceding calls remain valid.
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Pos() > calls[j].Pos() })
+
+	replaced = code
+	for _, call := range calls {
+		if len(call.Args) != 1 {
+			err = fmt.Errorf("expected exactly one argument to old() in the post-condition %#v, but got %d",
+				condStr, len(call.Args))
+			return
+		}
+
+		arg := call.Args[0]
+		if len(findOldCallsIn(arg)) > 0 {
+			err = fmt.Errorf("unexpected nested old() in the post-condition %#v", condStr)
+			return
+		}
+
+		var expr string
+		expr, err = printNode(fset, arg)
+		if err != nil {
+			return
+		}
+
+		name, ok := o.nameOf[expr]
+		if !ok {
+			name = o.name()
+			o.nameOf[expr] = name
+			o.snapshots = append(o.snapshots, snapshot{Name: name, Expr: expr})
+		}
+
+		start := fset.Position(call.Pos()).Offset - offset
+		end := fset.Position(call.End()).Offset - offset
+		replaced = replaced[:start] + name + replaced[end:]
+	}
+
+	return
+}
+
+// rewriteOld replaces the calls to old() in the post-conditions and their initializations with the variables
+// capturing the values before the function execution. The rewritten code is formatted as by gofmt.
+//
+// The rewritten post-conditions correspond to the given post-conditions. Equal expressions share the same
+// snapshot variable. The names of the variables differ from the taken names and from the identifiers
+// in the post-conditions.
+func rewriteOld(posts []parsecond.Condition, taken map[string]bool) (
+	rewritten []parsecond.Condition, snapshots []snapshot, err error) {
+
+	o := &oldRewriter{taken: make(map[string]bool), nameOf: make(map[string]string)}
+	for name := range taken {
+		o.taken[name] = true
+	}
+
+	for _, c := range posts {
+		for _, code := range []string{c.InitStr, c.CondStr} {
+			for name := range identifiers(code) {
+				o.taken[name] = true
+			}
+		}
+	}
+
+	rewritten = make([]parsecond.Condition, 0, len(posts))
+
+	for _, c := range posts {
+		if len(findOldCalls(c)) == 0 {
+			rewritten = append(rewritten, c)
+			continue
+		}
+
+		r := c
+
+		if c.InitStr != "" {
+			r.InitStr, err = o.rewriteInit(c)
+			if err != nil {
+				return
+			}
+		}
+
+		r.CondStr, r.Cond, err = o.rewriteCond(c)
+		if err != nil {
+			return
+		}
+
+		rewritten = append(rewritten, r)
+	}
+
+	snapshots = o.snapshots
+	return
+}
+
+// rewriteInit rewrites the calls to old() in the initialization of the post-condition.
+func (o *oldRewriter) rewriteInit(c parsecond.Condition) (initStr string, err error) {
+	text := c.InitStr + "; " + c.CondStr
+
+	init, fset, err := parseInit(c.InitStr)
+	if err != nil {
+		err = fmt.Errorf("failed to parse the initialization of the post-condition %#v: %s", text, err)
+		return
+	}
+
+	if len(findOldCallsIn(init)) == 0 {
+		initStr = c.InitStr
+		return
+	}
+
+	initStr, err = o.replace(c.InitStr, fset, init, len(initPrefix), text)
+	if err != nil {
+		return
+	}
+
+	var rewritten ast.Stmt
+	rewritten, fset, err = parseInit(initStr)
+	if err != nil {
+		err = fmt.Errorf("failed to parse the initialization of the post-condition %#v with old() rewritten "+
+			"as %#v: %s", text, initStr, err)
+		return
+	}
+
+	return printNode(fset, rewritten)
+}
+
+// rewriteCond rewrites the calls to old() in the post-condition without its initialization.
+func (o *oldRewriter) rewriteCond(c parsecond.Condition) (condStr string, cond ast.Expr, err error) {
+	condStr = c.CondStr
+	cond = c.Cond
+
+	if len(findOldCallsIn(c.Cond)) == 0 {
+		return
+	}
+
+	// The condition expression was parsed from CondStr so that its positions are offsets into CondStr.
+	fset := token.NewFileSet()
+	fset.AddFile("", fset.Base(), len(c.CondStr))
+
+	condStr, err = o.replace(c.CondStr, fset, c.Cond, 0, c.CondStr)
+	if err != nil {
+		return
+	}
+
+	var parsed ast.Expr
+	parsed, err = parser.ParseExpr(condStr)
+	if err != nil {
+		err = fmt.Errorf("failed to parse the post-condition %#v with old() rewritten as %#v: %s",
+			c.CondStr, condStr, err.Error())
+		return
+	}
+
+	condStr, err = printNode(token.NewFileSet(), parsed)
+	if err != nil {
+		return
+	}
+
+	// Parse the formatted condition so that the positions of the expression refer to it.
+	cond, err = parser.ParseExpr(condStr)
+	return
+}
+
+// snapshotCode generates the code capturing the old values.
+//
+// The code is indented. It does not end with a new-line character.
+func snapshotCode(snapshots []snapshot) string {
+	names := make([]string, 0, len(snapshots))
+	exprs := make([]string, 0, len(snapshots))
+	for _, s := range snapshots {
+		names = append(names, s.Name)
+		exprs = append(exprs, s.Expr)
+	}
+
+	return fmt.Sprintf("\t// Old values\n\t%s := %s", strings.Join(names, ", "), strings.Join(exprs, ", "))
+}
+
+// identifiers collects the identifiers in the code.
+func identifiers(code string) map[string]bool {
+	names := make(map[string]bool)
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(code))
+
+	var s scanner.Scanner
+	s.Init(file, []byte(code), nil, 0)
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		if tok == token.IDENT {
+			names[lit] = true
+		}
+	}
+
+	return names
+}
+
+// usedNames collects the identifiers which the generated variables must not shadow: the names in the signature
+// and in the body of the function as well as in its preamble. The previously generated condition checks
+// are skipped.
+func usedNames(up funcUpdate) map[string]bool {
+	names := make(map[string]bool)
+
+	collect := func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+
+		start, end := up.contractInBody.Start, up.contractInBody.End
+		if start != token.NoPos && n.Pos() >= start && n.End() <= end {
+			return false
+		}
+
+		if ident, ok := n.(*ast.Ident); ok {
+			names[ident.Name] = true
+		}
+
+		return true
+	}
+
+	if up.fn.Recv != nil {
+		ast.Inspect(up.fn.Recv, collect)
+	}
+
+	ast.Inspect(up.fn.Type, collect)
+	ast.Inspect(up.fn.Body, collect)
+
+	for name := range identifiers(up.contractInDoc.Preamble) {
+		names[name] = true
+	}
+
+	return names
+}
@@ -0,0 +1,352 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Options define how the files are processed.
+type Options struct {
+	// Remove indicates that the code to check the conditions is removed, but the conditions are left untouched
+	// in the comment.
+	Remove bool
+
+	// TypeCheck indicates that the contracts are type-checked in the scope of their functions before generating
+	// the code. The files with ill-typed contracts are reported with Diagnostics as errors.
+	// The type check is skipped if Remove is set.
+	TypeCheck bool
+
+	// NameResults indicates that the unnamed results of a function are named automatically if its
+	// post-conditions refer to them (e.g., as result or err).
+	NameResults bool
+
+	// Handler is the function called with a contracts.ViolationInfo when a condition is violated instead of
+	// panicking. It is given as "Func" (declared in the processed package), "pkg.Func" or "import/path/pkg.Func".
+	// If empty, the generated code panics.
+	Handler string
+
+	// Functions restricts the processing to the given functions qualified by their receiver types
+	// (e.g., "SomeFunc" or "SomeType.SomeMethod" as listed by Check). If empty, all the functions are processed.
+	Functions []string
+
+	// Coverage indicates that the generated code counts the evaluations of the conditions in a registry
+	// declared in the generated file contracts_coverage.go of the package. The counters are dumped
+	// with contracts.WriteProfile. The external test packages are not instrumented.
+	Coverage bool
+
+	// Toggle indicates that the generated blocks of checks are guarded by the level of the checks
+	// loaded once on entry with contracts.CurrentLevel so that the checks can be disabled or narrowed to
+	// the pre-conditions without rebuilding (e.g., with the environment variable GOCONTRACTS=off).
+	Toggle bool
+
+	// Metrics indicates that the generated code counts the violations with contracts.Counted before they
+	// are handled. The counts are exposed through expvar and in the Prometheus text format by the package
+	// github.com/Parquery/gocontracts/contracts/metrics.
+	Metrics bool
+}
+
+// Result bundles the outcome of processing a single file.
+type Result struct {
+	Path string
+
+	// Text is the original content of the file.
+	Text string
+
+	// Updated is the processed content of the file. It is only valid if Err is nil.
+	Updated string
+
+	// OutOfSync lists the functions whose condition checks did not correspond to the contracts in
+	// the documentation. It is only valid if Err is nil.
+	OutOfSync []string
+
+	// Err is set if the file could not be processed.
+	Err error
+
+	// Generated indicates that the file is generated alongside the processed files (e.g., the constants of
+	// a contract family).
+	Generated bool
+}
+
+// Changed indicates whether processing the file changed its content.
+func (r Result) Changed() bool {
+	return r.Err == nil && r.Text != r.Updated
+}
+
+// isGenerated checks whether the file was marked as generated by a tool.
+//
+// See https://golang.org/s/generatedcode for the convention.
+func isGenerated(text string, pth string) bool {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, pth, text, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		// Let Process report the error.
+		return false
+	}
+
+	return ast.IsGenerated(node)
+}
+
+// processFileToResult loads and processes the file. The errors are recorded in the result.
+//
+// The scope gives the names declared in the other files of the package. It can be nil.
+//
+// If skipGenerated is set, the generated files are not processed and skip is set.
+func processFileToResult(pth string, opts Options, scope *packageScope, skipGenerated bool) (
+	result Result, skip bool) {
+	result.Path = pth
+
+	data, err := ioutil.ReadFile(pth)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to read: %s", err)
+		return
+	}
+
+	result.Text = string(data)
+
+	if skipGenerated && isGenerated(result.Text, pth) {
+		skip = true
+		return
+	}
+
+	result.Updated, result.OutOfSync, result.Err = process(result.Text, pth, opts, scope)
+	return
+}
+
+// packageFiles lists the Go files of the package in the directory including the test files.
+//
+// The files excluded by the build constraints are not listed. If there are no Go files, paths is empty.
+func packageFiles(dir string) (paths []string, err error) {
+	pkg, err := build.Default.ImportDir(dir, build.ImportComment)
+	if _, ok := err.(*build.NoGoError); ok {
+		// There is nothing to process.
+		err = nil
+		return
+	}
+
+	if err != nil {
+		err = fmt.Errorf("failed to import the package from %s: %s", dir, err)
+		return
+	}
+
+	names := make([]string, 0, len(pkg.GoFiles)+len(pkg.CgoFiles)+len(pkg.TestGoFiles)+len(pkg.XTestGoFiles))
+	names = append(names, pkg.GoFiles...)
+	names = append(names, pkg.CgoFiles...)
+	names = append(names, pkg.TestGoFiles...)
+	names = append(names, pkg.XTestGoFiles...)
+	sort.Strings(names)
+
+	paths = make([]string, 0, len(names))
+	for _, name := range names {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+
+	return
+}
+
+// typeCheck type-checks the contracts of the files if requested by the options.
+func typeCheck(paths []string, opts Options) (diagnostics map[string]Diagnostics, err error) {
+	if !opts.TypeCheck || opts.Remove {
+		return
+	}
+
+	return typeCheckFiles(paths)
+}
+
+// withDiagnostics records the diagnostics of the file as the error of the result.
+func withDiagnostics(result Result, diagnostics map[string]Diagnostics) Result {
+	// The type check is more thorough than the checks while processing so its diagnostics take precedence.
+	if diags := diagnostics[result.Path]; len(diags) > 0 {
+		result.Err = diags
+	}
+
+	return result
+}
+
+// ProcessDir processes all the Go files of the package in the directory.
+//
+// The files excluded by the build constraints and the generated files are skipped.
+// The test files are processed as well.
+// The files defining the constants of the contract families used in the package are appended to the results
+// as well as the coverage registry if the conditions are instrumented and the samplers of the sampled functions.
+// The errors in individual files are recorded in the results and do not abort the processing.
+func ProcessDir(dir string, opts Options) (results []Result, err error) {
+	paths, err := packageFiles(dir)
+	if err != nil {
+		return
+	}
+
+	diagnostics, err := typeCheck(paths, opts)
+	if err != nil {
+		return
+	}
+
+	scope := newPackageScope(paths)
+
+	results = make([]Result, 0, len(paths))
+	for _, pth := range paths {
+		result, skip := processFileToResult(pth, opts, scope, true)
+		if !skip {
+			results = append(results, withDiagnostics(result, diagnostics))
+		}
+	}
+
+	results = append(results, familyFiles(dir, results, opts, scope)...)
+	results = append(results, coverageFile(dir, results, opts, scope)...)
+	results = append(results, samplingFile(dir, results, opts, scope)...)
+	return
+}
+
+// skipDir indicates whether the directory should be skipped when walking a directory tree.
+//
+// Following the go tool, the vendor and testdata directories as well as the directories starting with "." or "_"
+// are skipped.
+func skipDir(name string) bool {
+	return name == "vendor" || name == "testdata" ||
+		strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// walkPackages calls fn on every directory of the tree which is not skipped.
+func walkPackages(root string, fn func(dir string)) error {
+	return filepath.Walk(root, func(pth string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		if pth != root && skipDir(info.Name()) {
+			return filepath.SkipDir
+		}
+
+		fn(pth)
+		return nil
+	})
+}
+
+// processTree processes all the packages in the directory tree.
+func processTree(root string, opts Options) (results []Result, err error) {
+	err = walkPackages(root, func(dir string) {
+		dirResults, dirErr := ProcessDir(dir, opts)
+		if dirErr != nil {
+			results = append(results, Result{Path: dir, Err: dirErr})
+		} else {
+			results = append(results, dirResults...)
+		}
+	})
+
+	return
+}
+
+// treeRoot gives the root directory of the pattern ending in "...".
+func treeRoot(pattern string) string {
+	root := strings.TrimSuffix(pattern, "...")
+
+	switch root {
+	case "":
+		return "."
+	case "/":
+		return root
+	default:
+		return strings.TrimSuffix(root, "/")
+	}
+}
+
+// siblingFiles lists the files of the package of an explicitly given file including the file itself.
+//
+// The file is referred to by the given path so that its diagnostics can be matched with its result.
+func siblingFiles(pth string) (paths []string) {
+	paths, err := packageFiles(filepath.Dir(pth))
+	if err != nil {
+		// Consider the file on its own if its package can not be imported.
+		paths = nil
+	}
+
+	found := false
+	for i := range paths {
+		if paths[i] == filepath.Clean(pth) {
+			paths[i] = pth
+			found = true
+		}
+	}
+
+	if !found {
+		paths = append(paths, pth)
+	}
+
+	return
+}
+
+// processExplicitFile processes an explicitly given file in the context of its package.
+//
+// The files defining the constants of the families used in the file are generated as well.
+func processExplicitFile(pth string, opts Options) (results []Result, err error) {
+	paths := siblingFiles(pth)
+
+	diagnostics, err := typeCheck(paths, opts)
+	if err != nil {
+		return
+	}
+
+	scope := newPackageScope(paths)
+
+	result, _ := processFileToResult(pth, opts, scope, false)
+	results = []Result{withDiagnostics(result, diagnostics)}
+	results = append(results, familyFiles(filepath.Dir(pth), results, opts, scope)...)
+	results = append(results, coverageFile(filepath.Dir(pth), results, opts, scope)...)
+	results = append(results, samplingFile(filepath.Dir(pth), results, opts, scope)...)
+	return
+}
+
+// ProcessPackages processes the Go files given as patterns.
+//
+// A pattern is either a path to a Go file, a directory of a package or a directory followed by "/..."
+// to process all the packages in the directory tree (e.g., "./...").
+//
+// The generated files and the files excluded by the build constraints are skipped in directories.
+// The files defining the constants of the contract families are included in the results.
+// The vendor and testdata directories are skipped in directory trees.
+// The errors in individual files are recorded in the results and do not abort the processing.
+func ProcessPackages(patterns []string, opts Options) (results []Result, err error) {
+	for _, pattern := range patterns {
+		var patternResults []Result
+
+		switch {
+		case pattern == "..." || strings.HasSuffix(pattern, "/..."):
+			patternResults, err = processTree(treeRoot(pattern), opts)
+
+		default:
+			var info os.FileInfo
+			info, err = os.Stat(pattern)
+			switch {
+			case err != nil:
+				patternResults = []Result{{Path: pattern, Err: fmt.Errorf("failed to read: %s", err)}}
+				err = nil
+
+			case info.IsDir():
+				patternResults, err = ProcessDir(pattern, opts)
+
+			default:
+				// Explicitly given files are always processed.
+				patternResults, err = processExplicitFile(pattern, opts)
+			}
+		}
+
+		if err != nil {
+			return
+		}
+
+		results = append(results, patternResults...)
+	}
+
+	return
+}
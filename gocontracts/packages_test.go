@@ -0,0 +1,592 @@
+package gocontracts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Parquery/gocontracts/gocontracts/testcases"
+)
+
+// writeTree writes the files to the directory. The paths of the files are relative to the directory.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	for rel, text := range files {
+		pth := filepath.Join(dir, rel)
+
+		err := os.MkdirAll(filepath.Dir(pth), 0700)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		err = ioutil.WriteFile(pth, []byte(text), 0600)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+}
+
+func TestProcessPackages(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "packages_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	cs := testcases.DoubleNegation
+	failure := testcases.FailureCommentParse
+
+	writeTree(t, tmpdir, map[string]string{
+		"somepkg/some.go":      cs.Text,
+		"somepkg/some_test.go": cs.Text,
+		"somepkg/generated.go": "// Code generated by some tool. DO NOT EDIT.\n\n" + cs.Text,
+		"somepkg/ignored.go":   "//go:build ignore\n\n" + cs.Text,
+		"broken/broken.go":     failure.Text,
+		"vendor/other/some.go": cs.Text,
+		"testdata/some.go":     cs.Text,
+		"empty/README.md":      "nothing to see here",
+	})
+
+	results, err := ProcessPackages([]string{tmpdir + "/..."}, Options{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []string{
+		filepath.Join(tmpdir, "broken", "broken.go"),
+		filepath.Join(tmpdir, "somepkg", "some.go"),
+		filepath.Join(tmpdir, "somepkg", "some_test.go"),
+	}
+
+	if len(results) != len(expected) {
+		paths := make([]string, 0, len(results))
+		for _, result := range results {
+			paths = append(paths, result.Path)
+		}
+
+		t.Fatalf("Expected results for %#v, got %#v", expected, paths)
+	}
+
+	for i, result := range results {
+		if result.Path != expected[i] {
+			t.Fatalf("Expected the result %d for %s, got %s", i, expected[i], result.Path)
+		}
+	}
+
+	if results[0].Err == nil || results[0].Err.Error() != failure.Error {
+		t.Fatalf("Expected the error %#v for %s, got %v", failure.Error, results[0].Path, results[0].Err)
+	}
+
+	for _, result := range results[1:] {
+		if result.Err != nil {
+			t.Fatalf("Unexpected error for %s: %s", result.Path, result.Err.Error())
+		}
+
+		if !result.Changed() || result.Updated != cs.Expected {
+			t.Fatalf("Expected %s to be updated to:\n%s\ngot:\n%s", result.Path, cs.Expected, result.Updated)
+		}
+	}
+}
+
+func TestProcessPackages_ExplicitFile(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "packages_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	cs := testcases.DoubleNegation
+
+	// Explicitly given files are processed even if they are excluded by the build constraints.
+	text := "//go:build ignore\n\n" + cs.Text
+	writeTree(t, tmpdir, map[string]string{"some.go": text})
+
+	pth := filepath.Join(tmpdir, "some.go")
+	results, err := ProcessPackages([]string{pth, filepath.Join(tmpdir, "nonexisting.go")}, Options{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	if results[0].Err != nil {
+		t.Fatal(results[0].Err.Error())
+	}
+
+	if results[0].Updated != "//go:build ignore\n\n"+cs.Expected {
+		t.Fatalf("Unexpected update of %s:\n%s", pth, results[0].Updated)
+	}
+
+	if results[1].Err == nil {
+		t.Fatalf("Expected an error for a non-existing file, but got nil")
+	}
+}
+
+func TestTreeRoot(t *testing.T) {
+	for pattern, expected := range map[string]string{
+		"...":          ".",
+		"./...":        ".",
+		"/...":         "/",
+		"some/dir/...": "some/dir",
+		"/some/...":    "/some",
+	} {
+		if got := treeRoot(pattern); got != expected {
+			t.Errorf("Expected the root %#v of the pattern %#v, got %#v", expected, pattern, got)
+		}
+	}
+}
+
+func TestProcessDir_Families(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "packages_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{
+		"some.go": testcases.Families.Text,
+		"manual.go": `package somepkg
+
+// InUtest is managed manually.
+const InUtest = true
+`})
+
+	results, err := ProcessDir(tmpdir, Options{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []string{
+		filepath.Join(tmpdir, "manual.go"),
+		filepath.Join(tmpdir, "some.go"),
+		filepath.Join(tmpdir, "contracts_test_enabled.go"),
+		filepath.Join(tmpdir, "contracts_test_disabled.go"),
+	}
+
+	paths := make([]string, 0, len(results))
+	for _, result := range results {
+		paths = append(paths, result.Path)
+
+		if result.Err != nil {
+			t.Fatalf("Unexpected error for %s: %s", result.Path, result.Err)
+		}
+
+		if result.Generated != strings.HasPrefix(filepath.Base(result.Path), "contracts_") {
+			t.Fatalf("Unexpected Generated %v for %s", result.Generated, result.Path)
+		}
+	}
+
+	if strings.Join(paths, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected results for %#v, got %#v", expected, paths)
+	}
+
+	expectedEnabled := `// Code generated by gocontracts. DO NOT EDIT.
+
+//go:build test
+
+package somepkg
+
+// InTest enables the conditions of the contract family "test". Build with the tag "test" to enable them.
+const InTest = true
+`
+	if results[2].Text != "" || results[2].Updated != expectedEnabled {
+		t.Fatalf("Expected the file %s to be created as:\n%s\ngot:\n%s",
+			results[2].Path, expectedEnabled, results[2].Updated)
+	}
+
+	if !strings.Contains(results[3].Updated, "//go:build !test\n") ||
+		!strings.Contains(results[3].Updated, "const InTest = false\n") {
+		t.Fatalf("Expected the file %s to disable the family, got:\n%s", results[3].Path, results[3].Updated)
+	}
+
+	// The generated files are picked up as they are, but not processed.
+	for _, result := range results[2:] {
+		err = ioutil.WriteFile(result.Path, []byte(result.Updated), 0600)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	results, err = ProcessDir(tmpdir, Options{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 4 || results[2].Changed() || results[3].Changed() {
+		t.Fatalf("Expected the family files to be unchanged, got %#v", results)
+	}
+
+	results, err = ProcessDir(tmpdir, Options{Remove: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected no family files in the remove mode, got %d results", len(results))
+	}
+}
+
+func TestProcessDir_InheritedContracts(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "packages_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{
+		"shape.go": `package somepkg
+
+// Shape defines a geometric shape.
+type Shape interface {
+	// Scale scales the shape.
+	//
+	// Scale requires:
+	//  * factor > 0
+	Scale(factor float64)
+}
+`,
+		"square.go": `package somepkg
+
+// Square is a shape.
+type Square struct {
+	side float64
+}
+
+// Scale scales the square.
+func (sq *Square) Scale(f float64) {
+	sq.side *= f
+}
+
+func report(info contracts.ViolationInfo) {
+	panic(info.Message())
+}
+`})
+
+	results, err := ProcessDir(tmpdir, Options{Handler: "report"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected two results, got %#v", results)
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatalf("Unexpected error for %s: %s", result.Path, result.Err)
+		}
+	}
+
+	if results[0].Changed() {
+		t.Fatalf("Expected the file of the interface to be unchanged, got:\n%s", results[0].Updated)
+	}
+
+	// The violations refer to the condition as documented in the file of the interface.
+	expected := `Condition: "f > 0", File: "shape.go", Line: 8`
+	if !strings.Contains(results[1].Updated, "if !(f > 0) {") ||
+		!strings.Contains(results[1].Updated, expected) {
+		t.Fatalf("Expected the implementation to check the inherited pre-condition, got:\n%s", results[1].Updated)
+	}
+}
+
+func TestProcessDir_SiblingInvariants(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "packages_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{
+		"counter.go": `package somepkg
+
+// Counter counts.
+//
+// Counter invariants:
+//  * c.n >= 0
+type Counter struct {
+	n int
+}
+`,
+		"methods.go": `package somepkg
+
+// Inc increments the counter.
+func (c *Counter) Inc() {
+	c.n++
+}
+
+func report(info contracts.ViolationInfo) {
+	panic(info.Message())
+}
+`})
+
+	results, err := ProcessDir(tmpdir, Options{Handler: "report"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected two results, got %#v", results)
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatalf("Unexpected error for %s: %s", result.Path, result.Err)
+		}
+	}
+
+	if results[0].Changed() {
+		t.Fatalf("Expected the file of the type to be unchanged, got:\n%s", results[0].Updated)
+	}
+
+	// The violations refer to the invariant as documented in the file of the type.
+	expected := `Condition: "c.n >= 0", File: "counter.go", Line: 6`
+	if strings.Count(results[1].Updated, "if !(c.n >= 0) {") != 2 ||
+		!strings.Contains(results[1].Updated, expected) {
+		t.Fatalf("Expected the method to check the invariant on entry and exit, got:\n%s", results[1].Updated)
+	}
+
+	err = ioutil.WriteFile(results[1].Path, []byte(results[1].Updated), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	results, err = ProcessDir(tmpdir, Options{Handler: "report"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, result := range results {
+		if result.Err != nil || result.Changed() {
+			t.Fatalf("Expected %s to be in sync after processing, got %#v", result.Path, result)
+		}
+	}
+}
+
+func TestProcessDir_Coverage(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "packages_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{
+		"some.go": `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * positive: x > 0
+func SomeFunc(x int) {}
+`,
+		"other.go": `package somepkg
+
+// OtherFunc does something.
+//
+// OtherFunc ensures:
+//  * result != ""
+func OtherFunc() (result string) { return "other" }
+`,
+		"some_test.go": `package somepkg_test
+
+// testFunc is not instrumented since the external test package can not refer to the registry.
+//
+// testFunc requires:
+//  * x > 0
+func testFunc(x int) {}
+`})
+
+	results, err := ProcessDir(tmpdir, Options{Coverage: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 4 || results[3].Path != filepath.Join(tmpdir, "contracts_coverage.go") ||
+		!results[3].Generated {
+		t.Fatalf("Expected the coverage registry to be appended to the results, got %#v", results)
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatalf("Unexpected error for %s: %s", result.Path, result.Err)
+		}
+
+		err = ioutil.WriteFile(result.Path, []byte(result.Updated), 0600)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	if strings.Contains(results[2].Updated, "contractsCoverage") {
+		t.Fatalf("Expected the external test package not to be instrumented, got:\n%s", results[2].Updated)
+	}
+
+	expected := `// Code generated by gocontracts. DO NOT EDIT.
+
+package somepkg
+
+import "github.com/Parquery/gocontracts/contracts"
+
+// contractsCoverage counts the evaluations of the instrumented conditions of the package.
+var contractsCoverage = contracts.NewCoverage("somepkg", []contracts.Counter{
+	{Kind: contracts.Postcondition, Function: "OtherFunc", Condition: "result != \"\"", File: "other.go", Line: 6},
+	{Kind: contracts.Precondition, Function: "SomeFunc", Label: "positive", Condition: "x > 0", File: "some.go", Line: 6},
+})
+`
+	if results[3].Updated != expected {
+		t.Fatalf("Expected the coverage registry:\n%s\ngot:\n%s", expected, results[3].Updated)
+	}
+
+	// The registry still lists the instrumented conditions of the other files if a single file is processed.
+	results, err = ProcessPackages([]string{filepath.Join(tmpdir, "some.go")}, Options{Coverage: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 2 || results[0].Changed() || results[1].Changed() {
+		t.Fatalf("Expected the file and the coverage registry to be unchanged, got %#v", results)
+	}
+
+	results, err = ProcessDir(tmpdir, Options{Remove: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Expected no coverage registry in the remove mode, got %d results", len(results))
+	}
+}
+
+func TestProcessDir_Sampling(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "packages_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{"some.go": testcases.Sampling.Text})
+
+	results, err := ProcessDir(tmpdir, Options{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 2 || results[1].Path != filepath.Join(tmpdir, "contracts_sampling.go") ||
+		!results[1].Generated {
+		t.Fatalf("Expected the samplers to be appended to the results, got %#v", results)
+	}
+
+	expected := `// Code generated by gocontracts. DO NOT EDIT.
+
+package somepkg
+
+import "github.com/Parquery/gocontracts/contracts"
+
+// contractsSample_SomeFunc selects the calls of SomeFunc whose conditions are checked.
+var contractsSample_SomeFunc = contracts.NewSampler(1000)
+`
+	if results[1].Err != nil || results[1].Updated != expected {
+		t.Fatalf("Expected the samplers:\n%s\ngot:\n%s (%v)", expected, results[1].Updated, results[1].Err)
+	}
+
+	// The samplers are dropped once no function is sampled anymore.
+	writeTree(t, tmpdir, map[string]string{
+		"some.go":               testcases.NoConditions.Text,
+		"contracts_sampling.go": expected})
+
+	results, err = ProcessDir(tmpdir, Options{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 2 || !results[1].Changed() ||
+		results[1].Updated != "// Code generated by gocontracts. DO NOT EDIT.\n\npackage somepkg\n" {
+		t.Fatalf("Expected the samplers to be emptied, got %#v", results)
+	}
+}
+
+func TestProcessDir_SamplerCollision(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "packages_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{"some.go": `package somepkg
+
+// A_B does something.
+//
+// A_B requires:
+//  * x > 0
+//
+// A_B contracts: sample 1/2
+func A_B(x int) {}
+
+// A is something.
+type A struct{}
+
+// B does something.
+//
+// B requires:
+//  * x > 0
+//
+// B contracts: sample 1/3
+func (a *A) B(x int) {}
+`})
+
+	results, err := ProcessDir(tmpdir, Options{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 2 || results[1].Path != filepath.Join(tmpdir, "contracts_sampling.go") {
+		t.Fatalf("Expected the samplers to be appended to the results, got %#v", results)
+	}
+
+	expected := "the functions A_B and A.B can not be sampled since their samplers would both be named contractsSample_A_B"
+	if results[1].Err == nil || results[1].Err.Error() != expected {
+		t.Fatalf("Expected the error %q, got %v", expected, results[1].Err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
 	"go/token"
 	"io/ioutil"
@@ -20,13 +21,22 @@ import (
 
 // funcUpdate defines how a function should be updated.
 type funcUpdate struct {
-	contractInDoc  parsecomment.Contract
+	contractInDoc parsecomment.Contract
+
+	// invariants of the receiver's type to be checked on entry and exit of the method
+	invariants []parsecond.Condition
+
 	fn             *ast.FuncDecl
 	contractInBody parsebody.Contract
+
+	// resultNames are the names given to the unnamed results of the function. If empty, the results are
+	// left as they are.
+	resultNames []string
 }
 
-func violationMsg(c parsecond.Condition) string {
-	parts := make([]string, 0, 6)
+// violationText gives the message reporting the violated condition.
+func violationText(c parsecond.Condition) string {
+	parts := make([]string, 0, 3)
 
 	parts = append(parts, "Violated: ")
 
@@ -34,14 +44,31 @@ func violationMsg(c parsecond.Condition) string {
 		parts = append(parts, fmt.Sprintf("%s: ", c.Label))
 	}
 
-	if len(c.InitStr) > 0 {
-		parts = append(parts, fmt.Sprintf("%s; ", c.InitStr))
+	parts = append(parts, c.Text())
+	return strings.Join(parts, "")
+}
+
+func violationMsg(c parsecond.Condition) string {
+	return strconv.Quote(violationText(c))
+}
+
+// offendingKey gives the variable identifying the element which violated a universally quantified condition.
+//
+// It is empty if the condition is not universally quantified or if the key is a blank identifier.
+func offendingKey(c parsecond.Condition) string {
+	q := c.Quantifier
+	if q == nil || q.Exists || q.Key == "_" {
+		return ""
 	}
 
-	parts = append(parts, c.CondStr)
-	msg := strings.Join(parts, "")
+	return q.Key
+}
 
-	return strconv.Quote(msg)
+// violationFormat gives the format of the message reporting the element which violated a universally
+// quantified condition. The format expects the key of the element as its only argument.
+func violationFormat(c parsecond.Condition) string {
+	return strconv.Quote(fmt.Sprintf("%s (%s = %%v)",
+		strings.Replace(violationText(c), "%", "%%", -1), offendingKey(c)))
 }
 
 func negateNot(condStr string, unary *ast.UnaryExpr) string {
@@ -86,28 +113,242 @@ func notCondStr(c parsecond.Condition) string {
 
 // conditionToCode generates the condition as Golang code to be inserted
 // into "if" and "switch" statements.
+//
+// The condition of a family is only checked if the family is enabled.
 func conditionToCode(c parsecond.Condition) string {
+	notCond := guarded(notCondStr(c), c.Family)
+
 	if c.InitStr == "" {
-		return notCondStr(c)
+		return notCond
+	}
+
+	return fmt.Sprintf("%s; %s", c.InitStr, notCond)
+}
+
+// check defines how a single condition is checked in the generated code.
+type check struct {
+	// Code is the negated condition to be inserted into "if" and "switch" statements.
+	//
+	// If the condition is universally quantified, Code is the negated condition on a single element.
+	// If the condition is existentially quantified, Code is the condition on a single element as-is.
+	Code string
+
+	// Violation is the statement executed when the condition is violated.
+	Violation string
+
+	// Quantified condition, if any
+	Quantified *parsecond.Condition
+
+	// Gate is the constant enabling the family of the quantified condition, if any.
+	Gate string
+
+	// Break is set if the loop over the elements of a universally quantified condition needs to be stopped
+	// explicitly after the first violation.
+	Break bool
+}
+
+// newCheck creates the check of the condition of the given kind (e.g., "Precondition").
+//
+// The code is generated from the condition code, while the violation refers to the condition
+// as it was written in the documentation.
+func newCheck(code parsecond.Condition, documented parsecond.Condition, s site, kind string) check {
+	c := checkWithViolation(code, s.violation(kind, documented))
+
+	if s.coverage {
+		c.Code = coveredCode(code, documented, s)
+	}
+
+	if code.Quantifier != nil {
+		// Neither returning an error nor panicking needs a break.
+		c.Break = !code.Quantifier.Exists && s.handler != nil && !(kind == "Precondition" && s.returnErrors)
 	}
 
-	return fmt.Sprintf("%s; %s", c.InitStr, notCondStr(c))
+	return c
+}
+
+// checkWithViolation creates the check of the condition which executes the given statement on violation.
+func checkWithViolation(code parsecond.Condition, violation string) check {
+	c := check{
+		Code:      conditionToCode(code),
+		Violation: violation}
+
+	if code.Quantifier != nil {
+		c.Quantified = &code
+
+		// The loops over the elements are guarded as a whole.
+		c.Code = notCondStr(code)
+		if code.Quantifier.Exists {
+			c.Code = strings.Trim(code.CondStr, " \t")
+		}
+
+		if code.Family != "" {
+			c.Gate = familyConstant(code.Family)
+		}
+	}
+
+	return c
+}
+
+// statement generates the statement checking the condition.
+//
+// The lines are not indented.
+func (c check) statement() []string {
+	q := c.Quantified
+	switch {
+	case q == nil:
+		return []string{
+			fmt.Sprintf("if %s {", c.Code),
+			"\t" + c.Violation,
+			"}"}
+
+	case q.Quantifier.Exists:
+		gate := ""
+		if c.Gate != "" {
+			gate = c.Gate + " && "
+		}
+
+		return []string{
+			fmt.Sprintf("if %s!func() bool {", gate),
+			fmt.Sprintf("\tfor %s {", q.RangeClause()),
+			fmt.Sprintf("\t\tif %s {", c.Code),
+			"\t\t\treturn true",
+			"\t\t}",
+			"\t}",
+			"\treturn false",
+			"}() {",
+			"\t" + c.Violation,
+			"}"}
+
+	default:
+		lines := []string{
+			fmt.Sprintf("for %s {", q.RangeClause(offendingKey(*q))),
+			fmt.Sprintf("\tif %s {", c.Code),
+			"\t\t" + c.Violation}
+
+		if c.Break {
+			lines = append(lines, "\t\tbreak")
+		}
+
+		lines = append(lines, "\t}", "}")
+
+		if c.Gate == "" {
+			return lines
+		}
+
+		guardedLines := []string{fmt.Sprintf("if %s {", c.Gate)}
+		for _, l := range lines {
+			guardedLines = append(guardedLines, "\t"+l)
+		}
+
+		return append(guardedLines, "}")
+	}
+}
+
+// checkBlock defines the data needed to generate a block of condition checks.
+type checkBlock struct {
+	// Comment introduces the block in the code (e.g., "Pre-conditions").
+	Comment string
+
+	Checks []check
+}
+
+// newCheckBlock creates a block of condition checks whose comment is pluralized according to the number of
+// the checks.
+func newCheckBlock(singular string, plural string, checks []check) checkBlock {
+	comment := singular
+	if len(checks) > 1 {
+		comment = plural
+	}
+
+	return checkBlock{Comment: comment, Checks: checks}
+}
+
+// quantified checks whether any of the conditions in the block is quantified.
+func (b checkBlock) quantified() bool {
+	for _, c := range b.Checks {
+		if c.Quantified != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sequentialCode generates the checks as a sequence of statements since the quantified conditions can not be
+// checked in the cases of a "switch" statement.
+//
+// A single check is generated as a single statement, while multiple checks are enclosed in a block.
+// If deferred is set, the checks are deferred to the function exit.
+//
+// The code is indented. It does not end with a new-line character.
+func sequentialCode(b checkBlock, deferred bool) string {
+	lines := []string{"// " + b.Comment}
+
+	var body []string
+	for _, c := range b.Checks {
+		body = append(body, c.statement()...)
+	}
+
+	switch {
+	case deferred:
+		lines = append(lines, "defer func() {")
+		for _, l := range body {
+			lines = append(lines, "\t"+l)
+		}
+		lines = append(lines, "}()")
+
+	case len(b.Checks) > 1:
+		lines = append(lines, "{")
+		for _, l := range body {
+			lines = append(lines, "\t"+l)
+		}
+		lines = append(lines, "}")
+
+	default:
+		lines = append(lines, body...)
+	}
+
+	return "\t" + strings.Join(lines, "\n\t")
+}
+
+// executeChecks generates the code of the block of checks with the given template unless the block needs
+// to be generated sequentially.
+func executeChecks(tpl *template.Template, b checkBlock, deferred bool) (code string, err error) {
+	if b.quantified() {
+		code = sequentialCode(b, deferred)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = tpl.Execute(&buf, b)
+	if err != nil {
+		return
+	}
+
+	code = buf.String()
+	return
+}
+
+// toChecks creates the checks of the conditions as they were written in the documentation.
+func toChecks(conditions []parsecond.Condition, s site, kind string) (checks []check) {
+	checks = make([]check, 0, len(conditions))
+	for _, c := range conditions {
+		checks = append(checks, newCheck(c, c, s, kind))
+	}
+
+	return
 }
 
 var tplPre = template.Must(
-	template.New("preconditions").Funcs(
-		template.FuncMap{
-			"violationMsg":    violationMsg,
-			"conditionToCode": conditionToCode,
-		}).Parse(
-		`{{$l := len .Pres }}{{ if eq $l 1 }}{{ $c := index .Pres 0 }}	// Pre-condition
-	if {{ conditionToCode $c }} {
-		panic({{ violationMsg $c }})
-	}
-{{- else }}	// Pre-conditions
-	switch { {{- range .Pres }}
-	case {{ conditionToCode . }}:
-		panic({{ violationMsg . }})
+	template.New("preconditions").Parse(
+		`{{$l := len .Checks }}{{ if eq $l 1 }}{{ $c := index .Checks 0 }}	// {{ .Comment }}
+	if {{ $c.Code }} {
+		{{ $c.Violation }}
+	}
+{{- else }}	// {{ .Comment }}
+	switch { {{- range .Checks }}
+	case {{ .Code }}:
+		{{ .Violation }}
 {{- end }}
 	default:
 		// Pass
@@ -115,22 +356,18 @@ var tplPre = template.Must(
 {{- end }}`))
 
 var tplPost = template.Must(
-	template.New("postconditions").Funcs(
-		template.FuncMap{
-			"violationMsg":    violationMsg,
-			"conditionToCode": conditionToCode,
-		}).Parse(
-		`{{$l := len .Posts }}{{ if eq $l 1 }}{{ $c := index .Posts 0 }}	// Post-condition
+	template.New("postconditions").Parse(
+		`{{$l := len .Checks }}{{ if eq $l 1 }}{{ $c := index .Checks 0 }}	// {{ .Comment }}
 	defer func() {
-		if {{ conditionToCode $c }} {
-			panic({{ violationMsg $c }})
+		if {{ $c.Code }} {
+			{{ $c.Violation }}
 		}
 	}()
-{{- else }}	// Post-conditions
+{{- else }}	// {{ .Comment }}
 	defer func() {
-		switch { {{- range .Posts }}
-		case {{ conditionToCode . }}:
-			panic({{ violationMsg . }})
+		switch { {{- range .Checks }}
+		case {{ .Code }}:
+			{{ .Violation }}
 		{{- end }}
 		default:
 			// Pass
@@ -138,11 +375,17 @@ var tplPost = template.Must(
 	}()
 {{- end }}`))
 
-// generateCode generates the code of the two blocks.
+// generateCode generates the code of the contract blocks.
+//
+// The site determines how the violations are reported.
+// The invariants are checked both on entry and on exit of the function.
+// The calls to old() in the post-conditions are replaced with the values captured before the function execution.
+// The variables capturing the values do not shadow the names of the site.
 //
 // The first line of generated code is indented.
 // The generated code does not end with a new-line character.
-func generateCode(contract parsecomment.Contract) (code string, err error) {
+func generateCode(contract parsecomment.Contract, invariants []parsecond.Condition, s site) (
+	code string, err error) {
 	// Post-condition
 	defer func() {
 		if strings.HasSuffix(code, "\n") {
@@ -150,18 +393,100 @@ func generateCode(contract parsecomment.Contract) (code string, err error) {
 		}
 	}()
 
+	for _, conditions := range [][]parsecond.Condition{contract.Pres, invariants} {
+		for _, c := range conditions {
+			if len(findOldCalls(c)) > 0 {
+				err = fmt.Errorf("unexpected old() in the condition %#v; "+
+					"old() can be only used in post-conditions", c.CondStr)
+				return
+			}
+		}
+	}
+
+	for _, conditions := range [][]parsecond.Condition{contract.Pres, invariants, contract.Posts} {
+		for _, c := range conditions {
+			if c.Quantifier != nil && len(findOldCalls(parsecond.Condition{Cond: c.Quantifier.Collection})) > 0 {
+				err = fmt.Errorf("unexpected old() in the collection of the quantified condition %#v; "+
+					"old() can be only used in the condition on the elements", c.Text())
+				return
+			}
+		}
+	}
+
+	for _, c := range contract.Posts {
+		if c.Quantifier == nil {
+			continue
+		}
+
+		bound := make(map[string]bool)
+		for _, name := range []string{c.Quantifier.Key, c.Quantifier.Value} {
+			if name != "" && name != "_" {
+				bound[name] = true
+			}
+		}
+
+		for _, call := range findOldCalls(c) {
+			if name := referredName(call, bound); name != "" {
+				err = fmt.Errorf("unexpected old() referring to the variable %s bound by the quantified condition %#v; "+
+					"old() can be only applied to the values known on entry of the function", name, c.Text())
+				return
+			}
+		}
+	}
+
+	var posts []parsecond.Condition
+	var snapshots []snapshot
+	posts, snapshots, err = rewriteOld(contract.Posts, s.names)
+	if err != nil {
+		return
+	}
+
 	blocks := []string{}
 
+	if s.toggle && (len(contract.Pres) > 0 || len(invariants) > 0 || len(contract.Posts) > 0) {
+		blocks = append(blocks, s.levelCode())
+	}
+
+	if s.sampler != "" && (len(contract.Pres) > 0 || len(invariants) > 0 || len(contract.Posts) > 0) {
+		blocks = append(blocks, s.samplingCode())
+	}
+
 	if len(contract.Pres) > 0 {
-		var buf bytes.Buffer
-		err = tplPre.Execute(&buf, contract)
+		singular, plural := "Pre-condition", "Pre-conditions"
+		if s.returnErrors {
+			singular, plural = "Pre-condition (error)", "Pre-conditions (error)"
+		}
+
+		var block string
+		block, err = executeChecks(tplPre,
+			newCheckBlock(singular, plural, toChecks(contract.Pres, s, "Precondition")), false)
 		if err != nil {
 			return
 		}
 
-		blocks = append(blocks, buf.String())
+		blocks = append(blocks, s.guardedBlock(block, "LevelPre"))
 	}
 
+	if len(invariants) > 0 {
+		var block string
+		block, err = executeChecks(tplPre, newCheckBlock("Invariant on entry", "Invariants on entry",
+			toChecks(invariants, s, "Invariant")), false)
+		if err != nil {
+			return
+		}
+
+		blocks = append(blocks, s.guardedBlock(block, "LevelAll"))
+
+		block, err = executeChecks(tplPost, newCheckBlock("Invariant on exit", "Invariants on exit",
+			toChecks(invariants, s, "Invariant")), true)
+		if err != nil {
+			return
+		}
+
+		blocks = append(blocks, s.guardedBlock(block, "LevelAll"))
+	}
+
+	var preamble string
 	if len(contract.Preamble) > 0 {
 		// Since Golang package text/template does not contain "indent" filter,
 		// we manually indent the code and do not use a template here.
@@ -176,17 +501,41 @@ func generateCode(contract parsecomment.Contract) (code string, err error) {
 		}
 
 		buf.WriteString("\t// Preamble ends.")
-		blocks = append(blocks, buf.String())
+		preamble = buf.String()
 	}
 
-	if len(contract.Posts) > 0 {
-		var buf bytes.Buffer
-		err = tplPost.Execute(&buf, contract)
+	var snapshotBlock string
+	if len(snapshots) > 0 {
+		snapshotBlock = snapshotCode(snapshots)
+	}
+
+	if len(contract.Posts) == 0 {
+		if preamble != "" {
+			blocks = append(blocks, preamble)
+		}
+	} else {
+		checks := make([]check, 0, len(posts))
+		for i := range posts {
+			checks = append(checks, newCheck(posts[i], contract.Posts[i], s, "Postcondition"))
+		}
+
+		var block string
+		block, err = executeChecks(tplPost, newCheckBlock("Post-condition", "Post-conditions", checks), true)
 		if err != nil {
 			return
 		}
 
-		blocks = append(blocks, buf.String())
+		if s.toggle || s.sampler != "" {
+			// The preamble and the old values are only needed by the post-conditions so that they are
+			// guarded together.
+			blocks = append(blocks, s.guardedBlock(foldedBlock(block, preamble, snapshotBlock), "LevelAll"))
+		} else {
+			for _, b := range []string{preamble, snapshotBlock, s.guardedBlock(block, "LevelAll")} {
+				if b != "" {
+					blocks = append(blocks, b)
+				}
+			}
+		}
 	}
 
 	code = strings.Join(blocks, "\n\n")
@@ -195,7 +544,8 @@ func generateCode(contract parsecomment.Contract) (code string, err error) {
 
 // updateEmptyFunc updates the function which contains no body.
 // cursor points to the end of the function.
-func updateEmptyFunc(fset *token.FileSet, up funcUpdate, code string, writer *bytes.Buffer) (cursor int) {
+func updateEmptyFunc(
+	fset *token.FileSet, up funcUpdate, code string, indent string, writer *bytes.Buffer) (cursor int) {
 	// The function contains no statements except the conditions so we can simply fill it out.
 	cursor = fset.Position(up.fn.Body.Rbrace).Offset // Move cursor to the end of the function
 
@@ -203,6 +553,7 @@ func updateEmptyFunc(fset *token.FileSet, up funcUpdate, code string, writer *by
 		writer.WriteRune('\n')
 		writer.WriteString(code)
 		writer.WriteRune('\n')
+		writer.WriteString(indent)
 	}
 
 	return
@@ -211,7 +562,7 @@ func updateEmptyFunc(fset *token.FileSet, up funcUpdate, code string, writer *by
 // updateSingleLineFunc updates the function whose body is a single line of statements separated by ';'.
 // cursor points just after the right brace of the function definition.
 func updateSingleLineFunc(
-	fset *token.FileSet, up funcUpdate, code string, text string, writer *bytes.Buffer) (cursor int) {
+	fset *token.FileSet, up funcUpdate, code string, indent string, text string, writer *bytes.Buffer) (cursor int) {
 
 	lbraceOffset := fset.Position(up.fn.Body.Lbrace).Offset
 	rbraceOffset := fset.Position(up.fn.Body.Rbrace).Offset
@@ -225,7 +576,7 @@ func updateSingleLineFunc(
 
 		// Add an indention so that the statements on the single line are indented properly after the contract
 		// conditions.
-		writer.WriteRune('\t')
+		writer.WriteString(indent + "\t")
 
 		// Write the function body
 
@@ -235,7 +586,7 @@ func updateSingleLineFunc(
 
 		// Write a new line so that the previous single-line function is nicely reformatted as a multi-line
 		// function.
-		writer.WriteString("\n}")
+		writer.WriteString("\n" + indent + "}")
 	} else {
 		writer.WriteString(text[lbraceOffset+1 : rbraceOffset+1])
 	}
@@ -281,7 +632,76 @@ func updateMultilineFunc(
 	return
 }
 
-func update(text string, updates []funcUpdate, fset *token.FileSet) (updated string, err error) {
+// lineIndent gives the leading white space of the line which contains the offset.
+func lineIndent(text string, offset int) string {
+	start := strings.LastIndex(text[:offset], "\n") + 1
+
+	end := start
+	for end < offset && (text[end] == ' ' || text[end] == '\t') {
+		end++
+	}
+
+	return text[start:end]
+}
+
+// indentCode prefixes the non-empty lines of the code with the indentation.
+func indentCode(code string, indent string) string {
+	if indent == "" {
+		return code
+	}
+
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// normalizeCode formats the code of the contract blocks so that it can be compared regardless of the formatting.
+func normalizeCode(code string) string {
+	src := "package p\n\nfunc _() {\n" + code + "\n}\n"
+
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return strings.TrimSpace(code)
+	}
+
+	return string(formatted)
+}
+
+// inSync checks whether the generated code corresponds to the contract blocks already present in the function body.
+func inSync(text string, fset *token.FileSet, up funcUpdate, code string) bool {
+	existing := ""
+	if up.contractInBody.Start != token.NoPos {
+		start := fset.Position(up.contractInBody.Start).Offset
+		end := fset.Position(up.contractInBody.End).Offset
+		existing = text[start:end]
+	}
+
+	return normalizeCode(existing) == normalizeCode(code)
+}
+
+// funcName returns the name of the function as referred to in the reports.
+// The name of a method is prefixed with the name of its receiver's type.
+func funcName(fn *ast.FuncDecl) string {
+	if typeName := ReceiverType(fn); typeName != "" {
+		return typeName + "." + fn.Name.Name
+	}
+
+	return fn.Name.Name
+}
+
+// update writes the generated code to the function bodies.
+// The functions whose contract blocks did not correspond to the generated code are listed in outOfSync.
+// The base site determines how the conditions are checked in all the functions (e.g., the handler called on
+// violations instead of panicking); the function and the reporting of the violated pre-conditions as errors are
+// set per function.
+func update(text string, updates []funcUpdate, fset *token.FileSet, base site) (
+	updated string, outOfSync []string, err error) {
+
 	writer := bytes.NewBufferString("")
 
 	cursor := 0
@@ -290,19 +710,56 @@ func update(text string, updates []funcUpdate, fset *token.FileSet) (updated str
 		lbraceOffset := fset.Position(up.fn.Body.Lbrace).Offset
 
 		// Write the prefix
-		writer.WriteString(text[cursor : lbraceOffset+1])
+		if len(up.resultNames) > 0 {
+			resultsStart := fset.Position(up.fn.Type.Results.Pos()).Offset
+			resultsEnd := fset.Position(up.fn.Type.Results.End()).Offset
+
+			writer.WriteString(text[cursor:resultsStart])
+			writer.WriteString(namedResultsCode(fset, text, up.fn, up.resultNames))
+			writer.WriteString(text[resultsEnd : lbraceOffset+1])
+		} else {
+			writer.WriteString(text[cursor : lbraceOffset+1])
+		}
+
+		s := base
+		s.function = funcName(up.fn)
+		s.names = usedNames(up)
+		if s.toggle {
+			s.level = freshName(levelVar, s.names)
+		}
+		if s.sampling && up.contractInDoc.Sample > 0 {
+			s.sampler = samplerVar(up.fn)
+			s.sampled = freshName(sampledVar, s.names)
+		}
+		if up.contractInDoc.ReturnErrors && len(up.contractInDoc.Pres) > 0 {
+			s.returnErrors = true
+			s.zeros, err = zeroResults(fset, text, up.fn)
+		}
 
 		var code string
-		code, err = generateCode(up.contractInDoc)
+		if err == nil {
+			code, err = generateCode(up.contractInDoc, up.invariants, s)
+		}
+
 		if err != nil {
+			err = fmt.Errorf("failed to generate the code of the function %s on line %d: %s",
+				up.fn.Name.Name, fset.Position(up.fn.Pos()).Line, err.Error())
 			return
 		}
 
+		if !inSync(text, fset, up, code) || len(up.resultNames) > 0 {
+			outOfSync = append(outOfSync, funcName(up.fn))
+		}
+
+		// The function literals in a group of variable declarations are indented.
+		indent := lineIndent(text, lbraceOffset)
+		code = indentCode(code, indent)
+
 		switch {
 		case up.contractInBody.NextNodePos == token.NoPos:
 			// The function contains no statements except the conditions so we can simply fill it out.
 
-			cursor = updateEmptyFunc(fset, up, code, writer)
+			cursor = updateEmptyFunc(fset, up, code, indent, writer)
 
 		case fset.Position(up.fn.Body.Lbrace).Line == fset.Position(up.fn.Body.Rbrace).Line:
 			// The function contains statements on the same lines as the braces.
@@ -315,7 +772,7 @@ func update(text string, updates []funcUpdate, fset *token.FileSet) (updated str
 					fset.Position(up.contractInBody.Start).Line))
 			}
 
-			cursor = updateSingleLineFunc(fset, up, code, text, writer)
+			cursor = updateSingleLineFunc(fset, up, code, indent, text, writer)
 
 		default:
 			// The function contains one or more statements and possibly a previously generated code to
@@ -332,25 +789,246 @@ func update(text string, updates []funcUpdate, fset *token.FileSet) (updated str
 	return
 }
 
+// ReceiverType returns the name of the receiver's type stripped of the pointer and the type parameters.
+// If fn is not a method, an empty string is returned.
+func ReceiverType(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+
+	expr := fn.Recv.List[0].Type
+	for {
+		switch v := expr.(type) {
+		case *ast.StarExpr:
+			expr = v.X
+		case *ast.ParenExpr:
+			expr = v.X
+		case *ast.IndexExpr:
+			expr = v.X
+		case *ast.IndexListExpr:
+			expr = v.X
+		case *ast.Ident:
+			return v.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// collectInvariants parses the invariants from the documentation of all the types declared in the file.
+// The invariants are mapped by the type name. The lines of the invariants refer to the lines in the file.
+func collectInvariants(fset *token.FileSet, node *ast.File) (invariants map[string][]parsecond.Condition, err error) {
+	invariants = make(map[string][]parsecond.Condition)
+
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+
+			doc := typeSpec.Doc
+			if doc == nil && !genDecl.Lparen.IsValid() {
+				// The documentation of a single type declaration is attached to the declaration.
+				doc = genDecl.Doc
+			}
+
+			if doc == nil {
+				continue
+			}
+
+			name := typeSpec.Name.Name
+
+			lines, fileLines := commentLines(fset, doc)
+
+			var invs []parsecond.Condition
+			invs, err = parsecomment.ToInvariants(name, lines)
+			if err != nil {
+				err = fmt.Errorf("failed to parse comments of the type %s on line %d: %s",
+					name, fset.Position(doc.Pos()).Line, err)
+				return
+			}
+
+			locateConditions(invs, fileLines)
+
+			if len(invs) > 0 {
+				invariants[name] = invs
+			}
+		}
+	}
+
+	return
+}
+
+// siblingInvariants collects the invariants of the types declared in the other files of the package.
+//
+// The conditions refer to the files where they are documented. The files whose invariants can not be parsed
+// are skipped since the problems are reported when the files are processed themselves.
+func siblingInvariants(fset *token.FileSet, siblings []*ast.File) (invariants map[string][]parsecond.Condition) {
+	invariants = make(map[string][]parsecond.Condition)
+
+	for _, sibling := range siblings {
+		invs, err := collectInvariants(fset, sibling)
+		if err != nil {
+			continue
+		}
+
+		filename := fset.Position(sibling.Package).Filename
+		for typeName, conditions := range invs {
+			for i := range conditions {
+				conditions[i].File = filename
+			}
+
+			invariants[typeName] = conditions
+		}
+	}
+
+	return
+}
+
 // Process automatically adds (or updates) the blocks for checking the pre and postconditions.
+// The invariants of a type are checked on entry and exit of its every exported method.
+// The packages referred to in the contracts are automatically imported, while the imports which became
+// unused by the update are removed.
 // If remove is set, the code to check the conditions is removed, but the conditions are left untouched
 // in the comment.
 func Process(text string, filename string, remove bool) (updated string, err error) {
-	fset := token.NewFileSet()
+	updated, _, err = process(text, filename, Options{Remove: remove}, nil)
+	return
+}
 
-	var node *ast.File
-	node, err = parser.ParseFile(fset, filename, text, parser.ParseComments)
+// ProcessWithOptions processes the text of a Go file as Process does, but allows for specifying
+// further options.
+//
+// The type check is not performed since the other files of the package are unknown.
+func ProcessWithOptions(text string, filename string, opts Options) (updated string, err error) {
+	updated, _, err = process(text, filename, opts, nil)
+	return
+}
+
+// ProcessInPackage processes the text of a Go file as ProcessWithOptions does, but in the context of
+// the other files of its package in the directory of the file. It also lists the functions out of sync as
+// CheckWithOptions does.
+//
+// The text takes the place of the file on disk (e.g., an unsaved document in an editor) so that the inherited
+// conditions and the invariants declared in the other files are taken into account.
+func ProcessInPackage(text string, filename string, opts Options) (updated string, outOfSync []string, err error) {
+	return process(text, filename, opts, newPackageScope(siblingFiles(filename)))
+}
+
+// Check lists the functions whose condition checks in the code do not correspond to the contracts in
+// the documentation.
+// If remove is set, the functions which still contain the condition checks are listed.
+func Check(text string, filename string, remove bool) (outOfSync []string, err error) {
+	_, outOfSync, err = process(text, filename, Options{Remove: remove}, nil)
+	return
+}
+
+// CheckWithOptions lists the functions out of sync as Check does, but allows for specifying further options.
+//
+// The functions whose results need to be named are listed as well if NameResults is set.
+func CheckWithOptions(text string, filename string, opts Options) (outOfSync []string, err error) {
+	_, outOfSync, err = process(text, filename, opts, nil)
+	return
+}
+
+// literalFuncs represents the function literals assigned to the variables of the declaration
+// (e.g., "var Parse = func(s string) int {...}") as function declarations named after the variables.
+//
+// The contracts of the literals are processed like the contracts of the declared functions. A variable
+// declared on its own is documented by the doc comment of the declaration, while a variable in a group is
+// documented by its own doc comment.
+func literalFuncs(d *ast.GenDecl) (fns []*ast.FuncDecl) {
+	if d.Tok != token.VAR {
+		return
+	}
+
+	for _, spec := range d.Specs {
+		valueSpec := spec.(*ast.ValueSpec)
+		if len(valueSpec.Names) != 1 || len(valueSpec.Values) != 1 {
+			continue
+		}
+
+		lit, ok := valueSpec.Values[0].(*ast.FuncLit)
+		if !ok {
+			continue
+		}
+
+		doc := valueSpec.Doc
+		if doc == nil && !d.Lparen.IsValid() {
+			doc = d.Doc
+		}
+
+		fns = append(fns, parsebody.LiteralDecl(valueSpec.Names[0], doc, lit))
+	}
+
+	return
+}
+
+// funcDecls lists the function declarations of the file together with the function literals assigned to
+// the package-level variables in the order of declaration.
+func funcDecls(node *ast.File) (fns []*ast.FuncDecl) {
+	for _, decl := range node.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			fns = append(fns, d)
+		case *ast.GenDecl:
+			fns = append(fns, literalFuncs(d)...)
+		}
+	}
+
+	return
+}
+
+// packageInvariants merges the invariants of the types declared in the file with the invariants of the types
+// declared in the other files of the package.
+func packageInvariants(fset *token.FileSet, node *ast.File, siblingInvs map[string][]parsecond.Condition) (
+	invariants map[string][]parsecond.Condition, err error) {
+
+	invariants, err = collectInvariants(fset, node)
 	if err != nil {
 		return
 	}
 
+	for typeName, invs := range siblingInvs {
+		if _, ok := invariants[typeName]; !ok {
+			invariants[typeName] = invs
+		}
+	}
+
+	return
+}
+
+// collectUpdates parses the contracts of all the functions in the file and specifies how the functions
+// should be updated. The function literals assigned to the package-level variables are included.
+//
+// The lines of the conditions and of the preambles refer to the lines in the file.
+// If remove is set, the contracts are not parsed so that the updates remove the condition checks except for
+// the functions marked as always checked. The contracts marked as documented only are collected as well and need to
+// be stripped with stripDocOnly before generating the code.
+// The contracts of the interface methods given in inh are merged into the contracts of the implementing methods.
+// The invariants of the types declared in the other files of the package are given in siblingInvs.
+func collectUpdates(fset *token.FileSet, node *ast.File, remove bool, inh inheritance,
+	siblingInvs map[string][]parsecond.Condition) (updates []funcUpdate, err error) {
 	cmtMap := ast.NewCommentMap(fset, node, node.Comments)
 
-	updates := []funcUpdate{}
+	var invariants map[string][]parsecond.Condition
+	if !remove {
+		invariants, err = packageInvariants(fset, node, siblingInvs)
+		if err != nil {
+			return
+		}
+	} else {
+		// Remove is true, hence leave the invariants empty.
+	}
 
-	for _, decl := range node.Decls {
-		fn, ok := decl.(*ast.FuncDecl)
-		if !ok {
+	updates = []funcUpdate{}
+
+	for _, fn := range funcDecls(node) {
+		if fn.Body == nil {
+			// Skip the functions implemented outside of Go such as in assembly.
 			continue
 		}
 
@@ -359,21 +1037,72 @@ func Process(text string, filename string, remove bool) (updated string, err err
 		////
 
 		name := fn.Name.Name
-		commentLines := strings.Split(fn.Doc.Text(), "\n")
+		lines, fileLines := commentLines(fset, fn.Doc)
 
 		var contractInDoc parsecomment.Contract
 
-		if !remove {
-			contractInDoc, err = parsecomment.ToContract(name, commentLines)
+		// The contracts of the other functions are not parsed if the checks are removed, but the functions marked
+		// as always checked keep their checks.
+		checks := parsecomment.DefaultChecks
+		if remove {
+			checks, err = parsecomment.ToChecks(name, lines)
 			if err != nil {
 				err = fmt.Errorf("failed to parse comments of the function %s on line %d: %s",
 					name, fset.Position(fn.Doc.Pos()).Line, err)
 				return
 			}
+
+			if checks == parsecomment.AlwaysChecked && invariants == nil {
+				invariants, err = packageInvariants(fset, node, siblingInvs)
+				if err != nil {
+					return
+				}
+			}
+		}
+
+		if !remove || checks == parsecomment.AlwaysChecked {
+			contractInDoc, err = parsecomment.ToContract(name, lines)
+			if err != nil {
+				err = fmt.Errorf("failed to parse comments of the function %s on line %d: %s",
+					name, fset.Position(fn.Doc.Pos()).Line, err)
+				return
+			}
+
+			locateContract(&contractInDoc, fileLines)
+
+			var methods []interfaceMethod
+			if typeName := ReceiverType(fn); typeName != "" {
+				methods = inh[typeName+"."+name]
+			}
+
+			if len(methods) > 0 {
+				contractInDoc, err = inheritContract(
+					fn, fset.Position(node.Package).Filename, contractInDoc, methods)
+				if err != nil {
+					err = fmt.Errorf("failed to inherit the contract of the method %s on line %d: %s",
+						name, fset.Position(fn.Pos()).Line, err)
+					return
+				}
+			}
 		} else {
 			// Remove is true, hence leave the pre and postconditions empty.
 		}
 
+		var invs []parsecond.Condition
+		if typeName := ReceiverType(fn); typeName != "" && fn.Name.IsExported() {
+			invs = invariants[typeName]
+		}
+
+		if len(invs) > 0 {
+			recv := fn.Recv.List[0]
+			if len(recv.Names) == 0 || recv.Names[0].Name == "_" {
+				err = fmt.Errorf("the method %s on line %d needs to name its receiver "+
+					"in order to check the invariants of the type %s",
+					name, fset.Position(fn.Pos()).Line, ReceiverType(fn))
+				return
+			}
+		}
+
 		////
 		// Parse body
 		////
@@ -394,6 +1123,7 @@ func Process(text string, filename string, remove bool) (updated string, err err
 		if len(contractInDoc.Pres) == 0 &&
 			len(contractInDoc.Preamble) == 0 &&
 			len(contractInDoc.Posts) == 0 &&
+			len(invs) == 0 &&
 			contractInBody.Start == token.NoPos {
 			continue
 		}
@@ -401,21 +1131,221 @@ func Process(text string, filename string, remove bool) (updated string, err err
 		updates = append(updates,
 			funcUpdate{
 				contractInDoc:  contractInDoc,
+				invariants:     invs,
 				fn:             fn,
 				contractInBody: contractInBody,
 			})
 	}
 
+	return
+}
+
+// process updates the blocks for checking the contracts and lists the functions whose blocks needed to be updated.
+//
+// The scope gives the names declared in the other files of the package. It can be nil.
+// The options are overridden by the directives in the file.
+func process(text string, filename string, opts Options, scope *packageScope) (
+	updated string, outOfSync []string, err error) {
+
+	opts, err = directiveOptions(text, filename, opts)
+	if err != nil {
+		return
+	}
+
+	var h *handler
+	h, err = parseHandler(opts.Handler, filename)
+	if err != nil {
+		return
+	}
+
+	updated, outOfSync, err = processOnce(text, filename, opts, scope, h)
+	if err != nil || (h == nil && !opts.Coverage && !opts.Metrics) {
+		return
+	}
+
+	// The violations and the counters refer to the lines of the conditions which are shifted by the generated
+	// code and the imports. Re-process the text until the lines settle.
+	const maxPasses = 3
+	for pass := 1; pass < maxPasses && updated != text; pass++ {
+		text = updated
+
+		var more []string
+		updated, more, err = processOnce(text, filename, opts, scope, h)
+		if err != nil {
+			return
+		}
+
+		outOfSync = mergeNames(outOfSync, more)
+	}
+
+	return
+}
+
+// mergeNames merges the names of the functions keeping their order and removing the duplicates.
+func mergeNames(names []string, more []string) []string {
+	seen := make(map[string]bool)
+	for _, name := range names {
+		seen[name] = true
+	}
+
+	for _, name := range more {
+		if !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+
+	return names
+}
+
+// selectUpdates keeps only the updates of the given functions qualified by their receiver types.
+func selectUpdates(updates []funcUpdate, functions []string) []funcUpdate {
+	selected := make(map[string]bool)
+	for _, name := range functions {
+		selected[name] = true
+	}
+
+	result := make([]funcUpdate, 0, len(updates))
+	for _, up := range updates {
+		if selected[funcName(up.fn)] {
+			result = append(result, up)
+		}
+	}
+
+	return result
+}
+
+// stripDocOnly empties the contracts of the functions marked as documented only so that their condition checks
+// are removed. The functions without condition checks in their bodies are left out.
+func stripDocOnly(updates []funcUpdate) []funcUpdate {
+	result := make([]funcUpdate, 0, len(updates))
+	for _, up := range updates {
+		if up.contractInDoc.Checks == parsecomment.DocOnly {
+			if up.contractInBody.Start == token.NoPos {
+				continue
+			}
+
+			up.contractInDoc = parsecomment.Contract{Checks: parsecomment.DocOnly}
+			up.invariants = nil
+		}
+
+		result = append(result, up)
+	}
+
+	return result
+}
+
+// processOnce updates the blocks for checking the contracts in a single pass.
+func processOnce(text string, filename string, opts Options, scope *packageScope, h *handler) (
+	updated string, outOfSync []string, err error) {
+	fset := token.NewFileSet()
+
+	var node *ast.File
+	node, err = parser.ParseFile(fset, filename, text, parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	siblings := parseSiblings(fset, node, filename, scope)
+
+	var inh inheritance
+	if !opts.Remove {
+		inh, err = packageInheritance(fset, node, siblings)
+		if err != nil {
+			return
+		}
+	}
+
+	var updates []funcUpdate
+	updates, err = collectUpdates(fset, node, opts.Remove, inh, siblingInvariants(fset, siblings))
+	if err != nil {
+		return
+	}
+
+	updates = stripDocOnly(updates)
+
+	if len(opts.Functions) > 0 {
+		updates = selectUpdates(updates, opts.Functions)
+	}
+
+	var diagnostics Diagnostics
+	diagnostics, err = nameResults(fset, node, filename, updates, opts.NameResults, scope)
+	if err != nil {
+		return
+	}
+
+	if len(diagnostics) > 0 {
+		err = diagnostics
+		return
+	}
+
 	if len(updates) == 0 {
 		updated = text
 		return
 	}
 
-	updated, err = update(text, updates, fset)
+	referred := []string{}
+	for _, up := range updates {
+		var names []string
+		names, err = packagesInContract(up, node, scope)
+		if err != nil {
+			return
+		}
+
+		referred = append(referred, names...)
+	}
+
+	for _, up := range updates {
+		if up.contractInDoc.ReturnErrors && len(up.contractInDoc.Pres) > 0 {
+			referred = append(referred, "errors")
+			break
+		}
+	}
+
+	// The elements violating the universally quantified conditions are reported with fmt.
+	for _, up := range updates {
+		for _, conditions := range [][]parsecond.Condition{
+			up.contractInDoc.Pres, up.invariants, up.contractInDoc.Posts} {
+
+			for _, c := range conditions {
+				if offendingKey(c) != "" {
+					referred = append(referred, "fmt")
+				}
+			}
+		}
+	}
+
+	if h != nil || ((opts.Toggle || opts.Metrics) && checked(updates)) {
+		referred = append(referred, runtimePackage)
+	}
+
+	if h != nil {
+		if h.pkgName != "" {
+			referred = append(referred, h.pkgName)
+		}
+	}
+
+	// The external test packages can not refer to the coverage registry and the samplers of the package.
+	external := strings.HasSuffix(filename, "_test.go") && strings.HasSuffix(node.Name.Name, "_test")
+
+	base := site{handler: h, file: filepath.Base(filename), toggle: opts.Toggle,
+		coverage: opts.Coverage && !external, sampling: !external, metrics: opts.Metrics}
+
+	if opts.Metrics {
+		base.pkgPath = packagePath(filename, node.Name.Name)
+	}
+
+	updated, outOfSync, err = update(text, updates, fset, base)
 	if err != nil {
 		return
 	}
 
+	updated, err = manageImports(node, updated, filename, referred, h.knownImports())
+	if err != nil {
+		err = fmt.Errorf("failed to update the imports: %s", err)
+		return
+	}
+
 	return
 }
 
@@ -449,6 +1379,14 @@ func ProcessInPlace(pth string, remove bool) (err error) {
 		return
 	}
 
+	err = WriteFile(pth, updated)
+	return
+}
+
+// WriteFile writes the text atomically to the file.
+//
+// The text is first written to a temporary file in the same directory which is then moved to pth.
+func WriteFile(pth string, text string) (err error) {
 	var tmp *os.File
 	tmp, err = ioutil.TempFile(filepath.Dir(pth), "temporary-gocontracts-"+filepath.Base(pth))
 	if err != nil {
@@ -472,7 +1410,7 @@ func ProcessInPlace(pth string, remove bool) (err error) {
 		}
 	}()
 
-	err = ioutil.WriteFile(tmp.Name(), []byte(updated), 0600)
+	err = ioutil.WriteFile(tmp.Name(), []byte(text), 0600)
 	if err != nil {
 		err = fmt.Errorf("failed to write to %s: %s", tmp.Name(), err.Error())
 		return
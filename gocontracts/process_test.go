@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
 
 	"fmt"
@@ -38,12 +39,59 @@ var cases = []testcases.Case{
 	testcases.RemoveInCodeOfEmptyFunction,
 	testcases.RemoveInCodeWithSemicolon,
 	testcases.FromReadme,
+	testcases.Invariants,
+	testcases.InvariantsRemovedInCode,
+	testcases.OldValues,
+	testcases.OldValuesNamesTaken,
+	testcases.OldValuesInInitialization,
+	testcases.OldValuesInCode,
+	testcases.FunctionWithoutBody,
+	testcases.ImportsAddedToGroup,
+	testcases.ImportsAddedToSingleImport,
+	testcases.ImportsRemoved,
+	testcases.NamedResults,
+	testcases.NamedResultsWithError,
+	testcases.Handler,
+	testcases.HandlerInSamePackage,
+	testcases.HandlerRemoved,
+	testcases.TypedPanics,
+	testcases.ReturnErrors,
+	testcases.ReturnErrorsRemoved,
+	testcases.Quantifiers,
+	testcases.QuantifiersWithHandler,
+	testcases.Families,
+	testcases.Interfaces,
+	testcases.DirectiveDisable,
+	testcases.DirectiveHandler,
+	testcases.Checks,
+	testcases.ChecksRemoved,
+	testcases.Coverage,
+	testcases.Toggle,
+	testcases.ToggleRemoved,
+	testcases.ToggleShadowed,
+	testcases.Sampling,
+	testcases.SamplingRemoved,
+	testcases.SamplingShadowed,
+	testcases.SamplingInCode,
+	testcases.SamplingWithOldValues,
+	testcases.SamplingWithOldValuesRemoved,
+	testcases.FuncLiteral,
+	testcases.FuncLiteralRemoved,
 }
 
 var failures = []testcases.Failure{
 	testcases.FailureCommentParse,
 	testcases.FailureBodyParse,
-	testcases.FailureUnparsableFile}
+	testcases.FailureUnparsableFile,
+	testcases.FailureUnnamedReceiver,
+	testcases.FailureOldInPrecondition,
+	testcases.FailureUnnamedResults,
+	testcases.FailureReturnErrorsWithoutError,
+	testcases.FailureOldInQuantifiedCollection,
+	testcases.FailureOldOfQuantifiedVariable,
+	testcases.FailureUnnamedInheritedParameter,
+	testcases.FailureUnknownDirective,
+	testcases.FailureRepeatedHandlerDirective}
 
 // meld runs a meld to compare the expected against the got.
 func meld(expected string, got string) (err error) {
@@ -99,7 +147,10 @@ func lastCommon(expected string, got string) (i int, found bool) {
 
 func TestProcess(t *testing.T) {
 	for _, cs := range cases {
-		updated, err := Process(cs.Text, cs.ID, cs.Remove)
+		opts := Options{Remove: cs.Remove, NameResults: cs.NameResults, Handler: cs.Handler, Coverage: cs.Coverage,
+			Toggle: cs.Toggle, Metrics: cs.Metrics}
+
+		updated, err := ProcessWithOptions(cs.Text, cs.ID, opts)
 
 		switch {
 		case err != nil:
@@ -125,6 +176,47 @@ func TestProcess(t *testing.T) {
 	}
 }
 
+func TestProcess_Metrics(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "process_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	// The violations are counted by the import path of the package.
+	writeTree(t, tmpdir, map[string]string{
+		"go.mod":      "module example.com/some\n",
+		"util/doc.go": "package somepkg\n"})
+
+	for _, cs := range []testcases.Case{testcases.Metrics, testcases.MetricsWithoutHandler} {
+		filename := filepath.Join(tmpdir, "util", cs.ID)
+		opts := Options{Handler: cs.Handler, Metrics: cs.Metrics}
+
+		updated, err := ProcessWithOptions(cs.Text, filename, opts)
+		if err != nil {
+			t.Fatalf("Failed at case %s: %s", cs.ID, err.Error())
+		}
+
+		if updated != cs.Expected {
+			t.Fatalf("Failed at case %s: expected:\n%s\ngot:\n%s", cs.ID, cs.Expected, updated)
+		}
+
+		outOfSync, err := CheckWithOptions(cs.Expected, filename, opts)
+		if err != nil {
+			t.Fatalf("Failed at case %s: %s", cs.ID, err.Error())
+		}
+
+		if len(outOfSync) > 0 {
+			t.Fatalf("Failed at case %s: expected no functions out of sync, got %v", cs.ID, outOfSync)
+		}
+	}
+}
+
 func TestProcessFailures(t *testing.T) {
 	for _, failure := range failures {
 		_, err := Process(failure.Text, failure.ID, false)
@@ -296,3 +388,122 @@ func TestProcessFile_NonExisting(t *testing.T) {
 		t.Fatalf("Expected an error %#v, but got %#v", expected, err.Error())
 	}
 }
+
+func TestCheck(t *testing.T) {
+	for _, cs := range cases {
+		opts := Options{Remove: cs.Remove, NameResults: cs.NameResults, Handler: cs.Handler, Coverage: cs.Coverage,
+			Toggle: cs.Toggle, Metrics: cs.Metrics}
+
+		outOfSync, err := CheckWithOptions(cs.Expected, cs.ID, opts)
+		if err != nil {
+			t.Fatalf("Failed at case %s: %s", cs.ID, err.Error())
+		}
+
+		if len(outOfSync) > 0 {
+			t.Errorf("Expected the processed code of the case %s to be in sync, but got out of sync: %#v",
+				cs.ID, outOfSync)
+		}
+
+		outOfSync, err = CheckWithOptions(cs.Text, cs.ID, opts)
+		if err != nil {
+			t.Fatalf("Failed at case %s: %s", cs.ID, err.Error())
+		}
+
+		if (cs.Text != cs.Expected) != (len(outOfSync) > 0) {
+			t.Errorf("Expected the case %s to be out of sync: %v, but got out of sync: %#v",
+				cs.ID, cs.Text != cs.Expected, outOfSync)
+		}
+	}
+}
+
+func TestCheck_Formatted(t *testing.T) {
+	// The generated code was formatted with gofmt.
+	text := `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * a[0] == old(a[0]) + 1
+func SomeFunc(a []int) {
+	// Old values
+	old1 := a[0]
+
+	// Post-condition
+	defer func() {
+		if !(a[0] == old1+1) {
+			panic("Violated: a[0] == old(a[0]) + 1")
+		}
+	}()
+
+	a[0]++
+}
+`
+
+	outOfSync, err := Check(text, "formatted", false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(outOfSync) != 0 {
+		t.Fatalf("Expected no functions out of sync, got %#v", outOfSync)
+	}
+
+	outOfSync, err = Check(text, "formatted", true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []string{"SomeFunc"}
+	if len(outOfSync) != 1 || outOfSync[0] != expected[0] {
+		t.Fatalf("Expected out of sync %#v in the remove mode, got %#v", expected, outOfSync)
+	}
+}
+
+func TestCheck_Method(t *testing.T) {
+	text := `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+func (s *SomeStruct) SomeFunc(x int) {
+	return
+}
+`
+
+	outOfSync, err := Check(text, "method", false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []string{"SomeStruct.SomeFunc"}
+	if len(outOfSync) != 1 || outOfSync[0] != expected[0] {
+		t.Fatalf("Expected out of sync %#v, got %#v", expected, outOfSync)
+	}
+}
+
+func TestProcessWithOptions_Functions(t *testing.T) {
+	cs := testcases.MultipleFunctions
+
+	updated, err := ProcessWithOptions(cs.Text, cs.ID, Options{Functions: []string{"AnotherFunc"}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Only the checks of AnotherFunc are generated.
+	split := strings.Index(cs.Expected, "// AnotherFunc does something.")
+	expected := cs.Text[:strings.Index(cs.Text, "// AnotherFunc does something.")] + cs.Expected[split:]
+
+	if updated != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, updated)
+	}
+
+	outOfSync, err := CheckWithOptions(cs.Text, cs.ID, Options{Functions: []string{"SomeFunc"}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(outOfSync) != 1 || outOfSync[0] != "SomeFunc" {
+		t.Fatalf("Expected only SomeFunc out of sync, got %#v", outOfSync)
+	}
+}
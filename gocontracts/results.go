@@ -0,0 +1,405 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// packageScope describes the package of the processed file.
+//
+// It allows for resolving the identifiers declared in the other files of the package.
+type packageScope struct {
+	// names maps the names of the packages to the names declared at their package level.
+	names map[string]map[string]bool
+
+	// paths of the Go files of the package including the processed file
+	paths []string
+}
+
+// declaredNames collects the names declared at the package level of the file.
+func declaredNames(node *ast.File, names map[string]bool) {
+	for name := range node.Scope.Objects {
+		names[name] = true
+	}
+}
+
+// packageText is the text of a file of the package.
+type packageText struct {
+	path string
+	text string
+}
+
+// packageTexts gives the texts of the files of the package in the scope. The processed files are given as
+// updated, while the other files are read as they are. The files which can not be read are skipped.
+func packageTexts(results []Result, scope *packageScope) (texts []packageText) {
+	updated := make(map[string]string)
+	for _, result := range results {
+		if result.Err == nil {
+			updated[filepath.Clean(result.Path)] = result.Updated
+		}
+	}
+
+	for _, pth := range scope.paths {
+		text, ok := updated[filepath.Clean(pth)]
+		if !ok {
+			data, err := ioutil.ReadFile(pth)
+			if err != nil {
+				continue
+			}
+
+			text = string(data)
+		}
+
+		texts = append(texts, packageText{path: pth, text: text})
+	}
+
+	return
+}
+
+// newPackageScope collects the names declared at the package level of the given files.
+//
+// The files which can not be read or parsed are ignored since the processing reports them anyhow.
+func newPackageScope(paths []string) *packageScope {
+	scope := &packageScope{names: make(map[string]map[string]bool), paths: paths}
+
+	fset := token.NewFileSet()
+	for _, pth := range paths {
+		data, err := ioutil.ReadFile(pth)
+		if err != nil {
+			continue
+		}
+
+		node, err := parser.ParseFile(fset, pth, data, 0)
+		if err != nil {
+			continue
+		}
+
+		names, ok := scope.names[node.Name.Name]
+		if !ok {
+			names = make(map[string]bool)
+			scope.names[node.Name.Name] = names
+		}
+
+		declaredNames(node, names)
+	}
+
+	return scope
+}
+
+// declared checks whether the name is declared at the package level. The scope can be nil.
+func (s *packageScope) declared(pkgName string, name string) bool {
+	if s == nil {
+		return false
+	}
+
+	return s.names[pkgName][name]
+}
+
+// siblings lists the other files of the package. The scope can be nil.
+func (s *packageScope) siblings(filename string) (paths []string) {
+	if s == nil {
+		return
+	}
+
+	for _, pth := range s.paths {
+		if filepath.Clean(pth) != filepath.Clean(filename) {
+			paths = append(paths, pth)
+		}
+	}
+
+	return
+}
+
+// proposeResultNames proposes the names for the unnamed results of the function.
+//
+// A result of type error is named err. A single other result is named result, while multiple other results
+// are named result1, result2 etc.
+func proposeResultNames(fn *ast.FuncDecl) (names []string) {
+	fields := fn.Type.Results.List
+
+	isError := make([]bool, len(fields))
+	errors := 0
+	for i, field := range fields {
+		ident, ok := field.Type.(*ast.Ident)
+		isError[i] = ok && ident.Name == "error"
+		if isError[i] {
+			errors++
+		}
+	}
+
+	names = make([]string, 0, len(fields))
+
+	if errors > 1 {
+		// Multiple errors are unconventional so we simply number all the results.
+		for i := range fields {
+			names = append(names, fmt.Sprintf("result%d", i+1))
+		}
+		return
+	}
+
+	others := len(fields) - errors
+	other := 0
+	for i := range fields {
+		switch {
+		case isError[i]:
+			names = append(names, "err")
+		case others == 1:
+			names = append(names, "result")
+		default:
+			other++
+			names = append(names, fmt.Sprintf("result%d", other))
+		}
+	}
+
+	return
+}
+
+// undeclaredInPosts maps the post-conditions to the identifiers which they refer to, but which are not
+// declared in the scope of the function. The function needs to have unnamed results.
+//
+// The scope of the package can be nil if the other files of the package are unknown.
+// The post-conditions are identified by their line. The identifiers are sorted.
+func undeclaredInPosts(up funcUpdate, node *ast.File, filename string, scope *packageScope) (
+	undeclared map[int][]string, err error) {
+
+	undeclared = make(map[int][]string)
+
+	if len(up.contractInDoc.Posts) == 0 {
+		return
+	}
+
+	// Write each post-condition on a separate line so that the identifiers can be attributed to them.
+	var b strings.Builder
+	b.WriteString("package p\n\nfunc _() {\n")
+	line := 3
+
+	if up.contractInDoc.Preamble != "" {
+		b.WriteString(up.contractInDoc.Preamble)
+		b.WriteString("\n")
+		line += strings.Count(up.contractInDoc.Preamble, "\n") + 1
+	}
+
+	lineOf := make(map[int]int)
+	for _, c := range up.contractInDoc.Posts {
+		line++
+		lineOf[line] = c.Line
+
+		if c.InitStr != "" {
+			b.WriteString(fmt.Sprintf("if %s; %s {}\n", c.InitStr, c.ExprStr()))
+		} else {
+			b.WriteString(fmt.Sprintf("if %s {}\n", c.ExprStr()))
+		}
+	}
+	b.WriteString("}\n")
+
+	fset := token.NewFileSet()
+	var parsed *ast.File
+	parsed, err = parser.ParseFile(fset, "", b.String(), 0)
+	if err != nil {
+		err = fmt.Errorf("failed to parse the post-conditions of the function %s in the following playground:\n%s\n"+
+			"The error was: %s", up.fn.Name.Name, b.String(), err)
+		return
+	}
+
+	declared := make(map[string]bool)
+	fieldNames(up.fn.Recv, declared)
+	fieldNames(up.fn.Type.TypeParams, declared)
+	fieldNames(up.fn.Type.Params, declared)
+	fieldNames(up.fn.Type.Results, declared)
+
+	// Old values are rewritten as variables by gocontracts.
+	declared["old"] = true
+
+	for _, spec := range node.Imports {
+		declared[importName(spec, filename)] = true
+	}
+
+	// Packages referred to in the contract are imported automatically.
+	packages := make(map[string]bool)
+	for name := range unresolvedSelectors(parsed) {
+		if _, ok := resolveImport(name, filename); ok {
+			packages[name] = true
+		}
+	}
+
+	// Without the scope of the package, the names declared in the other files of the package can not be told
+	// apart from the undeclared ones so only the names proposed for the unnamed results are reported.
+	var candidates map[string]bool
+	if scope == nil {
+		candidates = make(map[string]bool)
+		for _, name := range proposeResultNames(up.fn) {
+			candidates[name] = true
+		}
+	}
+
+	seen := make(map[int]map[string]bool)
+	for _, ident := range parsed.Unresolved {
+		name := ident.Name
+		if declared[name] || packages[name] || node.Scope.Lookup(name) != nil ||
+			scope.declared(node.Name.Name, name) || types.Universe.Lookup(name) != nil {
+			continue
+		}
+
+		if candidates != nil && !candidates[name] {
+			continue
+		}
+
+		docLine, ok := lineOf[fset.Position(ident.Pos()).Line]
+		if !ok {
+			// The identifier is in the preamble.
+			continue
+		}
+
+		if seen[docLine] == nil {
+			seen[docLine] = make(map[string]bool)
+		}
+
+		if !seen[docLine][name] {
+			seen[docLine][name] = true
+			undeclared[docLine] = append(undeclared[docLine], name)
+		}
+	}
+
+	for _, names := range undeclared {
+		sort.Strings(names)
+	}
+
+	return
+}
+
+// usedInBody checks whether the identifier is used anywhere in the body of the function.
+func usedInBody(fn *ast.FuncDecl, name string) (used bool) {
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == name {
+			used = true
+		}
+
+		return !used
+	})
+
+	return
+}
+
+// nameResults checks that the post-conditions of the functions with unnamed results do not refer to
+// undeclared identifiers.
+//
+// If automatic is set, the results of such functions are named following proposeResultNames. The results are
+// left unnamed if the proposed names do not cover any of the undeclared identifiers. The names are set in
+// the updates.
+//
+// The problems are reported as diagnostics.
+func nameResults(fset *token.FileSet, node *ast.File, filename string, updates []funcUpdate, automatic bool,
+	scope *packageScope) (diagnostics Diagnostics, err error) {
+
+	for i := range updates {
+		up := &updates[i]
+
+		if !hasUnnamedResults(up.fn) {
+			continue
+		}
+
+		var undeclared map[int][]string
+		undeclared, err = undeclaredInPosts(*up, node, filename, scope)
+		if err != nil {
+			return
+		}
+
+		if len(undeclared) == 0 {
+			continue
+		}
+
+		name := funcName(up.fn)
+
+		var proposed []string
+		if automatic {
+			proposed = proposeResultNames(up.fn)
+
+			covers := false
+			for _, names := range undeclared {
+				for _, n := range names {
+					for _, p := range proposed {
+						covers = covers || n == p
+					}
+				}
+			}
+
+			if !covers {
+				proposed = nil
+			}
+		}
+
+		declared := make(map[string]bool)
+		fieldNames(up.fn.Recv, declared)
+		fieldNames(up.fn.Type.TypeParams, declared)
+		fieldNames(up.fn.Type.Params, declared)
+
+		conflicts := []string{}
+		for _, p := range proposed {
+			if declared[p] || usedInBody(up.fn, p) {
+				conflicts = append(conflicts, p)
+			}
+		}
+
+		if len(conflicts) > 0 {
+			diagnostics = append(diagnostics, Diagnostic{
+				Position: fset.Position(up.fn.Type.Results.Pos()),
+				Message: fmt.Sprintf("the results of the function %s can not be named automatically "+
+					"since the following names are already used in the function: %s", name,
+					strings.Join(conflicts, ", "))})
+			continue
+		}
+
+		resolved := make(map[string]bool)
+		for _, p := range proposed {
+			resolved[p] = true
+		}
+
+		for _, c := range up.contractInDoc.Posts {
+			missing := []string{}
+			for _, n := range undeclared[c.Line] {
+				if !resolved[n] {
+					missing = append(missing, n)
+				}
+			}
+
+			if len(missing) == 0 {
+				continue
+			}
+
+			msg := fmt.Sprintf("post-condition %#v refers to %s which is not in scope of the function %s",
+				c.CondStr, strings.Join(missing, ", "), name)
+			if len(proposed) == 0 {
+				msg += "; the results of the function are unnamed, name them in order to refer to them " +
+					"in the post-conditions"
+			} else {
+				msg += fmt.Sprintf("; the results were named automatically as %s",
+					strings.Join(proposed, ", "))
+			}
+
+			diagnostics = append(diagnostics, Diagnostic{
+				Position: token.Position{Filename: documentedIn(c, filename), Line: c.Line},
+				Message:  msg})
+		}
+
+		up.resultNames = proposed
+	}
+
+	return
+}
+
+// namedResultsCode generates the code of the results of the function named as given.
+func namedResultsCode(fset *token.FileSet, text string, fn *ast.FuncDecl, names []string) string {
+	parts := make([]string, 0, len(names))
+	for i, field := range fn.Type.Results.List {
+		parts = append(parts, fmt.Sprintf("%s %s", names[i], sourceOf(fset, text, field.Type)))
+	}
+
+	return "(" + strings.Join(parts, ", ") + ")"
+}
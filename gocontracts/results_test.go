@@ -0,0 +1,164 @@
+package gocontracts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessWithOptions_NameResultsConflict(t *testing.T) {
+	text := `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * result > 0
+func SomeFunc(x int) int {
+	result := x * x
+	return result + 1
+}
+`
+
+	_, err := ProcessWithOptions(text, "some.go", Options{NameResults: true})
+	if err == nil {
+		t.Fatal("expected an error, but got none")
+	}
+
+	expected := "some.go:7:22: the results of the function SomeFunc can not be named automatically " +
+		"since the following names are already used in the function: result"
+	if err.Error() != expected {
+		t.Fatalf("expected the error %#v, got %#v", expected, err.Error())
+	}
+}
+
+func TestProcessWithOptions_NameResultsWithoutPackageScope(t *testing.T) {
+	// maxLen is declared in another file of the package which is unknown when processing the text on its own.
+	text := `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * len(result) <= maxLen
+func SomeFunc() string {
+	return ""
+}
+`
+
+	_, err := ProcessWithOptions(text, "some.go", Options{})
+	if err == nil {
+		t.Fatal("expected an error, but got none")
+	}
+
+	expected := "some.go:6: post-condition \"len(result) <= maxLen\" refers to result which is not in scope " +
+		"of the function SomeFunc; the results of the function are unnamed, name them in order to refer to them " +
+		"in the post-conditions"
+	if err.Error() != expected {
+		t.Fatalf("expected the error %#v, got %#v", expected, err.Error())
+	}
+
+	_, err = ProcessWithOptions(text, "some.go", Options{NameResults: true})
+	if err != nil {
+		t.Fatalf("expected no error if the results are named automatically, got: %s", err.Error())
+	}
+}
+
+func TestProcessPackages_PackageScope(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "results_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{
+		"somepkg/counter.go": "package somepkg\n\nvar counter int\n",
+		"somepkg/some.go": `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * counter > 0
+func SomeFunc() int {
+	counter++
+	return counter
+}
+`,
+	})
+
+	someGo := filepath.Join(tmpdir, "somepkg", "some.go")
+
+	for _, pattern := range []string{filepath.Join(tmpdir, "somepkg"), someGo} {
+		results, err := ProcessPackages([]string{pattern}, Options{NameResults: true})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		for _, result := range results {
+			if result.Err != nil {
+				t.Fatalf("expected no error for the pattern %s, got: %s", pattern, result.Err.Error())
+			}
+
+			if result.Path == someGo && !result.Changed() {
+				t.Fatalf("expected the file %s to be changed for the pattern %s", someGo, pattern)
+			}
+		}
+	}
+}
+
+func TestProcessPackages_PackageScopeNotImported(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "results_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{
+		"somepkg/registry.go": `package somepkg
+
+type registry struct{}
+
+func (r registry) Len() int { return 1 }
+
+var sort = registry{}
+`,
+		"somepkg/some.go": `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * sort.Len() > 0
+func SomeFunc() {}
+`,
+	})
+
+	someGo := filepath.Join(tmpdir, "somepkg", "some.go")
+
+	for _, pattern := range []string{filepath.Join(tmpdir, "somepkg"), someGo} {
+		results, err := ProcessPackages([]string{pattern}, Options{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		for _, result := range results {
+			if result.Err != nil {
+				t.Fatalf("expected no error for the pattern %s, got: %s", pattern, result.Err.Error())
+			}
+
+			if result.Path == someGo && strings.Contains(result.Updated, `import "sort"`) {
+				t.Fatalf("expected the variable sort of the package not to be imported for the pattern %s, "+
+					"got:\n%s", pattern, result.Updated)
+			}
+		}
+	}
+}
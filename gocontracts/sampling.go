@@ -0,0 +1,174 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sampledVar is the name of the variable holding the decision whether the conditions are checked on the call
+// unless the function already uses it.
+const sampledVar = "sampled"
+
+// samplingFileName is the name of the generated file declaring the samplers of the package.
+const samplingFileName = "contracts_sampling.go"
+
+// samplerVar gives the name of the variable holding the sampler of the function (e.g.,
+// "contractsSample_SomeType_SomeMethod" for the method SomeType.SomeMethod).
+//
+// The receiver type is separated from the method so that the methods A.BC and AB.C do not share the sampler.
+func samplerVar(fn *ast.FuncDecl) string {
+	return "contractsSample_" + strings.Replace(funcName(fn), ".", "_", -1)
+}
+
+// samplingCode generates the code deciding whether the conditions are checked on the call.
+//
+// The code is indented. It does not end with a new-line character.
+func (s site) samplingCode() string {
+	return fmt.Sprintf("\t// Sampling\n\t%s := %s.Sample()", s.sampled, s.sampler)
+}
+
+// sampler declares the sampler of a sampled function.
+type sampler struct {
+	// name of the variable holding the sampler
+	name string
+
+	// function is the name of the sampled function.
+	function string
+
+	// sample is the number of calls per checked call.
+	sample int
+}
+
+// decl generates the declaration of the sampler.
+func (s sampler) decl() string {
+	return fmt.Sprintf("// %s selects the calls of %s whose conditions are checked.\nvar %s = %s.NewSampler(%d)\n",
+		s.name, s.function, s.name, runtimePackage, s.sample)
+}
+
+// refers checks whether the body of the function refers to the identifier.
+func refers(fn *ast.FuncDecl, name string) bool {
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == name {
+			found = true
+		}
+
+		return !found
+	})
+
+	return found
+}
+
+// samplersOf lists the samplers of the sampled functions in the file.
+//
+// If the file can not be parsed or belongs to an external test package, the samplers are empty.
+func samplersOf(text string, filename string) (pkgName string, samplers []sampler) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, filename, text, parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	if strings.HasSuffix(filename, "_test.go") && strings.HasSuffix(node.Name.Name, "_test") {
+		return
+	}
+
+	pkgName = node.Name.Name
+
+	updates, err := collectUpdates(fset, node, false, nil, nil)
+	if err != nil {
+		return
+	}
+
+	for _, up := range updates {
+		sample := up.contractInDoc.Sample
+		if sample == 0 || !refers(up.fn, samplerVar(up.fn)) {
+			continue
+		}
+
+		samplers = append(samplers, sampler{name: samplerVar(up.fn), function: funcName(up.fn), sample: sample})
+	}
+
+	return
+}
+
+// samplingFileCode generates the code of the file declaring the samplers of the package.
+//
+// The functions whose names differ only in the underscores and the dots (e.g., the function A_B and
+// the method A.B) would share the sampler so that they are reported as an error.
+func samplingFileCode(pkgName string, samplers []sampler) (string, error) {
+	functionOf := make(map[string]string)
+	for _, s := range samplers {
+		if other, ok := functionOf[s.name]; ok {
+			return "", fmt.Errorf("the functions %s and %s can not be sampled since their samplers "+
+				"would both be named %s", other, s.function, s.name)
+		}
+
+		functionOf[s.name] = s.function
+	}
+
+	var b strings.Builder
+
+	b.WriteString("// Code generated by gocontracts. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n", pkgName)
+
+	if len(samplers) > 0 {
+		fmt.Fprintf(&b, "\nimport %s\n", strconv.Quote(runtimeImportPath))
+	}
+
+	for _, s := range samplers {
+		b.WriteString("\n" + s.decl())
+	}
+
+	code, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format the samplers: %s", err)
+	}
+
+	return string(code), nil
+}
+
+// samplingFile generates the file declaring the samplers of the functions of the package in the directory
+// whose conditions are checked only on the sampled calls (marked as "SomeFunc contracts: sample 1/N").
+//
+// If the conditions are removed or there is nothing to declare, no file is generated. An existing file is
+// emptied if no function is sampled anymore.
+func samplingFile(dir string, results []Result, opts Options, scope *packageScope) (samplingResults []Result) {
+	if opts.Remove {
+		return
+	}
+
+	pkgName := ""
+	samplers := []sampler{}
+	for _, file := range packageTexts(results, scope) {
+		name, fileSamplers := samplersOf(file.text, file.path)
+		if name != "" {
+			pkgName = name
+		}
+
+		samplers = append(samplers, fileSamplers...)
+	}
+
+	pth := filepath.Join(dir, samplingFileName)
+	if pkgName == "" || (len(samplers) == 0 && !exists(pth)) {
+		return
+	}
+
+	code, err := samplingFileCode(pkgName, samplers)
+	if err != nil {
+		samplingResults = []Result{{Path: pth, Err: err}}
+		return
+	}
+
+	result := generatedFileResult(pth, code)
+	result.Generated = true
+
+	samplingResults = []Result{result}
+	return
+}
@@ -0,0 +1,146 @@
+package testcases
+
+// Checks tests that the functions marked as documented only are not checked, while the functions marked as
+// always checked are.
+var Checks = Case{
+	ID: "checks",
+	Text: `package somepkg
+
+// SomeStruct defines something.
+//
+// SomeStruct invariants:
+//  * s.x >= 0
+type SomeStruct struct {
+	x int
+}
+
+// Hot is on the hot path.
+//
+// Hot requires:
+//  * x > 0
+//
+// Hot contracts: doc-only
+func (s *SomeStruct) Hot(x int) {
+	// Pre-condition
+	if !(x > 0) {
+		panic("Violated: x > 0")
+	}
+
+	s.x += x
+}
+
+// Critical must always be checked.
+//
+// Critical requires:
+//  * x > 0
+//
+// Critical contracts: checked
+func Critical(x int) {
+	return
+}
+
+// Regular is checked by default.
+//
+// Regular requires:
+//  * x > 0
+func Regular(x int) {
+	return
+}
+`,
+	Expected: `package somepkg
+
+// SomeStruct defines something.
+//
+// SomeStruct invariants:
+//  * s.x >= 0
+type SomeStruct struct {
+	x int
+}
+
+// Hot is on the hot path.
+//
+// Hot requires:
+//  * x > 0
+//
+// Hot contracts: doc-only
+func (s *SomeStruct) Hot(x int) {
+	s.x += x
+}
+
+// Critical must always be checked.
+//
+// Critical requires:
+//  * x > 0
+//
+// Critical contracts: checked
+func Critical(x int) {
+	// Pre-condition
+	if !(x > 0) {
+		panic("Violated: x > 0")
+	}
+
+	return
+}
+
+// Regular is checked by default.
+//
+// Regular requires:
+//  * x > 0
+func Regular(x int) {
+	// Pre-condition
+	if !(x > 0) {
+		panic("Violated: x > 0")
+	}
+
+	return
+}
+`}
+
+// ChecksRemoved tests that the functions marked as always checked keep their checks when the checks are removed.
+var ChecksRemoved = Case{
+	ID:     "checks_removed",
+	Text:   Checks.Expected,
+	Remove: true,
+	Expected: `package somepkg
+
+// SomeStruct defines something.
+//
+// SomeStruct invariants:
+//  * s.x >= 0
+type SomeStruct struct {
+	x int
+}
+
+// Hot is on the hot path.
+//
+// Hot requires:
+//  * x > 0
+//
+// Hot contracts: doc-only
+func (s *SomeStruct) Hot(x int) {
+	s.x += x
+}
+
+// Critical must always be checked.
+//
+// Critical requires:
+//  * x > 0
+//
+// Critical contracts: checked
+func Critical(x int) {
+	// Pre-condition
+	if !(x > 0) {
+		panic("Violated: x > 0")
+	}
+
+	return
+}
+
+// Regular is checked by default.
+//
+// Regular requires:
+//  * x > 0
+func Regular(x int) {
+	return
+}
+`}
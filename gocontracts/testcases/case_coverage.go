@@ -0,0 +1,95 @@
+package testcases
+
+// Coverage tests that the evaluations of the conditions are counted in the coverage registry.
+var Coverage = Case{
+	ID: "coverage",
+	Text: `package somepkg
+
+// SomeType defines something.
+//
+// SomeType invariants:
+//  * t.x >= 0
+type SomeType struct {
+	x int
+}
+
+// Add adds the items.
+//
+// Add requires:
+//  * positive: forall i in items: items[i] > 0
+//  * [test] _, ok := limits[len(items)]; ok
+//
+// Add ensures:
+//  * exists i in items: t.x >= items[i]
+func (t *SomeType) Add(items []int, limits map[int]bool) {
+	for _, item := range items {
+		t.x += item
+	}
+}
+`,
+	Coverage: true,
+	Expected: `package somepkg
+
+import "fmt"
+
+// SomeType defines something.
+//
+// SomeType invariants:
+//  * t.x >= 0
+type SomeType struct {
+	x int
+}
+
+// Add adds the items.
+//
+// Add requires:
+//  * positive: forall i in items: items[i] > 0
+//  * [test] _, ok := limits[len(items)]; ok
+//
+// Add ensures:
+//  * exists i in items: t.x >= items[i]
+func (t *SomeType) Add(items []int, limits map[int]bool) {
+	// Pre-conditions
+	{
+		for i := range items {
+			if !contractsCoverage.Check("SomeType.Add", "coverage", 16, items[i] > 0) {
+				panic(fmt.Sprintf("Violated: positive: forall i in items: items[i] > 0 (i = %v)", i))
+			}
+		}
+		if _, ok := limits[len(items)]; InTest && !contractsCoverage.Check("SomeType.Add", "coverage", 17, ok) {
+			panic("Violated: _, ok := limits[len(items)]; ok")
+		}
+	}
+
+	// Invariant on entry
+	if !contractsCoverage.Check("SomeType.Add", "coverage", 8, t.x >= 0) {
+		panic("Violated: t.x >= 0")
+	}
+
+	// Invariant on exit
+	defer func() {
+		if !contractsCoverage.Check("SomeType.Add", "coverage", 8, t.x >= 0) {
+			panic("Violated: t.x >= 0")
+		}
+	}()
+
+	// Post-condition
+	defer func() {
+		if !func() bool {
+			for i := range items {
+				if contractsCoverage.Check("SomeType.Add", "coverage", 20, t.x >= items[i]) {
+					return true
+				}
+			}
+			return false
+		}() {
+			panic("Violated: exists i in items: t.x >= items[i]")
+		}
+	}()
+
+	for _, item := range items {
+		t.x += item
+	}
+}
+`,
+}
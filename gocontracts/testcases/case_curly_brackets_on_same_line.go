@@ -21,6 +21,8 @@ func SomeFunc(x int, y int) (result string, err error) {}
 `,
 	Expected: `package somepkg
 
+import "strings"
+
 // SomeFunc does something.
 //
 // SomeFunc requires:
@@ -0,0 +1,79 @@
+package testcases
+
+// DirectiveDisable tests that the condition checks are removed from a disabled file.
+var DirectiveDisable = Case{
+	ID: "directive_disable",
+	Text: `//go:generate gocontracts -w $GOFILE
+//gocontracts:disable
+
+package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+func SomeFunc(x int) {
+	// Pre-condition
+	if !(x > 0) {
+		panic("Violated: x > 0")
+	}
+
+	return
+}
+`,
+	Expected: `//go:generate gocontracts -w $GOFILE
+//gocontracts:disable
+
+package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+func SomeFunc(x int) {
+	return
+}
+`}
+
+// DirectiveHandler tests that the handler and the naming of the results are configured by the directives.
+var DirectiveHandler = Case{
+	ID: "directive_handler",
+	Text: `//gocontracts:handler github.com/some/violations.Report
+//gocontracts:name-results
+
+package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * result > x
+func SomeFunc(x int) int {
+	return x + 1
+}
+`,
+	Handler: "report",
+	Expected: `//gocontracts:handler github.com/some/violations.Report
+//gocontracts:name-results
+
+package somepkg
+
+import (
+	"github.com/Parquery/gocontracts/contracts"
+	"github.com/some/violations"
+)
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * result > x
+func SomeFunc(x int) (result int) {
+	// Post-condition
+	defer func() {
+		if !(result > x) {
+			violations.Report(contracts.ViolationInfo{Kind: contracts.Postcondition, Function: "SomeFunc", Condition: "result > x", File: "directive_handler", Line: 14})
+		}
+	}()
+
+	return x + 1
+}
+`}
@@ -19,6 +19,8 @@ func SomeFunc(x string) (result string, err error) {}
 `,
 	Expected: `package somepkg
 
+import "strings"
+
 // SomeFunc does something.
 //
 // SomeFunc requires:
@@ -0,0 +1,106 @@
+package testcases
+
+// Families tests that the conditions of the families are guarded by the constants of the families.
+var Families = Case{
+	ID: "families",
+	Text: `package somepkg
+
+// SomeType defines something.
+//
+// SomeType invariants (utest):
+//  * t.x >= 0
+type SomeType struct {
+	x     int
+	items []int
+}
+
+// Inc increments.
+//
+// Inc requires:
+//  * delta > 0
+//  * [test] !(delta > 100 || delta < 0)
+//
+// Inc ensures (test):
+//  * t.x >= old(t.x)
+//  * [utest] forall i, v in t.items: v > 0
+//  * exists _, v in t.items: v == delta
+func (t *SomeType) Inc(delta int) {
+	t.x += delta
+}
+`,
+	Expected: `package somepkg
+
+import "fmt"
+
+// SomeType defines something.
+//
+// SomeType invariants (utest):
+//  * t.x >= 0
+type SomeType struct {
+	x     int
+	items []int
+}
+
+// Inc increments.
+//
+// Inc requires:
+//  * delta > 0
+//  * [test] !(delta > 100 || delta < 0)
+//
+// Inc ensures (test):
+//  * t.x >= old(t.x)
+//  * [utest] forall i, v in t.items: v > 0
+//  * exists _, v in t.items: v == delta
+func (t *SomeType) Inc(delta int) {
+	// Pre-conditions
+	switch {
+	case !(delta > 0):
+		panic("Violated: delta > 0")
+	case InTest && (delta > 100 || delta < 0):
+		panic("Violated: !(delta > 100 || delta < 0)")
+	default:
+		// Pass
+	}
+
+	// Invariant on entry
+	if InUtest && !(t.x >= 0) {
+		panic("Violated: t.x >= 0")
+	}
+
+	// Invariant on exit
+	defer func() {
+		if InUtest && !(t.x >= 0) {
+			panic("Violated: t.x >= 0")
+		}
+	}()
+
+	// Old values
+	old1 := t.x
+
+	// Post-conditions
+	defer func() {
+		if InTest && !(t.x >= old1) {
+			panic("Violated: t.x >= old(t.x)")
+		}
+		if InUtest {
+			for i, v := range t.items {
+				if !(v > 0) {
+					panic(fmt.Sprintf("Violated: forall i, v in t.items: v > 0 (i = %v)", i))
+				}
+			}
+		}
+		if InTest && !func() bool {
+			for _, v := range t.items {
+				if v == delta {
+					return true
+				}
+			}
+			return false
+		}() {
+			panic("Violated: exists _, v in t.items: v == delta")
+		}
+	}()
+
+	t.x += delta
+}
+`}
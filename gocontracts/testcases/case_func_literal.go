@@ -0,0 +1,133 @@
+package testcases
+
+// FuncLiteral tests the contracts of the function literals assigned to the package-level variables.
+var FuncLiteral = Case{
+	ID: "func_literal",
+	Text: `package somepkg
+
+// Parse parses the text.
+//
+// Parse requires:
+//  * not empty: s != ""
+//
+// Parse ensures:
+//  * result >= 0
+var Parse = func(s string) (result int) {
+	return len(s)
+}
+
+var (
+	// Double doubles.
+	//
+	// Double requires:
+	//  * x > 0
+	Double = func(x int) int { return 2 * x }
+
+	// Check checks.
+	//
+	// Check requires:
+	//  * x < 100
+	Check = func(x int) {}
+
+	// Plain has no contract.
+	Plain = func() {}
+
+	untouched = 3
+)
+`,
+	Expected: `package somepkg
+
+// Parse parses the text.
+//
+// Parse requires:
+//  * not empty: s != ""
+//
+// Parse ensures:
+//  * result >= 0
+var Parse = func(s string) (result int) {
+	// Pre-condition
+	if !(s != "") {
+		panic("Violated: not empty: s != \"\"")
+	}
+
+	// Post-condition
+	defer func() {
+		if !(result >= 0) {
+			panic("Violated: result >= 0")
+		}
+	}()
+
+	return len(s)
+}
+
+var (
+	// Double doubles.
+	//
+	// Double requires:
+	//  * x > 0
+	Double = func(x int) int {
+		// Pre-condition
+		if !(x > 0) {
+			panic("Violated: x > 0")
+		}
+
+		return 2 * x
+	}
+
+	// Check checks.
+	//
+	// Check requires:
+	//  * x < 100
+	Check = func(x int) {
+		// Pre-condition
+		if !(x < 100) {
+			panic("Violated: x < 100")
+		}
+	}
+
+	// Plain has no contract.
+	Plain = func() {}
+
+	untouched = 3
+)
+`}
+
+// FuncLiteralRemoved tests that the condition checks are removed from the function literals.
+var FuncLiteralRemoved = Case{
+	ID:     "func_literal_removed",
+	Text:   FuncLiteral.Expected,
+	Remove: true,
+	Expected: `package somepkg
+
+// Parse parses the text.
+//
+// Parse requires:
+//  * not empty: s != ""
+//
+// Parse ensures:
+//  * result >= 0
+var Parse = func(s string) (result int) {
+	return len(s)
+}
+
+var (
+	// Double doubles.
+	//
+	// Double requires:
+	//  * x > 0
+	Double = func(x int) int {
+		return 2 * x
+	}
+
+	// Check checks.
+	//
+	// Check requires:
+	//  * x < 100
+	Check = func(x int) {}
+
+	// Plain has no contract.
+	Plain = func() {}
+
+	untouched = 3
+)
+`}
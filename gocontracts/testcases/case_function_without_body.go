@@ -0,0 +1,43 @@
+package testcases
+
+// FunctionWithoutBody tests that the functions without a body (e.g., implemented in assembly)
+// are skipped.
+var FunctionWithoutBody = Case{
+	ID: "function_without_body",
+	Text: `package somepkg
+
+// now gives the current time in nanoseconds.
+//
+// now ensures:
+//  * result > 0
+func now() (result int64)
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+func SomeFunc(x int) {
+	// do something
+}
+`,
+	Expected: `package somepkg
+
+// now gives the current time in nanoseconds.
+//
+// now ensures:
+//  * result > 0
+func now() (result int64)
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+func SomeFunc(x int) {
+	// Pre-condition
+	if !(x > 0) {
+		panic("Violated: x > 0")
+	}
+
+	// do something
+}
+`}
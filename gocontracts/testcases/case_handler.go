@@ -0,0 +1,131 @@
+package testcases
+
+// Handler tests that the violations are reported to the handler.
+var Handler = Case{
+	ID: "handler",
+	Text: `package somepkg
+
+// SomeType defines something.
+//
+// SomeType invariants:
+//  * t.x >= 0
+type SomeType struct {
+	x int
+}
+
+// Inc increments.
+//
+// Inc requires:
+//  * positive: delta > 0
+//
+// Inc ensures:
+//  * t.x == old(t.x) + delta
+func (t *SomeType) Inc(delta int) {
+	t.x += delta
+}
+`,
+	Handler: "github.com/some/violations.Report",
+	Expected: `package somepkg
+
+import (
+	"github.com/Parquery/gocontracts/contracts"
+	"github.com/some/violations"
+)
+
+// SomeType defines something.
+//
+// SomeType invariants:
+//  * t.x >= 0
+type SomeType struct {
+	x int
+}
+
+// Inc increments.
+//
+// Inc requires:
+//  * positive: delta > 0
+//
+// Inc ensures:
+//  * t.x == old(t.x) + delta
+func (t *SomeType) Inc(delta int) {
+	// Pre-condition
+	if !(delta > 0) {
+		violations.Report(contracts.ViolationInfo{Kind: contracts.Precondition, Function: "SomeType.Inc", Label: "positive", Condition: "delta > 0", File: "handler", Line: 19})
+	}
+
+	// Invariant on entry
+	if !(t.x >= 0) {
+		violations.Report(contracts.ViolationInfo{Kind: contracts.Invariant, Function: "SomeType.Inc", Condition: "t.x >= 0", File: "handler", Line: 11})
+	}
+
+	// Invariant on exit
+	defer func() {
+		if !(t.x >= 0) {
+			violations.Report(contracts.ViolationInfo{Kind: contracts.Invariant, Function: "SomeType.Inc", Condition: "t.x >= 0", File: "handler", Line: 11})
+		}
+	}()
+
+	// Old values
+	old1 := t.x
+
+	// Post-condition
+	defer func() {
+		if !(t.x == old1+delta) {
+			violations.Report(contracts.ViolationInfo{Kind: contracts.Postcondition, Function: "SomeType.Inc", Condition: "t.x == old(t.x) + delta", File: "handler", Line: 22})
+		}
+	}()
+
+	t.x += delta
+}
+`}
+
+// HandlerInSamePackage tests the handler declared in the processed package.
+var HandlerInSamePackage = Case{
+	ID: "handler_in_same_package",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * len(m) > 0
+func SomeFunc(x int, m map[int]bool) {}
+`,
+	Handler: "report",
+	Expected: `package somepkg
+
+import "github.com/Parquery/gocontracts/contracts"
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * len(m) > 0
+func SomeFunc(x int, m map[int]bool) {
+	// Pre-conditions
+	switch {
+	case !(x > 0):
+		report(contracts.ViolationInfo{Kind: contracts.Precondition, Function: "SomeFunc", Condition: "x > 0", File: "handler_in_same_package", Line: 8})
+	case !(len(m) > 0):
+		report(contracts.ViolationInfo{Kind: contracts.Precondition, Function: "SomeFunc", Condition: "len(m) > 0", File: "handler_in_same_package", Line: 9})
+	default:
+		// Pass
+	}
+}
+`}
+
+// HandlerRemoved tests that the import of the run-time support is removed together with the checks.
+var HandlerRemoved = Case{
+	ID:      "handler_removed",
+	Text:    HandlerInSamePackage.Expected,
+	Remove:  true,
+	Handler: "report",
+	Expected: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * len(m) > 0
+func SomeFunc(x int, m map[int]bool) {}
+`}
@@ -22,6 +22,8 @@ func SomeFunc(x int, y int) (result string, err error) {
 `,
 	Expected: `package somepkg
 
+import "strings"
+
 // SomeFunc does something.
 //
 // SomeFunc requires:
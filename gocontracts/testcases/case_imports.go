@@ -0,0 +1,149 @@
+package testcases
+
+// ImportsAddedToGroup tests that the packages referred to in the contracts are added to the group of imports.
+var ImportsAddedToGroup = Case{
+	ID: "imports_added_to_group",
+	Text: `package somepkg
+
+import (
+	"fmt"
+	"os"
+)
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * strings.HasSuffix(pth, ".go")
+//  * filepath.IsAbs(pth)
+//  * sort.Len() > 0
+func SomeFunc(pth string, sort sortable) {
+	fmt.Println(os.Args)
+}
+`,
+	Expected: `package somepkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * strings.HasSuffix(pth, ".go")
+//  * filepath.IsAbs(pth)
+//  * sort.Len() > 0
+func SomeFunc(pth string, sort sortable) {
+	// Pre-conditions
+	switch {
+	case !(strings.HasSuffix(pth, ".go")):
+		panic("Violated: strings.HasSuffix(pth, \".go\")")
+	case !(filepath.IsAbs(pth)):
+		panic("Violated: filepath.IsAbs(pth)")
+	case !(sort.Len() > 0):
+		panic("Violated: sort.Len() > 0")
+	default:
+		// Pass
+	}
+
+	fmt.Println(os.Args)
+}
+`}
+
+// ImportsAddedToSingleImport tests that a single import is converted to a group when adding the imports.
+var ImportsAddedToSingleImport = Case{
+	ID: "imports_added_to_single_import",
+	Text: `package somepkg
+
+import "fmt"
+
+// SomeFunc does something.
+//
+// SomeFunc preamble:
+//  start := time.Now()
+//
+// SomeFunc ensures:
+//  * time.Since(start) < time.Second
+func SomeFunc() {
+	fmt.Println("hello")
+}
+`,
+	Expected: `package somepkg
+
+import (
+	"fmt"
+	"time"
+)
+
+// SomeFunc does something.
+//
+// SomeFunc preamble:
+//  start := time.Now()
+//
+// SomeFunc ensures:
+//  * time.Since(start) < time.Second
+func SomeFunc() {
+	// Preamble starts.
+	start := time.Now()
+	// Preamble ends.
+
+	// Post-condition
+	defer func() {
+		if !(time.Since(start) < time.Second) {
+			panic("Violated: time.Since(start) < time.Second")
+		}
+	}()
+
+	fmt.Println("hello")
+}
+`}
+
+// ImportsRemoved tests that the imports which became unused after removing the checks are removed as well.
+var ImportsRemoved = Case{
+	ID: "imports_removed",
+	Text: `package somepkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+import "unicode"
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * strings.HasPrefix(x, "some")
+//  * unicode.IsUpper(rune(x[0]))
+func SomeFunc(x string) {
+	// Pre-conditions
+	switch {
+	case !(strings.HasPrefix(x, "some")):
+		panic("Violated: strings.HasPrefix(x, \"some\")")
+	case !(unicode.IsUpper(rune(x[0]))):
+		panic("Violated: unicode.IsUpper(rune(x[0]))")
+	default:
+		// Pass
+	}
+
+	fmt.Println(x)
+}
+`,
+	Remove: true,
+	Expected: `package somepkg
+
+import (
+	"fmt"
+)
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * strings.HasPrefix(x, "some")
+//  * unicode.IsUpper(rune(x[0]))
+func SomeFunc(x string) {
+	fmt.Println(x)
+}
+`}
@@ -0,0 +1,173 @@
+package testcases
+
+// Interfaces tests that the implementations inherit the contracts of the interface methods.
+var Interfaces = Case{
+	ID: "interfaces",
+	Text: `package somepkg
+
+// Shape defines a geometric shape.
+type Shape interface {
+	// Scale scales the shape.
+	//
+	// Scale requires:
+	//  * factor > 0
+	//  * [test] factor < 1000
+	//
+	// Scale ensures:
+	//  * scaled == factor
+	Scale(factor float64) (scaled float64)
+
+	// Area computes the area.
+	//
+	// Area ensures:
+	//  * positive: area >= 0
+	Area() (area float64)
+}
+
+// Square is a shape.
+type Square struct {
+	side float64
+}
+
+// Scale scales the square.
+//
+// Scale requires:
+//  * f >= 1
+//  * f < 100
+func (sq *Square) Scale(f float64) (result float64) {
+	sq.side *= f
+	return f
+}
+
+// Area computes the area of the square.
+func (sq *Square) Area() (a float64) {
+	return sq.side * sq.side
+}
+
+// Circle is a shape.
+type Circle struct {
+	radius float64
+}
+
+// Scale scales the circle.
+func (c *Circle) Scale(factor float64) (scaled float64) {
+	c.radius *= factor
+	return factor
+}
+
+// Area computes the area of the circle.
+//
+// Area ensures:
+//  * area <= 4*c.radius*c.radius
+func (c *Circle) Area() (area float64) {
+	return 3.14 * c.radius * c.radius
+}
+`,
+	Expected: `package somepkg
+
+// Shape defines a geometric shape.
+type Shape interface {
+	// Scale scales the shape.
+	//
+	// Scale requires:
+	//  * factor > 0
+	//  * [test] factor < 1000
+	//
+	// Scale ensures:
+	//  * scaled == factor
+	Scale(factor float64) (scaled float64)
+
+	// Area computes the area.
+	//
+	// Area ensures:
+	//  * positive: area >= 0
+	Area() (area float64)
+}
+
+// Square is a shape.
+type Square struct {
+	side float64
+}
+
+// Scale scales the square.
+//
+// Scale requires:
+//  * f >= 1
+//  * f < 100
+func (sq *Square) Scale(f float64) (result float64) {
+	// Pre-condition
+	if !(((f >= 1) && (f < 100)) || ((f > 0) && (!InTest || (f < 1000)))) {
+		panic("Violated: ((f >= 1) && (f < 100)) || ((f > 0) && (!InTest || (f < 1000)))")
+	}
+
+	// Post-condition
+	defer func() {
+		if !(result == f) {
+			panic("Violated: result == f")
+		}
+	}()
+
+	sq.side *= f
+	return f
+}
+
+// Area computes the area of the square.
+func (sq *Square) Area() (a float64) {
+	// Post-condition
+	defer func() {
+		if !(a >= 0) {
+			panic("Violated: positive: a >= 0")
+		}
+	}()
+
+	return sq.side * sq.side
+}
+
+// Circle is a shape.
+type Circle struct {
+	radius float64
+}
+
+// Scale scales the circle.
+func (c *Circle) Scale(factor float64) (scaled float64) {
+	// Pre-conditions
+	switch {
+	case !(factor > 0):
+		panic("Violated: factor > 0")
+	case InTest && !(factor < 1000):
+		panic("Violated: factor < 1000")
+	default:
+		// Pass
+	}
+
+	// Post-condition
+	defer func() {
+		if !(scaled == factor) {
+			panic("Violated: scaled == factor")
+		}
+	}()
+
+	c.radius *= factor
+	return factor
+}
+
+// Area computes the area of the circle.
+//
+// Area ensures:
+//  * area <= 4*c.radius*c.radius
+func (c *Circle) Area() (area float64) {
+	// Post-conditions
+	defer func() {
+		switch {
+		case !(area <= 4*c.radius*c.radius):
+			panic("Violated: area <= 4*c.radius*c.radius")
+		case !(area >= 0):
+			panic("Violated: positive: area >= 0")
+		default:
+			// Pass
+		}
+	}()
+
+	return 3.14 * c.radius * c.radius
+}
+`}
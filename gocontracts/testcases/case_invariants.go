@@ -0,0 +1,166 @@
+package testcases
+
+// Invariants tests that the invariants of a type are checked on entry and exit of its exported methods.
+var Invariants = Case{
+	ID: "invariants",
+	Text: `package somepkg
+
+// SomeStruct defines a struct.
+//
+// SomeStruct invariants:
+//  * s.x >= 0
+//  * not too large: s.x < s.y
+type SomeStruct struct {
+	x int
+	y int
+}
+
+// Increase increases x.
+//
+// Increase requires:
+//  * delta > 0
+func (s *SomeStruct) Increase(delta int) {
+	s.x += delta
+}
+
+// Decrease decreases x.
+func (s *SomeStruct) Decrease(delta int) {
+	// Invariant on entry
+	if !(s.x >= 0) {
+		panic("Violated: s.x >= 0")
+	}
+
+	s.x -= delta
+}
+
+// reset is not exported and hence does not check the invariants.
+func (s *SomeStruct) reset() {
+	s.x = 0
+}
+`,
+	Expected: `package somepkg
+
+// SomeStruct defines a struct.
+//
+// SomeStruct invariants:
+//  * s.x >= 0
+//  * not too large: s.x < s.y
+type SomeStruct struct {
+	x int
+	y int
+}
+
+// Increase increases x.
+//
+// Increase requires:
+//  * delta > 0
+func (s *SomeStruct) Increase(delta int) {
+	// Pre-condition
+	if !(delta > 0) {
+		panic("Violated: delta > 0")
+	}
+
+	// Invariants on entry
+	switch {
+	case !(s.x >= 0):
+		panic("Violated: s.x >= 0")
+	case !(s.x < s.y):
+		panic("Violated: not too large: s.x < s.y")
+	default:
+		// Pass
+	}
+
+	// Invariants on exit
+	defer func() {
+		switch {
+		case !(s.x >= 0):
+			panic("Violated: s.x >= 0")
+		case !(s.x < s.y):
+			panic("Violated: not too large: s.x < s.y")
+		default:
+			// Pass
+		}
+	}()
+
+	s.x += delta
+}
+
+// Decrease decreases x.
+func (s *SomeStruct) Decrease(delta int) {
+	// Invariants on entry
+	switch {
+	case !(s.x >= 0):
+		panic("Violated: s.x >= 0")
+	case !(s.x < s.y):
+		panic("Violated: not too large: s.x < s.y")
+	default:
+		// Pass
+	}
+
+	// Invariants on exit
+	defer func() {
+		switch {
+		case !(s.x >= 0):
+			panic("Violated: s.x >= 0")
+		case !(s.x < s.y):
+			panic("Violated: not too large: s.x < s.y")
+		default:
+			// Pass
+		}
+	}()
+
+	s.x -= delta
+}
+
+// reset is not exported and hence does not check the invariants.
+func (s *SomeStruct) reset() {
+	s.x = 0
+}
+`}
+
+// InvariantsRemovedInCode tests that the checks of the invariants are removed from the code.
+var InvariantsRemovedInCode = Case{
+	ID: "invariants_removed_in_code",
+	Text: `package somepkg
+
+// SomeStruct defines a struct.
+//
+// SomeStruct invariants:
+//  * s.x >= 0
+type SomeStruct struct {
+	x int
+}
+
+// Increase increases x.
+func (s *SomeStruct) Increase(delta int) {
+	// Invariant on entry
+	if !(s.x >= 0) {
+		panic("Violated: s.x >= 0")
+	}
+
+	// Invariant on exit
+	defer func() {
+		if !(s.x >= 0) {
+			panic("Violated: s.x >= 0")
+		}
+	}()
+
+	s.x += delta
+}
+`,
+	Remove: true,
+	Expected: `package somepkg
+
+// SomeStruct defines a struct.
+//
+// SomeStruct invariants:
+//  * s.x >= 0
+type SomeStruct struct {
+	x int
+}
+
+// Increase increases x.
+func (s *SomeStruct) Increase(delta int) {
+	s.x += delta
+}
+`}
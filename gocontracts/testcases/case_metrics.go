@@ -0,0 +1,173 @@
+package testcases
+
+// Metrics tests that the violations are counted before they are passed to the handler.
+//
+// The import path of the package is determined from the location of the file so the case is processed in
+// the package example.com/some/util.
+var Metrics = Case{
+	ID: "metrics",
+	Text: `package somepkg
+
+// SomeType defines something.
+//
+// SomeType invariants:
+//  * t.x >= 0
+type SomeType struct {
+	x int
+}
+
+// Inc increments.
+//
+// Inc requires:
+//  * positive: delta > 0
+//
+// Inc ensures:
+//  * t.x == old(t.x) + delta
+func (t *SomeType) Inc(delta int) {
+	t.x += delta
+}
+`,
+	Handler: "github.com/some/violations.Report",
+	Metrics: true,
+	Expected: `package somepkg
+
+import (
+	"github.com/Parquery/gocontracts/contracts"
+	"github.com/some/violations"
+)
+
+// SomeType defines something.
+//
+// SomeType invariants:
+//  * t.x >= 0
+type SomeType struct {
+	x int
+}
+
+// Inc increments.
+//
+// Inc requires:
+//  * positive: delta > 0
+//
+// Inc ensures:
+//  * t.x == old(t.x) + delta
+func (t *SomeType) Inc(delta int) {
+	// Pre-condition
+	if !(delta > 0) {
+		violations.Report(contracts.Counted(contracts.ViolationInfo{Kind: contracts.Precondition, Package: "example.com/some/util", Function: "SomeType.Inc", Label: "positive", Condition: "delta > 0", File: "metrics", Line: 19}))
+	}
+
+	// Invariant on entry
+	if !(t.x >= 0) {
+		violations.Report(contracts.Counted(contracts.ViolationInfo{Kind: contracts.Invariant, Package: "example.com/some/util", Function: "SomeType.Inc", Condition: "t.x >= 0", File: "metrics", Line: 11}))
+	}
+
+	// Invariant on exit
+	defer func() {
+		if !(t.x >= 0) {
+			violations.Report(contracts.Counted(contracts.ViolationInfo{Kind: contracts.Invariant, Package: "example.com/some/util", Function: "SomeType.Inc", Condition: "t.x >= 0", File: "metrics", Line: 11}))
+		}
+	}()
+
+	// Old values
+	old1 := t.x
+
+	// Post-condition
+	defer func() {
+		if !(t.x == old1+delta) {
+			violations.Report(contracts.Counted(contracts.ViolationInfo{Kind: contracts.Postcondition, Package: "example.com/some/util", Function: "SomeType.Inc", Condition: "t.x == old(t.x) + delta", File: "metrics", Line: 22}))
+		}
+	}()
+
+	t.x += delta
+}
+`}
+
+// MetricsWithoutHandler tests that the violations are counted before they panic or are returned as errors.
+var MetricsWithoutHandler = Case{
+	ID: "metrics_without_handler",
+	Text: `package somepkg
+
+import "errors"
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * all positive: forall i in ys: ys[i] > 0
+//
+// SomeFunc ensures:
+//  * result >= x
+func SomeFunc(x int, ys []int) (result int) {
+	return x
+}
+
+// Parse parses the text.
+//
+// Parse requires (error):
+//  * not empty: len(text) > 0
+func Parse(text string) (int, error) {
+	if text == "x" {
+		return 0, errors.New("x")
+	}
+
+	return len(text), nil
+}
+`,
+	Metrics: true,
+	Expected: `package somepkg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Parquery/gocontracts/contracts"
+)
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * all positive: forall i in ys: ys[i] > 0
+//
+// SomeFunc ensures:
+//  * result >= x
+func SomeFunc(x int, ys []int) (result int) {
+	// Pre-conditions
+	{
+		if !(x > 0) {
+			panic(contracts.Counted(contracts.ViolationInfo{Kind: contracts.Precondition, Package: "example.com/some/util", Function: "SomeFunc", Condition: "x > 0", File: "metrics_without_handler", Line: 12}).Message())
+		}
+		for i := range ys {
+			if !(ys[i] > 0) {
+				panic(contracts.Counted(contracts.ViolationInfo{Kind: contracts.Precondition, Package: "example.com/some/util", Function: "SomeFunc", Label: "all positive", Condition: "forall i in ys: ys[i] > 0", Offending: fmt.Sprintf("i = %v", i), File: "metrics_without_handler", Line: 13}).Message())
+			}
+		}
+	}
+
+	// Post-condition
+	defer func() {
+		if !(result >= x) {
+			panic(contracts.Counted(contracts.ViolationInfo{Kind: contracts.Postcondition, Package: "example.com/some/util", Function: "SomeFunc", Condition: "result >= x", File: "metrics_without_handler", Line: 16}).Message())
+		}
+	}()
+
+	return x
+}
+
+// Parse parses the text.
+//
+// Parse requires (error):
+//  * not empty: len(text) > 0
+func Parse(text string) (int, error) {
+	// Pre-condition (error)
+	if !(len(text) > 0) {
+		return 0, errors.New(contracts.Counted(contracts.ViolationInfo{Kind: contracts.Precondition, Package: "example.com/some/util", Function: "Parse", Label: "not empty", Condition: "len(text) > 0", File: "metrics_without_handler", Line: 43}).Message())
+	}
+
+	if text == "x" {
+		return 0, errors.New("x")
+	}
+
+	return len(text), nil
+}
+`}
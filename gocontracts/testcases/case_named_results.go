@@ -0,0 +1,71 @@
+package testcases
+
+// NamedResults tests that the single unnamed result is named automatically.
+var NamedResults = Case{
+	ID: "named_results",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * result != ""
+func SomeFunc(x int) string {
+	if x > 0 {
+		return "positive"
+	}
+	return "non-positive"
+}
+`,
+	NameResults: true,
+	Expected: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * result != ""
+func SomeFunc(x int) (result string) {
+	// Post-condition
+	defer func() {
+		if !(result != "") {
+			panic("Violated: result != \"\"")
+		}
+	}()
+
+	if x > 0 {
+		return "positive"
+	}
+	return "non-positive"
+}
+`}
+
+// NamedResultsWithError tests that the error result is named err while the other results are numbered.
+var NamedResultsWithError = Case{
+	ID: "named_results_with_error",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * err != nil || result1 <= result2
+func SomeFunc(x int) (int, int, error) {
+	return x, x + 1, nil
+}
+`,
+	NameResults: true,
+	Expected: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * err != nil || result1 <= result2
+func SomeFunc(x int) (result1 int, result2 int, err error) {
+	// Post-condition
+	defer func() {
+		if !(err != nil || result1 <= result2) {
+			panic("Violated: err != nil || result1 <= result2")
+		}
+	}()
+
+	return x, x + 1, nil
+}
+`}
@@ -0,0 +1,162 @@
+package testcases
+
+// OldValues tests that the calls to old() in the post-conditions are replaced with the captured old values.
+var OldValues = Case{
+	ID: "old_values",
+	Text: `package somepkg
+
+// increaseFirst increases the first element of the array.
+//
+// increaseFirst requires:
+//  * len(a) > 0
+//
+// increaseFirst ensures:
+//  * a[0] == old(a[0]) + 1
+//  * same length: len(a) == old(len(a))
+//  * a[0] > old( a[0] )
+func increaseFirst(a []int) {
+	// Old values
+	old1 := a[1]
+
+	// Post-condition
+	defer func() {
+		if !(a[0] == old1) {
+			panic("Violated: a[0] == old(a[1])")
+		}
+	}()
+
+	a[0]++
+}
+`,
+	Expected: `package somepkg
+
+// increaseFirst increases the first element of the array.
+//
+// increaseFirst requires:
+//  * len(a) > 0
+//
+// increaseFirst ensures:
+//  * a[0] == old(a[0]) + 1
+//  * same length: len(a) == old(len(a))
+//  * a[0] > old( a[0] )
+func increaseFirst(a []int) {
+	// Pre-condition
+	if !(len(a) > 0) {
+		panic("Violated: len(a) > 0")
+	}
+
+	// Old values
+	old1, old2 := a[0], len(a)
+
+	// Post-conditions
+	defer func() {
+		switch {
+		case !(a[0] == old1+1):
+			panic("Violated: a[0] == old(a[0]) + 1")
+		case !(len(a) == old2):
+			panic("Violated: same length: len(a) == old(len(a))")
+		case !(a[0] > old1):
+			panic("Violated: a[0] > old( a[0] )")
+		default:
+			// Pass
+		}
+	}()
+
+	a[0]++
+}
+`}
+
+// OldValuesNamesTaken tests that the captured old values do not shadow the identifiers of the function.
+var OldValuesNamesTaken = Case{
+	ID: "old_values_names_taken",
+	Text: `package somepkg
+
+// shift shifts the first element of the array.
+//
+// shift ensures:
+//  * old1[0] == old(old1[0])+old2
+func shift(old1 []int, old2 int) {
+	old3 := old2
+	old1[0] += old3
+}
+`,
+	Expected: `package somepkg
+
+// shift shifts the first element of the array.
+//
+// shift ensures:
+//  * old1[0] == old(old1[0])+old2
+func shift(old1 []int, old2 int) {
+	// Old values
+	old4 := old1[0]
+
+	// Post-condition
+	defer func() {
+		if !(old1[0] == old4+old2) {
+			panic("Violated: old1[0] == old(old1[0])+old2")
+		}
+	}()
+
+	old3 := old2
+	old1[0] += old3
+}
+`}
+
+// OldValuesInInitialization tests that the calls to old() in the initialization of a post-condition
+// are replaced as well.
+var OldValuesInInitialization = Case{
+	ID: "old_values_in_initialization",
+	Text: `package somepkg
+
+// increment increments the count of the key.
+//
+// increment ensures:
+//  * before, after := old(m[k]), m[k]; after == before+1
+func increment(m map[string]int, k string) {
+	m[k]++
+}
+`,
+	Expected: `package somepkg
+
+// increment increments the count of the key.
+//
+// increment ensures:
+//  * before, after := old(m[k]), m[k]; after == before+1
+func increment(m map[string]int, k string) {
+	// Old values
+	old1 := m[k]
+
+	// Post-condition
+	defer func() {
+		if before, after := old1, m[k]; !(after == before+1) {
+			panic("Violated: before, after := old(m[k]), m[k]; after == before+1")
+		}
+	}()
+
+	m[k]++
+}
+`}
+
+// OldValuesInCode tests that a comment "Old values" in a function without a contract is left as-is.
+var OldValuesInCode = Case{
+	ID: "old_values_in_code",
+	Text: `package somepkg
+
+// SomeFunc does something.
+func SomeFunc(a []int) {
+	// Old values
+	x := a[0]
+
+	fmt.Println(x)
+}
+`,
+	Expected: `package somepkg
+
+// SomeFunc does something.
+func SomeFunc(a []int) {
+	// Old values
+	x := a[0]
+
+	fmt.Println(x)
+}
+`}
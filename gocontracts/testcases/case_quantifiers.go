@@ -0,0 +1,136 @@
+package testcases
+
+// Quantifiers tests that the quantified conditions are checked in loops.
+var Quantifiers = Case{
+	ID: "quantifiers",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * positive: forall i, v in items: v > 0
+//  * exists k in m: k == ""
+//
+// SomeFunc ensures:
+//  * forall _, v in items[1:]: v <= old(x) * 100
+//  * result > 0
+func SomeFunc(x int, items []int, m map[string]bool) (result int) {
+	return x
+}
+
+// AnotherFunc does something else.
+//
+// AnotherFunc requires:
+//  * forall k, v in m: v > len(k)
+func AnotherFunc(m map[string]int) {}
+`,
+	Expected: `package somepkg
+
+import "fmt"
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * positive: forall i, v in items: v > 0
+//  * exists k in m: k == ""
+//
+// SomeFunc ensures:
+//  * forall _, v in items[1:]: v <= old(x) * 100
+//  * result > 0
+func SomeFunc(x int, items []int, m map[string]bool) (result int) {
+	// Pre-conditions
+	{
+		if !(x > 0) {
+			panic("Violated: x > 0")
+		}
+		for i, v := range items {
+			if !(v > 0) {
+				panic(fmt.Sprintf("Violated: positive: forall i, v in items: v > 0 (i = %v)", i))
+			}
+		}
+		if !func() bool {
+			for k := range m {
+				if k == "" {
+					return true
+				}
+			}
+			return false
+		}() {
+			panic("Violated: exists k in m: k == \"\"")
+		}
+	}
+
+	// Old values
+	old1 := x
+
+	// Post-conditions
+	defer func() {
+		for _, v := range items[1:] {
+			if !(v <= old1*100) {
+				panic("Violated: forall _, v in items[1:]: v <= old(x) * 100")
+			}
+		}
+		if !(result > 0) {
+			panic("Violated: result > 0")
+		}
+	}()
+
+	return x
+}
+
+// AnotherFunc does something else.
+//
+// AnotherFunc requires:
+//  * forall k, v in m: v > len(k)
+func AnotherFunc(m map[string]int) {
+	// Pre-condition
+	for k, v := range m {
+		if !(v > len(k)) {
+			panic(fmt.Sprintf("Violated: forall k, v in m: v > len(k) (k = %v)", k))
+		}
+	}
+}
+`}
+
+// QuantifiersWithHandler tests that the handler is informed about the element violating
+// a universally quantified condition.
+var QuantifiersWithHandler = Case{
+	ID: "quantifiers_with_handler",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * sorted: forall i in result: i == 0 || result[i] >= result[i-1]
+func SomeFunc(items []int) (result []int) {
+	return items
+}
+`,
+	Handler: "report",
+	Expected: `package somepkg
+
+import (
+	"fmt"
+	"github.com/Parquery/gocontracts/contracts"
+)
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * sorted: forall i in result: i == 0 || result[i] >= result[i-1]
+func SomeFunc(items []int) (result []int) {
+	// Post-condition
+	defer func() {
+		for i := range result {
+			if !(i == 0 || result[i] >= result[i-1]) {
+				report(contracts.ViolationInfo{Kind: contracts.Postcondition, Function: "SomeFunc", Label: "sorted", Condition: "forall i in result: i == 0 || result[i] >= result[i-1]", Offending: fmt.Sprintf("i = %v", i), File: "quantifiers_with_handler", Line: 11})
+				break
+			}
+		}
+	}()
+
+	return items
+}
+`}
@@ -0,0 +1,69 @@
+package testcases
+
+// ReturnErrors tests that the violated pre-conditions are returned as errors.
+var ReturnErrors = Case{
+	ID: "return_errors",
+	Text: `package somepkg
+
+// Parse parses the text.
+//
+// Parse requires (error):
+//  * text != ""
+//  * base in range: base >= 2 && base <= 36
+func Parse(text string, base int) (int, *Node, []byte, Point, error) {
+	return 0, nil, nil, Point{}, nil
+}
+
+// First returns the first item.
+//
+// First requires (error):
+//  * len(items) > 0
+func First[T any](items []T) (first T, err error) {
+	first = items[0]
+	return
+}
+`,
+	Expected: `package somepkg
+
+import "errors"
+
+// Parse parses the text.
+//
+// Parse requires (error):
+//  * text != ""
+//  * base in range: base >= 2 && base <= 36
+func Parse(text string, base int) (int, *Node, []byte, Point, error) {
+	// Pre-conditions (error)
+	switch {
+	case !(text != ""):
+		return 0, nil, nil, *new(Point), errors.New("Violated: text != \"\"")
+	case !(base >= 2 && base <= 36):
+		return 0, nil, nil, *new(Point), errors.New("Violated: base in range: base >= 2 && base <= 36")
+	default:
+		// Pass
+	}
+
+	return 0, nil, nil, Point{}, nil
+}
+
+// First returns the first item.
+//
+// First requires (error):
+//  * len(items) > 0
+func First[T any](items []T) (first T, err error) {
+	// Pre-condition (error)
+	if !(len(items) > 0) {
+		return *new(T), errors.New("Violated: len(items) > 0")
+	}
+
+	first = items[0]
+	return
+}
+`}
+
+// ReturnErrorsRemoved tests that the pre-conditions returned as errors are removed together with the import.
+var ReturnErrorsRemoved = Case{
+	ID:       "return_errors_removed",
+	Text:     ReturnErrors.Expected,
+	Remove:   true,
+	Expected: ReturnErrors.Text}
@@ -0,0 +1,206 @@
+package testcases
+
+// Sampling tests that the conditions of a sampled function are checked only on the sampled calls.
+var Sampling = Case{
+	ID: "sampling",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * x < 100
+//
+// SomeFunc ensures:
+//  * result != ""
+//
+// SomeFunc contracts: sample 1/1000
+func SomeFunc(x int) (result string) {
+	return "something"
+}
+`,
+	Expected: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * x < 100
+//
+// SomeFunc ensures:
+//  * result != ""
+//
+// SomeFunc contracts: sample 1/1000
+func SomeFunc(x int) (result string) {
+	// Sampling
+	sampled := contractsSample_SomeFunc.Sample()
+
+	// Pre-conditions
+	if sampled {
+		switch {
+		case !(x > 0):
+			panic("Violated: x > 0")
+		case !(x < 100):
+			panic("Violated: x < 100")
+		default:
+			// Pass
+		}
+	}
+
+	// Post-condition
+	if sampled {
+		defer func() {
+			if !(result != "") {
+				panic("Violated: result != \"\"")
+			}
+		}()
+	}
+
+	return "something"
+}
+`,
+}
+
+// SamplingRemoved tests that the sampling decision is removed together with the checks.
+var SamplingRemoved = Case{
+	ID:       "sampling_removed",
+	Text:     Sampling.Expected,
+	Remove:   true,
+	Expected: Sampling.Text,
+}
+
+// SamplingShadowed tests that the variable holding the sampling decision does not shadow the names used in
+// the function and that the sampler of a method is named after the receiver type and the method.
+var SamplingShadowed = Case{
+	ID: "sampling_shadowed",
+	Text: `package somepkg
+
+// SomeMethod does something.
+//
+// SomeMethod requires:
+//  * sampled > 0
+//
+// SomeMethod contracts: sample 1/10
+func (t *SomeType) SomeMethod(sampled int) {}
+`,
+	Expected: `package somepkg
+
+// SomeMethod does something.
+//
+// SomeMethod requires:
+//  * sampled > 0
+//
+// SomeMethod contracts: sample 1/10
+func (t *SomeType) SomeMethod(sampled int) {
+	// Sampling
+	sampled1 := contractsSample_SomeType_SomeMethod.Sample()
+
+	// Pre-condition
+	if sampled1 {
+		if !(sampled > 0) {
+			panic("Violated: sampled > 0")
+		}
+	}
+}
+`,
+}
+
+// SamplingInCode tests that a block of the function looking like the sampling decision is left as-is if
+// the function checks no conditions.
+var SamplingInCode = Case{
+	ID: "sampling_in_code",
+	Text: `package somepkg
+
+import "math/rand"
+
+// Pick picks an index.
+func Pick(n int) int {
+	// Sampling
+	idx := rand.Intn(n)
+
+	return idx
+}
+`,
+	Expected: `package somepkg
+
+import "math/rand"
+
+// Pick picks an index.
+func Pick(n int) int {
+	// Sampling
+	idx := rand.Intn(n)
+
+	return idx
+}
+`,
+}
+
+// SamplingWithOldValues tests that the preamble and the old values are computed only on the sampled calls.
+var SamplingWithOldValues = Case{
+	ID: "sampling_with_old_values",
+	Text: `package somepkg
+
+// Push pushes the value on the stack.
+//
+// Push preamble:
+//  last := len(s.items)
+//
+// Push ensures:
+//  * len(s.items) == old(len(s.items)) + 1
+//  * s.items[last] == x
+//
+// Push contracts: sample 1/100
+func (s *Stack) Push(x int) {
+	s.items = append(s.items, x)
+}
+`,
+	Expected: `package somepkg
+
+// Push pushes the value on the stack.
+//
+// Push preamble:
+//  last := len(s.items)
+//
+// Push ensures:
+//  * len(s.items) == old(len(s.items)) + 1
+//  * s.items[last] == x
+//
+// Push contracts: sample 1/100
+func (s *Stack) Push(x int) {
+	// Sampling
+	sampled := contractsSample_Stack_Push.Sample()
+
+	// Post-conditions
+	if sampled {
+		// Preamble starts.
+		last := len(s.items)
+		// Preamble ends.
+
+		// Old values
+		old1 := len(s.items)
+
+		defer func() {
+			switch {
+			case !(len(s.items) == old1+1):
+				panic("Violated: len(s.items) == old(len(s.items)) + 1")
+			case !(s.items[last] == x):
+				panic("Violated: s.items[last] == x")
+			default:
+				// Pass
+			}
+		}()
+	}
+
+	s.items = append(s.items, x)
+}
+`,
+}
+
+// SamplingWithOldValuesRemoved tests that the preamble and the old values guarded by the sampling are removed
+// together with the checks.
+var SamplingWithOldValuesRemoved = Case{
+	ID:       "sampling_with_old_values_removed",
+	Text:     SamplingWithOldValues.Expected,
+	Remove:   true,
+	Expected: SamplingWithOldValues.Text,
+}
@@ -0,0 +1,140 @@
+package testcases
+
+// Toggle tests that the blocks of checks are guarded by the level of the checks loaded on entry.
+var Toggle = Case{
+	ID: "toggle",
+	Text: `package somepkg
+
+// SomeType defines something.
+//
+// SomeType invariants:
+//  * t.x >= 0
+type SomeType struct {
+	x int
+}
+
+// Add adds the delta.
+//
+// Add requires:
+//  * positive: delta > 0
+//  * delta < 100
+//
+// Add ensures:
+//  * t.x == old(t.x) + delta
+func (t *SomeType) Add(delta int) {
+	t.x += delta
+}
+`,
+	Toggle: true,
+	Expected: `package somepkg
+
+import "github.com/Parquery/gocontracts/contracts"
+
+// SomeType defines something.
+//
+// SomeType invariants:
+//  * t.x >= 0
+type SomeType struct {
+	x int
+}
+
+// Add adds the delta.
+//
+// Add requires:
+//  * positive: delta > 0
+//  * delta < 100
+//
+// Add ensures:
+//  * t.x == old(t.x) + delta
+func (t *SomeType) Add(delta int) {
+	// Level of the checks
+	contractsLevel := contracts.CurrentLevel()
+
+	// Pre-conditions
+	if contractsLevel >= contracts.LevelPre {
+		switch {
+		case !(delta > 0):
+			panic("Violated: positive: delta > 0")
+		case !(delta < 100):
+			panic("Violated: delta < 100")
+		default:
+			// Pass
+		}
+	}
+
+	// Invariant on entry
+	if contractsLevel >= contracts.LevelAll {
+		if !(t.x >= 0) {
+			panic("Violated: t.x >= 0")
+		}
+	}
+
+	// Invariant on exit
+	if contractsLevel >= contracts.LevelAll {
+		defer func() {
+			if !(t.x >= 0) {
+				panic("Violated: t.x >= 0")
+			}
+		}()
+	}
+
+	// Post-condition
+	if contractsLevel >= contracts.LevelAll {
+		// Old values
+		old1 := t.x
+
+		defer func() {
+			if !(t.x == old1+delta) {
+				panic("Violated: t.x == old(t.x) + delta")
+			}
+		}()
+	}
+
+	t.x += delta
+}
+`,
+}
+
+// ToggleRemoved tests that the guarded blocks of checks are removed together with the import of
+// the run-time support.
+var ToggleRemoved = Case{
+	ID:       "toggle_removed",
+	Text:     Toggle.Expected,
+	Remove:   true,
+	Expected: Toggle.Text,
+}
+
+// ToggleShadowed tests that the variable holding the level of the checks does not shadow the names used in
+// the function.
+var ToggleShadowed = Case{
+	ID: "toggle_shadowed",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * contractsLevel > 0
+func SomeFunc(contractsLevel int) {}
+`,
+	Toggle: true,
+	Expected: `package somepkg
+
+import "github.com/Parquery/gocontracts/contracts"
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * contractsLevel > 0
+func SomeFunc(contractsLevel int) {
+	// Level of the checks
+	contractsLevel1 := contracts.CurrentLevel()
+
+	// Pre-condition
+	if contractsLevel1 >= contracts.LevelPre {
+		if !(contractsLevel > 0) {
+			panic("Violated: contractsLevel > 0")
+		}
+	}
+}
+`,
+}
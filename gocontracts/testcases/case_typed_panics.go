@@ -0,0 +1,36 @@
+package testcases
+
+// TypedPanics tests that the run-time support panics with the typed value.
+var TypedPanics = Case{
+	ID: "typed_panics",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * len(m) > 0
+func SomeFunc(x int, m map[int]bool) {}
+`,
+	Handler: "github.com/Parquery/gocontracts/contracts.Panic",
+	Expected: `package somepkg
+
+import "github.com/Parquery/gocontracts/contracts"
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+//  * len(m) > 0
+func SomeFunc(x int, m map[int]bool) {
+	// Pre-conditions
+	switch {
+	case !(x > 0):
+		contracts.Panic(contracts.ViolationInfo{Kind: contracts.Precondition, Function: "SomeFunc", Condition: "x > 0", File: "typed_panics", Line: 8})
+	case !(len(m) > 0):
+		contracts.Panic(contracts.ViolationInfo{Kind: contracts.Precondition, Function: "SomeFunc", Condition: "len(m) > 0", File: "typed_panics", Line: 9})
+	default:
+		// Pass
+	}
+}
+`}
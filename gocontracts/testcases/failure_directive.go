@@ -0,0 +1,27 @@
+package testcases
+
+// FailureUnknownDirective tests that the unknown directives are reported.
+var FailureUnknownDirective = Failure{
+	ID: "unknown_directive",
+	Text: `package somepkg
+
+//gocontracts:disabled
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+func SomeFunc(x int) {}
+`,
+	Error: "unknown directive //gocontracts:disabled on line 3; " +
+		"expected //gocontracts:disable, //gocontracts:handler or //gocontracts:name-results"}
+
+// FailureRepeatedHandlerDirective tests that the handler can be given only once.
+var FailureRepeatedHandlerDirective = Failure{
+	ID: "repeated_handler_directive",
+	Text: `//gocontracts:handler report
+//gocontracts:handler mypkg.Fail
+
+package somepkg
+`,
+	Error: "the directive //gocontracts:handler mypkg.Fail on line 2 repeats the handler given on line 1"}
@@ -0,0 +1,17 @@
+package testcases
+
+// FailureOldInPrecondition tests that old() is rejected outside of the post-conditions.
+var FailureOldInPrecondition = Failure{
+	ID: "old_in_precondition",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > old(x)
+func SomeFunc(x int) {
+	return
+}
+`,
+	Error: "failed to generate the code of the function SomeFunc on line 7: " +
+		"unexpected old() in the condition \"x > old(x)\"; old() can be only used in post-conditions"}
@@ -0,0 +1,18 @@
+package testcases
+
+// FailureOldInQuantifiedCollection tests that old() is rejected in the collection of a quantified condition.
+var FailureOldInQuantifiedCollection = Failure{
+	ID: "old_in_quantified_collection",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * forall i, x in old(items): items[i] == x
+func SomeFunc(items []int) {
+	return
+}
+`,
+	Error: "failed to generate the code of the function SomeFunc on line 7: " +
+		"unexpected old() in the collection of the quantified condition " +
+		"\"forall i, x in old(items): items[i] == x\"; old() can be only used in the condition on the elements"}
@@ -0,0 +1,20 @@
+package testcases
+
+// FailureOldOfQuantifiedVariable tests that old() is rejected if it refers to a variable bound by
+// the quantifier since the old values are captured before the elements are iterated.
+var FailureOldOfQuantifiedVariable = Failure{
+	ID: "old_of_quantified_variable",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * forall i in items: items[i] == old(items[i])+1
+func SomeFunc(items []int) {
+	return
+}
+`,
+	Error: "failed to generate the code of the function SomeFunc on line 7: " +
+		"unexpected old() referring to the variable i bound by the quantified condition " +
+		"\"forall i in items: items[i] == old(items[i])+1\"; " +
+		"old() can be only applied to the values known on entry of the function"}
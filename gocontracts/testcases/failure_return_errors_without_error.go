@@ -0,0 +1,19 @@
+package testcases
+
+// FailureReturnErrorsWithoutError tests that the pre-conditions can be returned only as the last result of
+// type error.
+var FailureReturnErrorsWithoutError = Failure{
+	ID: "return_errors_without_error",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires (error):
+//  * x > 0
+func SomeFunc(x int) int {
+	return x
+}
+`,
+	Error: "failed to generate the code of the function SomeFunc on line 7: " +
+		"the function SomeFunc needs to return an error as its last result " +
+		"in order to return the violated pre-conditions as errors"}
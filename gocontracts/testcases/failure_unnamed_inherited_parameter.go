@@ -0,0 +1,28 @@
+package testcases
+
+// FailureUnnamedInheritedParameter tests that the implementations need to name the parameters referred to
+// in the contracts of the interface methods.
+var FailureUnnamedInheritedParameter = Failure{
+	ID: "unnamed_inherited_parameter",
+	Text: `package somepkg
+
+// Shape defines a geometric shape.
+type Shape interface {
+	// Scale scales the shape.
+	//
+	// Scale requires:
+	//  * factor > 0
+	Scale(factor float64)
+}
+
+// Square is a shape.
+type Square struct {
+	side float64
+}
+
+// Scale ignores the factor.
+func (sq *Square) Scale(float64) {}
+`,
+	Error: "failed to inherit the contract of the method Scale on line 18: " +
+		"the parameter factor needs to be named in order to check the condition \"factor > 0\" " +
+		"inherited from Shape.Scale"}
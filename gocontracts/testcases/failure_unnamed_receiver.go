@@ -0,0 +1,22 @@
+package testcases
+
+// FailureUnnamedReceiver tests that the methods need to name their receiver if the type has invariants.
+var FailureUnnamedReceiver = Failure{
+	ID: "unnamed_receiver",
+	Text: `package somepkg
+
+// SomeStruct defines a struct.
+//
+// SomeStruct invariants:
+//  * s.x >= 0
+type SomeStruct struct {
+	x int
+}
+
+// Increase increases x.
+func (*SomeStruct) Increase(delta int) {
+	return
+}
+`,
+	Error: "the method Increase on line 12 needs to name its receiver " +
+		"in order to check the invariants of the type SomeStruct"}
@@ -0,0 +1,18 @@
+package testcases
+
+// FailureUnnamedResults tests that the post-conditions referring to unnamed results are reported.
+var FailureUnnamedResults = Failure{
+	ID: "unnamed_results",
+	Text: `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc ensures:
+//  * len(result) > len(prefix)
+func SomeFunc(prefix string) string {
+	return prefix + "!"
+}
+`,
+	Error: "unnamed_results:6: post-condition \"len(result) > len(prefix)\" refers to result which is not " +
+		"in scope of the function SomeFunc; the results of the function are unnamed, " +
+		"name them in order to refer to them in the post-conditions"}
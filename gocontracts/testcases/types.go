@@ -10,6 +10,21 @@ type Case struct {
 	// The value of remove argument to Process
 	Remove bool
 
+	// The value of the NameResults option
+	NameResults bool
+
+	// The value of the Handler option
+	Handler string
+
+	// The value of the Coverage option
+	Coverage bool
+
+	// The value of the Toggle option
+	Toggle bool
+
+	// The value of the Metrics option
+	Metrics bool
+
 	// Expected code after the Text was processed
 	Expected string
 }
@@ -0,0 +1,96 @@
+package gocontracts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// levelVar is the name of the variable holding the level of the checks loaded on entry to the function
+// unless the function already uses it.
+const levelVar = "contractsLevel"
+
+// freshName gives the name, or the name suffixed with the smallest number, which is not taken.
+func freshName(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s%d", name, i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// levelCode generates the code loading the level of the checks once on entry to the function.
+//
+// The code is indented. It does not end with a new-line character.
+func (s site) levelCode() string {
+	return fmt.Sprintf("\t// Level of the checks\n\t%s := %s.CurrentLevel()", s.level, runtimePackage)
+}
+
+// guardedBlock guards the block of checks by the level of the checks given as the name of the constant
+// of the contracts package (e.g., "LevelPre") if the site is toggled, and by the sampling decision if
+// the function is sampled. Otherwise, the block is returned as-is.
+//
+// The comment stays in front of the guard so that the block is still recognized in the function body.
+// Since the level is loaded only once on entry, the disabled checks cost a single atomic load per call.
+func (s site) guardedBlock(block string, level string) string {
+	guards := []string{}
+	if s.toggle {
+		guards = append(guards, fmt.Sprintf("%s >= %s.%s", s.level, runtimePackage, level))
+	}
+
+	if s.sampler != "" {
+		guards = append(guards, s.sampled)
+	}
+
+	if len(guards) == 0 {
+		return block
+	}
+
+	lines := strings.Split(block, "\n")
+
+	guarded := make([]string, 0, len(lines)+2)
+	guarded = append(guarded, lines[0], fmt.Sprintf("\tif %s {", strings.Join(guards, " && ")))
+	for _, line := range lines[1:] {
+		if line == "" {
+			guarded = append(guarded, line)
+			continue
+		}
+
+		guarded = append(guarded, "\t"+line)
+	}
+	guarded = append(guarded, "\t}")
+
+	return strings.Join(guarded, "\n")
+}
+
+// foldedBlock prepends the preamble and the capturing of the old values to the block of post-conditions
+// right after its comment so that they are guarded together with the post-conditions and remain in scope
+// of the deferred checks. The empty parts are skipped.
+func foldedBlock(block string, parts ...string) string {
+	lines := strings.SplitN(block, "\n", 2)
+
+	folded := []string{lines[0]}
+	for _, part := range parts {
+		if part != "" {
+			folded = append(folded, part+"\n")
+		}
+	}
+	folded = append(folded, lines[1])
+
+	return strings.Join(folded, "\n")
+}
+
+// checked indicates whether any of the updated functions checks conditions.
+func checked(updates []funcUpdate) bool {
+	for _, up := range updates {
+		if len(up.contractInDoc.Pres) > 0 || len(up.invariants) > 0 || len(up.contractInDoc.Posts) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
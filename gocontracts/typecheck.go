@@ -0,0 +1,427 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Parquery/gocontracts/parsecomment/parsecond"
+)
+
+// Diagnostic reports a problem with a contract at a position in the source code.
+type Diagnostic struct {
+	Position token.Position
+	Message  string
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s", d.Position.String(), d.Message)
+}
+
+// Diagnostics bundle the problems with the contracts of a file.
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+	lines := make([]string, 0, len(d))
+	for _, diag := range d {
+		lines = append(lines, diag.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// snippet describes a line of the type-checking playground which corresponds to a part of a contract.
+type snippet struct {
+	// what is the human-readable description of the checked code, e.g., `pre-condition "x > 0"`.
+	what string
+
+	// inPost indicates that the code is a post-condition.
+	inPost bool
+
+	fn *ast.FuncDecl
+}
+
+// hasUnnamedResults checks whether the function returns results without naming them.
+func hasUnnamedResults(fn *ast.FuncDecl) bool {
+	results := fn.Type.Results
+	return results != nil && len(results.List) > 0 && len(results.List[0].Names) == 0
+}
+
+// sourceOf returns the source code of the node.
+func sourceOf(fset *token.FileSet, text string, node ast.Node) string {
+	return text[fset.Position(node.Pos()).Offset:fset.Position(node.End()).Offset]
+}
+
+// playground generates the code of a file which mirrors the contracts of the given file so that they can be
+// type-checked in the scope of their functions.
+//
+// Each contracted function is mirrored by a function of the same signature (including the receiver) whose body
+// consists of the conditions and the preamble. The line directives map the code back to the documentation.
+// The snippets are mapped by the line in the playground.
+type playground struct {
+	name     string
+	code     strings.Builder
+	line     int
+	snippets map[int]snippet
+	counter  *int
+}
+
+// writeLine writes a line of code to the playground.
+func (p *playground) writeLine(format string, args ...interface{}) {
+	p.code.WriteString(fmt.Sprintf(format, args...))
+	p.code.WriteString("\n")
+	p.line++
+}
+
+// writeSnippet writes a line of code which maps to the given line of the documentation.
+func (p *playground) writeSnippet(filename string, docLine int, s snippet, code string) {
+	p.writeLine("//line %s:%d", filename, docLine)
+	p.snippets[p.line+1] = s
+	p.writeLine("%s", code)
+}
+
+// conditionCode generates the code to type-check a condition.
+func conditionCode(c parsecond.Condition) string {
+	if c.InitStr != "" {
+		return fmt.Sprintf("if %s; %s {}", c.InitStr, c.ExprStr())
+	}
+
+	return fmt.Sprintf("if %s {}", c.ExprStr())
+}
+
+// writeFunc mirrors the contract of the function in the playground.
+func (p *playground) writeFunc(fset *token.FileSet, text string, filename string, up funcUpdate) (err error) {
+	fn := up.fn
+	*p.counter++
+
+	recv := ""
+	if fn.Recv != nil {
+		recv = sourceOf(fset, text, fn.Recv) + " "
+	}
+
+	typeParams := ""
+	if fn.Type.TypeParams != nil {
+		typeParams = sourceOf(fset, text, fn.Type.TypeParams)
+	}
+
+	results := ""
+	if fn.Type.Results != nil {
+		results = " " + sourceOf(fset, text, fn.Type.Results)
+	}
+
+	p.writeLine("func %sgocontractsTypeCheck%d%s%s%s {",
+		recv, *p.counter, typeParams, sourceOf(fset, text, fn.Type.Params), results)
+
+	for _, c := range up.contractInDoc.Pres {
+		p.writeSnippet(documentedIn(c, filename), c.Line,
+			snippet{what: fmt.Sprintf("pre-condition %#v", c.CondStr), fn: fn}, conditionCode(c))
+	}
+
+	for _, c := range up.invariants {
+		p.writeSnippet(documentedIn(c, filename), c.Line,
+			snippet{what: fmt.Sprintf("invariant %#v", c.CondStr), fn: fn}, conditionCode(c))
+	}
+
+	if up.contractInDoc.Preamble != "" {
+		p.writeLine("//line %s:%d", filename, up.contractInDoc.PreambleLine)
+		for _, l := range strings.Split(up.contractInDoc.Preamble, "\n") {
+			p.snippets[p.line+1] = snippet{what: "preamble", fn: fn}
+			p.writeLine("%s", l)
+		}
+	}
+
+	for _, c := range up.contractInDoc.Posts {
+		// Check each post-condition in a separate block so that the old values do not clash.
+		var rewritten []parsecond.Condition
+		var snapshots []snapshot
+		rewritten, snapshots, err = rewriteOld([]parsecond.Condition{c}, usedNames(up))
+		if err != nil {
+			return
+		}
+
+		code := conditionCode(rewritten[0])
+		if len(snapshots) > 0 {
+			names := make([]string, 0, len(snapshots))
+			exprs := make([]string, 0, len(snapshots))
+			for _, s := range snapshots {
+				names = append(names, s.Name)
+				exprs = append(exprs, s.Expr)
+			}
+
+			code = fmt.Sprintf("%s := %s; %s", strings.Join(names, ", "), strings.Join(exprs, ", "), code)
+		}
+
+		p.writeSnippet(documentedIn(c, filename), c.Line,
+			snippet{what: fmt.Sprintf("post-condition %#v", c.CondStr), inPost: true, fn: fn},
+			fmt.Sprintf("{ %s }", code))
+	}
+
+	p.writeLine("panic(\"unreachable\")")
+	p.writeLine("}")
+	p.writeLine("")
+
+	return
+}
+
+// parsedFile bundles a parsed Go file with its content.
+type parsedFile struct {
+	path string
+	text string
+	node *ast.File
+}
+
+// newPlayground generates the playground mirroring the contracts of the file.
+//
+// The names declared at the package level are passed in so that they are not mistaken for packages.
+// If the file has no contracts, the playground is nil.
+func newPlayground(fset *token.FileSet, file parsedFile, pkgScope map[string]bool, inh inheritance,
+	siblingInvs map[string][]parsecond.Condition, counter *int) (p *playground, err error) {
+
+	updates, collectErr := collectUpdates(fset, file.node, false, inh, siblingInvs)
+	if collectErr != nil {
+		// The contracts can not be parsed, which will be reported when processing the file.
+		return
+	}
+
+	// No code is generated for the contracts which are only documented so they are not checked.
+	updates = stripDocOnly(updates)
+
+	contracted := make([]funcUpdate, 0, len(updates))
+	for _, up := range updates {
+		if len(up.contractInDoc.Pres) > 0 || len(up.contractInDoc.Posts) > 0 ||
+			up.contractInDoc.Preamble != "" || len(up.invariants) > 0 {
+			contracted = append(contracted, up)
+		}
+	}
+
+	if len(contracted) == 0 {
+		return
+	}
+
+	// Import all the packages of the file and the packages referred to only in the contracts.
+	// The errors about unused imports are ignored since they can not be mapped to the contracts.
+	imports := make(map[string]string)
+	dotImports := []string{}
+	for _, spec := range file.node.Imports {
+		name := importName(spec, file.path)
+		importPath, unquoteErr := strconv.Unquote(spec.Path.Value)
+		if name == "_" || importPath == "C" || unquoteErr != nil {
+			continue
+		}
+
+		if name == "." {
+			dotImports = append(dotImports, importPath)
+		} else {
+			imports[name] = importPath
+		}
+	}
+
+	for _, up := range contracted {
+		names, namesErr := packagesInContract(up, file.node, nil)
+		if namesErr != nil {
+			// The contract can not be parsed, which will be reported when processing the file.
+			return
+		}
+
+		for _, name := range names {
+			if _, ok := imports[name]; ok || pkgScope[name] {
+				continue
+			}
+
+			if importPath, ok := resolveImport(name, file.path); ok {
+				imports[name] = importPath
+			}
+		}
+	}
+
+	p = &playground{
+		name:     file.path + ".gocontracts",
+		snippets: make(map[int]snippet),
+		counter:  counter}
+
+	p.writeLine("package %s", file.node.Name.Name)
+	p.writeLine("")
+
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p.writeLine("import %s %s", name, strconv.Quote(imports[name]))
+	}
+	for _, importPath := range dotImports {
+		p.writeLine("import . %s", strconv.Quote(importPath))
+	}
+	p.writeLine("")
+
+	for _, up := range contracted {
+		err = p.writeFunc(fset, file.text, file.path, up)
+		if err != nil {
+			err = fmt.Errorf("failed to type-check the function %s on line %d: %s",
+				up.fn.Name.Name, fset.Position(up.fn.Pos()).Line, err)
+			return
+		}
+	}
+
+	return
+}
+
+// typeCheckPackage type-checks the contracts of the files belonging to the same package.
+func typeCheckPackage(fset *token.FileSet, files []parsedFile) (diagnostics map[string]Diagnostics, err error) {
+	diagnostics = make(map[string]Diagnostics)
+
+	pkgScope := make(map[string]bool)
+	for _, file := range files {
+		for name := range file.node.Scope.Objects {
+			pkgScope[name] = true
+		}
+	}
+
+	nodes := make([]*ast.File, 0, 2*len(files))
+	for _, file := range files {
+		nodes = append(nodes, file.node)
+	}
+
+	inh, inhErr := collectInheritance(fset, nodes)
+	if inhErr != nil {
+		// The contracts of the interfaces can not be parsed, which will be reported when processing the files.
+		inh = nil
+	}
+
+	counter := 0
+	playgrounds := make(map[string]*playground)
+	for _, file := range files {
+		// No code is generated for the disabled files so their contracts are not checked.
+		opts, dirErr := applyDirectives(fset, file.node, Options{})
+		if dirErr == nil && opts.Remove {
+			continue
+		}
+
+		siblings := []*ast.File{}
+		for _, other := range files {
+			if other.path != file.path && other.node.Name.Name == file.node.Name.Name {
+				siblings = append(siblings, other.node)
+			}
+		}
+
+		var p *playground
+		p, err = newPlayground(fset, file, pkgScope, inh, siblingInvariants(fset, siblings), &counter)
+		if err != nil {
+			return
+		}
+
+		if p == nil {
+			continue
+		}
+
+		var node *ast.File
+		node, err = parser.ParseFile(fset, p.name, p.code.String(), 0)
+		if err != nil {
+			err = fmt.Errorf("failed to parse the playground generated to type-check the contracts of %s: %s",
+				file.path, err)
+			return
+		}
+
+		playgrounds[p.name] = p
+		nodes = append(nodes, node)
+	}
+
+	if len(playgrounds) == 0 {
+		return
+	}
+
+	conf := types.Config{
+		Importer:    importer.ForCompiler(fset, "source", nil),
+		FakeImportC: true,
+		Error: func(e error) {
+			typeErr, ok := e.(types.Error)
+			if !ok {
+				return
+			}
+
+			raw := fset.PositionFor(typeErr.Pos, false)
+			p, ok := playgrounds[raw.Filename]
+			if !ok {
+				return
+			}
+
+			s, ok := p.snippets[raw.Line]
+			if !ok {
+				return
+			}
+
+			msg := fmt.Sprintf("%s: %s", s.what, typeErr.Msg)
+			if s.inPost && hasUnnamedResults(s.fn) && strings.HasPrefix(typeErr.Msg, "undefined: ") {
+				msg += fmt.Sprintf("; the results of the function %s are unnamed, "+
+					"name them in order to refer to them in the post-conditions", s.fn.Name.Name)
+			}
+
+			pos := fset.Position(typeErr.Pos)
+			pos.Column = 0
+			filename := strings.TrimSuffix(p.name, ".gocontracts")
+			diagnostics[filename] = append(diagnostics[filename], Diagnostic{Position: pos, Message: msg})
+		}}
+
+	// The errors are collected by the callback.
+	_, _ = conf.Check(files[0].node.Name.Name, fset, nodes, nil)
+
+	for _, diags := range diagnostics {
+		sort.SliceStable(diags, func(i, j int) bool { return diags[i].Position.Line < diags[j].Position.Line })
+	}
+
+	return
+}
+
+// typeCheckFiles type-checks the contracts of the given files against the signatures of their functions.
+//
+// The files are grouped by their packages. The files which can not be read or parsed are ignored since
+// the processing reports them anyhow. The diagnostics are mapped by the paths of the files.
+func typeCheckFiles(paths []string) (diagnostics map[string]Diagnostics, err error) {
+	diagnostics = make(map[string]Diagnostics)
+
+	fset := token.NewFileSet()
+
+	packages := make(map[string][]parsedFile)
+	pkgNames := []string{}
+	for _, pth := range paths {
+		data, readErr := ioutil.ReadFile(pth)
+		if readErr != nil {
+			continue
+		}
+
+		text := string(data)
+		node, parseErr := parser.ParseFile(fset, pth, text, parser.ParseComments)
+		if parseErr != nil {
+			continue
+		}
+
+		name := node.Name.Name
+		if _, ok := packages[name]; !ok {
+			pkgNames = append(pkgNames, name)
+		}
+		packages[name] = append(packages[name], parsedFile{path: pth, text: text, node: node})
+	}
+
+	for _, name := range pkgNames {
+		var pkgDiagnostics map[string]Diagnostics
+		pkgDiagnostics, err = typeCheckPackage(fset, packages[name])
+		if err != nil {
+			return
+		}
+
+		for pth, diags := range pkgDiagnostics {
+			diagnostics[pth] = diags
+		}
+	}
+
+	return
+}
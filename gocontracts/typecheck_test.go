@@ -0,0 +1,177 @@
+package gocontracts
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommentLines(t *testing.T) {
+	text := `package somepkg
+
+//go:generate gocontracts -w .
+
+// SomeFunc does something.
+//
+//
+// SomeFunc requires:
+/*  * x > 0
+ */
+//  * x < 10
+//
+func SomeFunc(x int) {}
+`
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, "some.go", text, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	doc := node.Decls[0].(*ast.FuncDecl).Doc
+
+	lines, fileLines := commentLines(fset, doc)
+
+	expected := strings.Split(doc.Text(), "\n")
+	if !reflect.DeepEqual(expected, lines) {
+		t.Fatalf("expected lines %#v, got %#v", expected, lines)
+	}
+
+	expectedFileLines := []int{5, 6, 8, 9, 10, 11, 12}
+	if !reflect.DeepEqual(expectedFileLines, fileLines) {
+		t.Fatalf("expected file lines %#v, got %#v", expectedFileLines, fileLines)
+	}
+}
+
+func TestProcessPackages_TypeCheck(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "typecheck_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	writeTree(t, tmpdir, map[string]string{
+		"somepkg/some.go": `package somepkg
+
+// SomeStruct defines a struct.
+//
+// SomeStruct invariants:
+//  * s.itemz != nil
+type SomeStruct struct {
+	items []int
+}
+
+// Push pushes an item.
+//
+// Push requires:
+//  * x > 0
+//  * y > 0
+//  * x + 1
+//
+// Push preamble:
+//  n := len(s.items)
+//
+// Push ensures:
+//  * len(s.items) == n + 1
+//  * len(s.items) == old(len(s.items)) + 1
+//  * strings.HasPrefix(name(x), "1")
+func (s *SomeStruct) Push(x int) {
+	s.items = append(s.items, x)
+}
+
+// Name gives a name.
+//
+// Name ensures:
+//  * result != ""
+func Name() string {
+	return "some name"
+}
+`,
+		"somepkg/other.go": `package somepkg
+
+import "fmt"
+
+func name(x int) string {
+	return fmt.Sprint(x)
+}
+
+// Valid is correctly contracted.
+//
+// Valid requires:
+//  * v, ok := m[k]; ok && v > 0
+//
+// Valid ensures:
+//  * strings.HasPrefix(result, string(k))
+func Valid[K ~string](m map[K]int, k K) (result string) {
+	return string(k)
+}
+`,
+	})
+
+	pkgDir := filepath.Join(tmpdir, "somepkg")
+	results, err := ProcessPackages([]string{pkgDir}, Options{TypeCheck: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	if results[0].Err != nil {
+		t.Fatalf("expected no error in %s, got: %s", results[0].Path, results[0].Err.Error())
+	}
+
+	someGo := filepath.Join(pkgDir, "some.go")
+	expected := strings.Join([]string{
+		someGo + `:6: invariant "s.itemz != nil": ` +
+			`s.itemz undefined (type *SomeStruct has no field or method itemz)`,
+		someGo + `:15: pre-condition "y > 0": undefined: y`,
+		someGo + `:16: pre-condition "x + 1": non-boolean condition in if statement`,
+		someGo + `:32: post-condition "result != \"\"": undefined: result; the results of the function Name ` +
+			`are unnamed, name them in order to refer to them in the post-conditions`,
+	}, "\n")
+
+	if _, ok := results[1].Err.(Diagnostics); !ok {
+		t.Fatalf("expected diagnostics in %s, got: %#v", results[1].Path, results[1].Err)
+	}
+
+	if results[1].Err.Error() != expected {
+		t.Fatalf("expected diagnostics:\n%s\n\ngot:\n%s", expected, results[1].Err.Error())
+	}
+
+	// Type-checking is skipped when the condition checks are removed.
+	results, err = ProcessPackages([]string{someGo}, Options{TypeCheck: true, Remove: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("expected a single result without error, got %#v", results)
+	}
+
+	// Type-checking is skipped in the disabled files.
+	writeTree(t, tmpdir, map[string]string{
+		"somepkg/some.go": "//gocontracts:disable\n\n" + results[0].Text,
+	})
+
+	results, err = ProcessPackages([]string{someGo}, Options{TypeCheck: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("expected a single result without error in the disabled file, got %#v", results)
+	}
+}
@@ -0,0 +1,76 @@
+package gocontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// zeroValue generates the code of the zero value of the type.
+//
+// The literals are used for the basic types and nil for the pointers, slices, maps, channels, functions and
+// interfaces. Since the underlying types of the other types are unknown without type-checking, their zero
+// values are given as *new(T).
+func zeroValue(fset *token.FileSet, text string, typ ast.Expr) string {
+	switch v := typ.(type) {
+	case *ast.Ident:
+		switch v.Name {
+		case "bool":
+			return "false"
+		case "string":
+			return `""`
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+			"byte", "rune", "float32", "float64", "complex64", "complex128":
+			return "0"
+		case "error", "any":
+			return "nil"
+		}
+
+	case *ast.ParenExpr:
+		return zeroValue(fset, text, v.X)
+
+	case *ast.StarExpr, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
+		return "nil"
+
+	case *ast.ArrayType:
+		if v.Len == nil {
+			// Slice
+			return "nil"
+		}
+	}
+
+	return fmt.Sprintf("*new(%s)", sourceOf(fset, text, typ))
+}
+
+// isErrorType checks whether the type expression refers to the built-in error.
+func isErrorType(typ ast.Expr) bool {
+	ident, ok := typ.(*ast.Ident)
+	return ok && ident.Name == "error"
+}
+
+// zeroResults generates the zero values of all the results of the function except the last one,
+// which needs to be an error.
+func zeroResults(fset *token.FileSet, text string, fn *ast.FuncDecl) (zeros []string, err error) {
+	results := fn.Type.Results
+	if results == nil || len(results.List) == 0 || !isErrorType(results.List[len(results.List)-1].Type) {
+		err = fmt.Errorf("the function %s needs to return an error as its last result "+
+			"in order to return the violated pre-conditions as errors", fn.Name.Name)
+		return
+	}
+
+	for _, field := range results.List {
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+
+		for i := 0; i < n; i++ {
+			zeros = append(zeros, zeroValue(fset, text, field.Type))
+		}
+	}
+
+	// The last result is the error itself.
+	zeros = zeros[:len(zeros)-1]
+	return
+}
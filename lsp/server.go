@@ -0,0 +1,503 @@
+// Package lsp implements a language server for editing the contracts.
+//
+// The server speaks the Language Server Protocol over a pair of streams (usually stdin and stdout). It publishes
+// the problems with the contracts as diagnostics, offers a code action to synchronize the condition checks of
+// a function with its documentation and shows the contract of a function when hovering over its condition checks.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/Parquery/gocontracts/diff"
+	"github.com/Parquery/gocontracts/gocontracts"
+)
+
+// Server serves a single client.
+type Server struct {
+	opts gocontracts.Options
+
+	in  *bufio.Reader
+	out io.Writer
+
+	// docs maps the URIs of the open documents to their content.
+	docs map[string]string
+
+	shutdown bool
+}
+
+// NewServer creates a server reading the messages from in and writing the messages to out.
+//
+// The documents are processed with the given options. The type check is not performed.
+func NewServer(in io.Reader, out io.Writer, opts gocontracts.Options) *Server {
+	return &Server{opts: opts, in: bufio.NewReader(in), out: out, docs: make(map[string]string)}
+}
+
+// Serve handles the messages until the client sends exit.
+//
+// An error is returned if the streams fail or if the client exits without requesting shutdown first.
+func (s *Server) Serve() (err error) {
+	for {
+		var msg Message
+		msg, err = ReadMessage(s.in)
+		if err != nil {
+			if err == io.EOF && s.shutdown {
+				err = nil
+			}
+			return
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				err = fmt.Errorf("the client exited without requesting shutdown")
+			}
+			return
+		}
+
+		err = s.handle(msg)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handle handles a single message. Only the failures to write to the client are returned as errors.
+func (s *Server) handle(msg Message) (err error) {
+	if msg.ID == nil {
+		// Notifications are not answered.
+		return s.notify(msg)
+	}
+
+	var result interface{}
+	var respErr *ResponseError
+
+	switch {
+	case s.shutdown:
+		respErr = &ResponseError{Code: codeInvalidRequest, Message: "the server has been shut down"}
+
+	case msg.Method == "initialize":
+		result = InitializeResult{
+			Capabilities: ServerCapabilities{TextDocumentSync: 1, CodeActionProvider: true, HoverProvider: true},
+			ServerInfo:   ServerInfo{Name: "gocontracts"}}
+
+	case msg.Method == "shutdown":
+		s.shutdown = true
+
+	case msg.Method == "textDocument/codeAction":
+		var params CodeActionParams
+		if respErr = unmarshalParams(msg, &params); respErr == nil {
+			result, respErr = s.codeActions(params)
+		}
+
+	case msg.Method == "textDocument/hover":
+		var params TextDocumentPositionParams
+		if respErr = unmarshalParams(msg, &params); respErr == nil {
+			result, respErr = s.hover(params)
+		}
+
+	default:
+		respErr = &ResponseError{Code: codeMethodNotFound, Message: fmt.Sprintf("unknown method %#v", msg.Method)}
+	}
+
+	resp := Message{ID: msg.ID, Error: respErr}
+	if respErr == nil {
+		resp.Result, err = json.Marshal(result)
+		if err != nil {
+			return
+		}
+	}
+
+	return WriteMessage(s.out, resp)
+}
+
+// unmarshalParams parses the parameters of the request.
+func unmarshalParams(msg Message, params interface{}) *ResponseError {
+	err := json.Unmarshal(msg.Params, params)
+	if err != nil {
+		return &ResponseError{
+			Code: codeInvalidParams, Message: fmt.Sprintf("failed to parse the parameters of %s: %s", msg.Method, err)}
+	}
+
+	return nil
+}
+
+// notify handles a notification. The unknown notifications and the notifications with invalid parameters
+// are ignored as they can not be answered.
+func (s *Server) notify(msg Message) (err error) {
+	switch msg.Method {
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return
+		}
+
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		return s.publishDiagnostics(params.TextDocument.URI)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil || len(params.ContentChanges) == 0 {
+			return
+		}
+
+		// The documents are synchronized in full so that the last change gives the whole content.
+		s.docs[params.TextDocument.URI] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.publishDiagnostics(params.TextDocument.URI)
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if json.Unmarshal(msg.Params, &params) != nil {
+			return
+		}
+
+		delete(s.docs, params.TextDocument.URI)
+
+		// Clear the diagnostics of the closed document.
+		return s.send("textDocument/publishDiagnostics",
+			PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+
+	default:
+		return
+	}
+}
+
+// send sends a notification to the client.
+func (s *Server) send(method string, params interface{}) (err error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+
+	return WriteMessage(s.out, Message{Method: method, Params: data})
+}
+
+// filename converts the URI of the document to the path of the file. If the URI does not refer to a file,
+// the URI itself is used.
+func filename(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+
+	return u.Path
+}
+
+// lineRange spans the whole line of the text. The line starts at 1 as in token.Position.
+func lineRange(text string, line int) Range {
+	lines := strings.Split(text, "\n")
+
+	i := line - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(lines) {
+		i = len(lines) - 1
+	}
+
+	return Range{
+		Start: Position{Line: i},
+		End:   Position{Line: i, Character: len(utf16.Encode([]rune(lines[i])))}}
+}
+
+// offsetOf converts the position to the byte offset in the text. The positions beyond the text are clamped.
+func offsetOf(text string, pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+
+	for units := 0; units < pos.Character && offset < len(text) && text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+
+	return offset
+}
+
+// positionOf converts the byte offset in the text to the position.
+func positionOf(text string, offset int) Position {
+	if offset > len(text) {
+		offset = len(text)
+	}
+
+	line := strings.Count(text[:offset], "\n")
+	lineStart := strings.LastIndex(text[:offset], "\n") + 1
+
+	return Position{Line: line, Character: len(utf16.Encode([]rune(text[lineStart:offset])))}
+}
+
+// diagnose converts the problems with the contracts of the document to diagnostics. The document is processed
+// in the context of the other files of its package.
+//
+// If there are no problems, the functions whose condition checks are out of sync are reported as information.
+func (s *Server) diagnose(uri string) []Diagnostic {
+	text := s.docs[uri]
+	pth := filename(uri)
+
+	diagnostics := []Diagnostic{}
+	for _, d := range gocontracts.DiagnoseInPackage(text, pth, s.opts) {
+		rng := lineRange(text, d.Position.Line)
+		if d.Position.Offset > 0 && d.Position.Column > 0 {
+			rng.Start = positionOf(text, d.Position.Offset)
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Range: rng, Severity: SeverityError, Source: "gocontracts", Message: d.Message})
+	}
+
+	if len(diagnostics) > 0 {
+		return diagnostics
+	}
+
+	_, outOfSync, err := gocontracts.ProcessInPackage(text, pth, s.opts)
+	if err != nil {
+		// Diagnose reports all the problems which can occur while processing.
+		return diagnostics
+	}
+
+	decls := funcDecls(text, pth)
+	for _, name := range outOfSync {
+		span, ok := decls[name]
+		if !ok {
+			continue
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    Range{Start: positionOf(text, span.name), End: positionOf(text, span.nameEnd)},
+			Severity: SeverityInformation,
+			Code:     "out-of-sync",
+			Source:   "gocontracts",
+			Message: fmt.Sprintf("the condition checks of %s do not correspond to the contract in "+
+				"the documentation", name)})
+	}
+
+	return diagnostics
+}
+
+// publishDiagnostics sends the diagnostics of the document to the client.
+func (s *Server) publishDiagnostics(uri string) error {
+	return s.send("textDocument/publishDiagnostics",
+		PublishDiagnosticsParams{URI: uri, Diagnostics: s.diagnose(uri)})
+}
+
+// funcSpan locates a function declaration by byte offsets.
+type funcSpan struct {
+	// start is the start of the documentation or of the declaration if undocumented.
+	start int
+	end   int
+
+	// name and nameEnd locate the name of the function.
+	name    int
+	nameEnd int
+}
+
+// funcDecls locates the function declarations by their names qualified by the receiver types
+// as listed by gocontracts.Check. The function literals assigned to the package-level variables are located by
+// the names of the variables. If the text can not be parsed, there are no declarations.
+func funcDecls(text string, pth string) (decls map[string]funcSpan) {
+	decls = make(map[string]funcSpan)
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, pth, text, parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	span := func(doc *ast.CommentGroup, start token.Pos, end token.Pos, nameIdent *ast.Ident) funcSpan {
+		if doc != nil {
+			start = doc.Pos()
+		}
+
+		return funcSpan{
+			start:   fset.Position(start).Offset,
+			end:     fset.Position(end).Offset,
+			name:    fset.Position(nameIdent.Pos()).Offset,
+			nameEnd: fset.Position(nameIdent.End()).Offset}
+	}
+
+	for _, decl := range node.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if typeName := gocontracts.ReceiverType(d); typeName != "" {
+				name = typeName + "." + name
+			}
+
+			decls[name] = span(d.Doc, d.Pos(), d.End(), d.Name)
+
+		case *ast.GenDecl:
+			if d.Tok != token.VAR {
+				continue
+			}
+
+			for _, spec := range d.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				if len(valueSpec.Names) != 1 || len(valueSpec.Values) != 1 {
+					continue
+				}
+
+				if _, ok := valueSpec.Values[0].(*ast.FuncLit); !ok {
+					continue
+				}
+
+				doc := valueSpec.Doc
+				if doc == nil && !d.Lparen.IsValid() {
+					doc = d.Doc
+				}
+
+				decls[valueSpec.Names[0].Name] = span(doc, valueSpec.Pos(), valueSpec.End(), valueSpec.Names[0])
+			}
+		}
+	}
+
+	return
+}
+
+// codeActions offers to synchronize the condition checks of the functions out of sync in the requested range.
+func (s *Server) codeActions(params CodeActionParams) (actions []CodeAction, respErr *ResponseError) {
+	actions = []CodeAction{}
+
+	uri := params.TextDocument.URI
+	text, ok := s.docs[uri]
+	if !ok {
+		return
+	}
+
+	pth := filename(uri)
+
+	_, outOfSync, err := gocontracts.ProcessInPackage(text, pth, s.opts)
+	if err != nil {
+		// The problems are reported as diagnostics.
+		return
+	}
+
+	start, end := offsetOf(text, params.Range.Start), offsetOf(text, params.Range.End)
+
+	decls := funcDecls(text, pth)
+	for _, name := range outOfSync {
+		span, ok := decls[name]
+		if !ok || span.end < start || span.start > end {
+			continue
+		}
+
+		opts := s.opts
+		opts.Functions = []string{name}
+
+		var updated string
+		updated, _, err = gocontracts.ProcessInPackage(text, pth, opts)
+		if err != nil {
+			respErr = &ResponseError{
+				Code: codeInternalError, Message: fmt.Sprintf("failed to process %s: %s", name, err)}
+			return
+		}
+
+		edits := []TextEdit{}
+		for _, e := range diff.Edits(text, updated) {
+			edits = append(edits, TextEdit{
+				Range:   Range{Start: Position{Line: e.Start}, End: Position{Line: e.End}},
+				NewText: e.NewText})
+		}
+
+		actions = append(actions, CodeAction{
+			Title: fmt.Sprintf("Sync contract checks of %s", name),
+			Kind:  "quickfix",
+			Edit:  &WorkspaceEdit{Changes: map[string][]TextEdit{uri: edits}}})
+	}
+
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Title < actions[j].Title })
+	return
+}
+
+// conditionText formats the condition as written in the documentation.
+func conditionText(c gocontracts.ConditionInfo) string {
+	parts := []string{}
+	if c.Family != "" {
+		parts = append(parts, fmt.Sprintf("[%s] ", c.Family))
+	}
+	if c.Label != "" {
+		parts = append(parts, c.Label+": ")
+	}
+	if c.Quantifier != "" {
+		parts = append(parts, c.Quantifier+": ")
+	}
+	if c.Init != "" {
+		parts = append(parts, c.Init+"; ")
+	}
+	parts = append(parts, c.Condition)
+
+	return strings.Join(parts, "")
+}
+
+// hoverText describes the contract of the function in Markdown.
+func hoverText(fc gocontracts.FunctionContract) string {
+	lines := []string{fmt.Sprintf("**Contract of %s**", fc.Function)}
+
+	sections := []struct {
+		title      string
+		conditions []gocontracts.ConditionInfo
+	}{
+		{title: "Requires", conditions: fc.Requires},
+		{title: "Ensures", conditions: fc.Ensures}}
+
+	for _, section := range sections {
+		if len(section.conditions) == 0 {
+			continue
+		}
+
+		lines = append(lines, "", section.title+":")
+		for _, c := range section.conditions {
+			lines = append(lines, fmt.Sprintf("* `%s` (line %d)", conditionText(c), c.Line))
+		}
+	}
+
+	if fc.Preamble != "" {
+		lines = append(lines, "", "Preamble:", "```go", fc.Preamble, "```")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// hover shows the contract of the function whose condition checks are under the position.
+func (s *Server) hover(params TextDocumentPositionParams) (hover *Hover, respErr *ResponseError) {
+	text, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return
+	}
+
+	extraction, err := gocontracts.Extract(text, filename(params.TextDocument.URI))
+	if err != nil {
+		// The problems are reported as diagnostics.
+		return
+	}
+
+	offset := offsetOf(text, params.Position)
+	for _, fc := range extraction.Functions {
+		if fc.Generated == nil || offset < fc.Generated.Start.Offset || offset >= fc.Generated.End.Offset {
+			continue
+		}
+
+		hover = &Hover{
+			Contents: MarkupContent{Kind: "markdown", Value: hoverText(fc)},
+			Range: &Range{
+				Start: positionOf(text, fc.Generated.Start.Offset),
+				End:   positionOf(text, fc.Generated.End.Offset)}}
+		return
+	}
+
+	return
+}
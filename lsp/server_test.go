@@ -0,0 +1,446 @@
+package lsp_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Parquery/gocontracts/gocontracts"
+	"github.com/Parquery/gocontracts/lsp"
+)
+
+// client scripts the conversation with a server.
+type client struct {
+	t *testing.T
+
+	w      io.WriteCloser
+	nextID int
+
+	// messages sent by the server
+	messages chan lsp.Message
+
+	// notifications received while waiting for a response
+	notifications []lsp.Message
+
+	// served receives the result of the server.
+	served chan error
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, w: clientOut, messages: make(chan lsp.Message, 100), served: make(chan error, 1)}
+
+	go func() {
+		err := lsp.NewServer(serverIn, serverOut, gocontracts.Options{}).Serve()
+		_ = serverOut.Close()
+		c.served <- err
+	}()
+
+	go func() {
+		r := bufio.NewReader(clientIn)
+		for {
+			msg, err := lsp.ReadMessage(r)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+
+			c.messages <- msg
+		}
+	}()
+
+	return c
+}
+
+// receive waits for the next message of the server.
+func (c *client) receive() lsp.Message {
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("The server closed the connection")
+		}
+		return msg
+
+	case <-time.After(10 * time.Second):
+		c.t.Fatal("Timed out waiting for a message of the server")
+	}
+
+	panic("unreachable")
+}
+
+func (c *client) send(msg lsp.Message, params interface{}) {
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			c.t.Fatal(err.Error())
+		}
+		msg.Params = data
+	}
+
+	err := lsp.WriteMessage(c.w, msg)
+	if err != nil {
+		c.t.Fatal(err.Error())
+	}
+}
+
+// request sends a request and waits for its response. The notifications received in the meantime are queued.
+func (c *client) request(method string, params interface{}) lsp.Message {
+	c.nextID++
+	id := json.RawMessage([]byte(strings.TrimSpace(string(mustMarshal(c.t, c.nextID)))))
+
+	c.send(lsp.Message{ID: &id, Method: method}, params)
+
+	for {
+		msg := c.receive()
+		if msg.ID == nil {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+
+		if string(*msg.ID) != string(id) {
+			c.t.Fatalf("Expected a response to the request %s, got %#v", id, msg)
+		}
+
+		return msg
+	}
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.send(lsp.Message{Method: method}, params)
+}
+
+// diagnostics waits for the next diagnostics published by the server.
+func (c *client) diagnostics() lsp.PublishDiagnosticsParams {
+	var msg lsp.Message
+	if len(c.notifications) > 0 {
+		msg, c.notifications = c.notifications[0], c.notifications[1:]
+	} else {
+		msg = c.receive()
+	}
+
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("Expected diagnostics, got %#v", msg)
+	}
+
+	var params lsp.PublishDiagnosticsParams
+	err := json.Unmarshal(msg.Params, &params)
+	if err != nil {
+		c.t.Fatal(err.Error())
+	}
+
+	return params
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return data
+}
+
+// applyEdits applies the line edits to the text. The edits are expected to be sorted and not to overlap.
+func applyEdits(text string, edits []lsp.TextEdit) string {
+	lines := strings.SplitAfter(text, "\n")
+
+	var b strings.Builder
+	line := 0
+	for _, e := range edits {
+		for ; line < e.Range.Start.Line; line++ {
+			b.WriteString(lines[line])
+		}
+
+		b.WriteString(e.NewText)
+		line = e.Range.End.Line
+	}
+
+	for ; line < len(lines); line++ {
+		b.WriteString(lines[line])
+	}
+
+	return b.String()
+}
+
+const uri = "file:///some/path/some.go"
+
+const text = `package somepkg
+
+// SomeFunc does something.
+//
+// SomeFunc requires:
+//  * x > 0
+func SomeFunc(x int) {
+	return
+}
+
+// AnotherFunc does something.
+//
+// AnotherFunc requires:
+//  * x > 0
+func AnotherFunc(x int) {
+	return
+}
+`
+
+func TestServer(t *testing.T) {
+	c := newClient(t)
+
+	resp := c.request("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}})
+
+	var initResult lsp.InitializeResult
+	err := json.Unmarshal(resp.Result, &initResult)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if initResult.Capabilities.TextDocumentSync != 1 || !initResult.Capabilities.CodeActionProvider ||
+		!initResult.Capabilities.HoverProvider {
+		t.Fatalf("Unexpected capabilities: %#v", initResult.Capabilities)
+	}
+
+	c.notify("initialized", map[string]interface{}{})
+
+	////
+	// Out of sync
+	////
+
+	c.notify("textDocument/didOpen", lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, LanguageID: "go", Version: 1, Text: text}})
+
+	diags := c.diagnostics()
+	if diags.URI != uri || len(diags.Diagnostics) != 2 {
+		t.Fatalf("Expected two functions out of sync, got %#v", diags)
+	}
+
+	first := diags.Diagnostics[0]
+	if first.Severity != lsp.SeverityInformation || first.Code != "out-of-sync" ||
+		first.Range != (lsp.Range{Start: lsp.Position{Line: 6, Character: 5}, End: lsp.Position{Line: 6, Character: 13}}) {
+		t.Fatalf("Unexpected diagnostic of SomeFunc: %#v", first)
+	}
+
+	////
+	// Code action
+	////
+
+	resp = c.request("textDocument/codeAction", lsp.CodeActionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Range:        lsp.Range{Start: lsp.Position{Line: 6}, End: lsp.Position{Line: 6, Character: 4}}})
+
+	var actions []lsp.CodeAction
+	err = json.Unmarshal(resp.Result, &actions)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(actions) != 1 || actions[0].Title != "Sync contract checks of SomeFunc" || actions[0].Edit == nil {
+		t.Fatalf("Expected a single code action to sync SomeFunc, got %#v", actions)
+	}
+
+	synced := applyEdits(text, actions[0].Edit.Changes[uri])
+
+	expected, err := gocontracts.ProcessWithOptions(text, "some.go", gocontracts.Options{Functions: []string{"SomeFunc"}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if synced != expected {
+		t.Fatalf("Expected the code action to sync SomeFunc as:\n%s\ngot:\n%s", expected, synced)
+	}
+
+	c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: synced}}})
+
+	diags = c.diagnostics()
+	if len(diags.Diagnostics) != 1 || !strings.Contains(diags.Diagnostics[0].Message, "AnotherFunc") {
+		t.Fatalf("Expected only AnotherFunc out of sync, got %#v", diags)
+	}
+
+	////
+	// Hover
+	////
+
+	// Hover over the check of the pre-condition.
+	resp = c.request("textDocument/hover", lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Position:     lsp.Position{Line: 8, Character: 3}})
+
+	var hover lsp.Hover
+	err = json.Unmarshal(resp.Result, &hover)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if hover.Contents.Kind != "markdown" ||
+		hover.Contents.Value != "**Contract of SomeFunc**\n\nRequires:\n* `x > 0` (line 6)" {
+		t.Fatalf("Unexpected hover: %#v", hover)
+	}
+
+	// Hover outside the checks.
+	resp = c.request("textDocument/hover", lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Position:     lsp.Position{Line: 0, Character: 3}})
+
+	if string(resp.Result) != "null" {
+		t.Fatalf("Expected no hover outside the checks, got %s", resp.Result)
+	}
+
+	////
+	// Errors
+	////
+
+	c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 3},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{
+			{Text: strings.Replace(text, "//  * x > 0\nfunc AnotherFunc", "//  * x >\nfunc AnotherFunc", 1)}}})
+
+	diags = c.diagnostics()
+	if len(diags.Diagnostics) != 1 || diags.Diagnostics[0].Severity != lsp.SeverityError ||
+		diags.Diagnostics[0].Range.Start.Line != 10 {
+		t.Fatalf("Expected an error in the documentation of AnotherFunc, got %#v", diags)
+	}
+
+	resp = c.request("textDocument/unknown", map[string]interface{}{})
+	if resp.Error == nil || resp.Error.Code != -32601 {
+		t.Fatalf("Expected an error for an unknown method, got %#v", resp)
+	}
+
+	c.notify("textDocument/didClose", lsp.DidCloseTextDocumentParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri}})
+
+	diags = c.diagnostics()
+	if len(diags.Diagnostics) != 0 {
+		t.Fatalf("Expected the diagnostics to be cleared on close, got %#v", diags)
+	}
+
+	////
+	// Shutdown
+	////
+
+	resp = c.request("shutdown", nil)
+	if resp.Error != nil || string(resp.Result) != "null" {
+		t.Fatalf("Expected a null result of shutdown, got %#v", resp)
+	}
+
+	c.notify("exit", nil)
+
+	err = <-c.served
+	if err != nil {
+		t.Fatalf("Expected the server to exit cleanly, got %s", err)
+	}
+}
+
+const shapeText = `package somepkg
+
+// Shape defines a geometric shape.
+type Shape interface {
+	// Scale scales the shape.
+	//
+	// Scale requires:
+	//  * factor > 0
+	Scale(factor float64)
+}
+`
+
+const squareText = `package somepkg
+
+// Square is a shape.
+type Square struct {
+	side float64
+}
+
+// Scale scales the square.
+func (sq *Square) Scale(factor float64) {
+	sq.side *= factor
+}
+`
+
+func TestServer_PackageScope(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "server_test-")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() {
+		err = os.RemoveAll(tmpdir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}()
+
+	for name, content := range map[string]string{"shape.go": shapeText, "square.go": squareText} {
+		err = ioutil.WriteFile(filepath.Join(tmpdir, name), []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	squareURI := "file://" + filepath.ToSlash(filepath.Join(tmpdir, "square.go"))
+
+	c := newClient(t)
+	c.request("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}})
+	c.notify("initialized", map[string]interface{}{})
+
+	c.notify("textDocument/didOpen", lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: squareURI, LanguageID: "go", Version: 1, Text: squareText}})
+
+	diags := c.diagnostics()
+	if len(diags.Diagnostics) != 1 || diags.Diagnostics[0].Code != "out-of-sync" {
+		t.Fatalf("Expected Square.Scale out of sync with the inherited pre-condition, got %#v", diags)
+	}
+
+	resp := c.request("textDocument/codeAction", lsp.CodeActionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: squareURI},
+		Range:        lsp.Range{Start: lsp.Position{Line: 8}, End: lsp.Position{Line: 8, Character: 4}}})
+
+	var actions []lsp.CodeAction
+	err = json.Unmarshal(resp.Result, &actions)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(actions) != 1 || actions[0].Edit == nil {
+		t.Fatalf("Expected a single code action to sync Square.Scale, got %#v", actions)
+	}
+
+	synced := applyEdits(squareText, actions[0].Edit.Changes[squareURI])
+	if !strings.Contains(synced, "panic(\"Violated: factor > 0\")") {
+		t.Fatalf("Expected the code action to check the inherited pre-condition, got:\n%s", synced)
+	}
+
+	// The inherited pre-condition is in sync once checked.
+	c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: squareURI, Version: 2},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: synced}}})
+
+	diags = c.diagnostics()
+	if len(diags.Diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics once synced, got %#v", diags)
+	}
+
+	c.request("shutdown", nil)
+	c.notify("exit", nil)
+
+	err = <-c.served
+	if err != nil {
+		t.Fatalf("Expected the server to exit cleanly, got %s", err)
+	}
+}
+
+func TestServer_ExitWithoutShutdown(t *testing.T) {
+	c := newClient(t)
+	c.notify("exit", nil)
+
+	err := <-c.served
+	if err == nil || err.Error() != "the client exited without requesting shutdown" {
+		t.Fatalf("Expected an error on exit without shutdown, got %v", err)
+	}
+}
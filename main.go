@@ -1,9 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"github.com/Parquery/gocontracts/diff"
 	"github.com/Parquery/gocontracts/gocontracts"
+	"github.com/Parquery/gocontracts/lsp"
 	"os"
 )
 
@@ -12,9 +15,50 @@ var inPlace = flag.Bool("w", false, "write result to (source) file instead of st
 var remove = flag.Bool("r", false,
 	"remove the condition checks from the code (but leave them in the comments). "+
 		"This is useful when you want to build a production binary without the checks.")
+var check = flag.Bool("check", false,
+	"do not write anything, but list the functions whose condition checks do not correspond to "+
+		"the contracts in the documentation and exit with a non-zero code if there are any. "+
+		"In combination with -r, list the functions which still contain the condition checks.")
+var showDiff = flag.Bool("d", false, "display diffs instead of printing the processed files to stdout")
+var typeCheck = flag.Bool("typecheck", false,
+	"type-check the conditions and the preambles in the scope of their functions before generating the code. "+
+		"The errors point to the lines of the documentation.")
+var nameResults = flag.Bool("name-results", false,
+	"name the unnamed results of the functions (e.g., as result and err) if their post-conditions refer to them")
+var handlerSpec = flag.String("handler", "",
+	"call the given function with a contracts.ViolationInfo on violations instead of panicking. "+
+		"The function is given as Func (declared in the processed package), pkg.Func or import/path/pkg.Func.")
+var typedPanics = flag.Bool("typed-panics", false,
+	"panic with a *contracts.ViolationError instead of a string on violations "+
+		"(shorthand for -handler "+gocontracts.TypedPanicHandler+")")
+var coverage = flag.Bool("coverage", false,
+	"count the evaluations of the conditions in the generated registry contracts_coverage.go of each package. "+
+		"Dump the counters with contracts.WriteProfileFile and report them with the command coverage.")
+var toggle = flag.Bool("toggle", false,
+	"guard the generated checks by the run-time switches of the contracts package so that the checks can be "+
+		"disabled or narrowed to the pre-conditions without rebuilding by setting the environment variable "+
+		"GOCONTRACTS to off, pre or all (default)")
+var metrics = flag.Bool("metrics", false,
+	"count the violations with contracts.Counted before they are handled. "+
+		"Expose the counts with the package github.com/Parquery/gocontracts/contracts/metrics.")
+var extractJSON = flag.Bool("json", false,
+	"do not process the files, but print the documented contracts as JSON to stdout "+
+		"(conditions, preambles and the positions of the documentation and of the condition checks)")
 
 func usage() {
-	_, err := fmt.Fprintf(os.Stderr, "usage: gocontracts [flags] [path]\n")
+	_, err := fmt.Fprintf(os.Stderr, "usage: gocontracts [flags] [path ...]\n"+
+		"       gocontracts [flags] lsp\n"+
+		"       gocontracts [flags] gentest [path ...]\n"+
+		"       gocontracts coverage profile [profile ...]\n\n"+
+		"A path is either a Go file, a package directory or a directory followed by \"/...\"\n"+
+		"to process all the packages in the directory tree (e.g., \"./...\").\n\n"+
+		"The command lsp serves the Language Server Protocol over stdin and stdout.\n"+
+		"The command gentest generates the property-based tests and the fuzz targets of the functions\n"+
+		"with post-conditions "+
+		"as {file}_contracts_test.go; the flags -w, -d and -check apply.\n"+
+		"The command coverage reports how often the conditions instrumented with -coverage were evaluated\n"+
+		"according to the given profiles.\n"+
+		"Refer to directories named lsp, gentest or coverage as ./lsp, ./gentest or ./coverage, respectively.\n\n")
 	if err != nil {
 		panic(err.Error())
 	}
@@ -24,6 +68,7 @@ func usage() {
 
 func main() {
 	os.Exit(func() (retcode int) {
+		flag.Usage = usage
 		flag.Parse()
 
 		if *version {
@@ -31,9 +76,9 @@ func main() {
 			return 0
 		}
 
-		if flag.NArg() != 1 {
-			_, err := fmt.Fprintf(os.Stderr, "Expected the path to the file as a single positional argument, "+
-				"but got positional %d argument(s)\n", flag.NArg())
+		if flag.NArg() == 0 {
+			_, err := fmt.Fprintf(os.Stderr, "Expected at least one path as a positional argument, "+
+				"but got none\n")
 
 			if err != nil {
 				panic(err.Error())
@@ -44,33 +89,234 @@ func main() {
 			return
 		}
 
-		pth := flag.Arg(0)
+		if *check && (*inPlace || *showDiff) {
+			_, err := fmt.Fprintf(os.Stderr, "The flag -check can not be combined with -w or -d\n")
+			if err != nil {
+				panic(err.Error())
+			}
+
+			return 1
+		}
+
+		if *extractJSON && (*inPlace || *showDiff || *check || *remove) {
+			_, err := fmt.Fprintf(os.Stderr, "The flag -json can not be combined with -w, -d, -check or -r\n")
+			if err != nil {
+				panic(err.Error())
+			}
+
+			return 1
+		}
+
+		if *extractJSON {
+			return extract(flag.Args())
+		}
+
+		if flag.Arg(0) == "coverage" {
+			return reportCoverage(flag.Args()[1:])
+		}
+
+		if *typedPanics && *handlerSpec != "" {
+			_, err := fmt.Fprintf(os.Stderr, "The flag -typed-panics can not be combined with -handler\n")
+			if err != nil {
+				panic(err.Error())
+			}
+
+			return 1
+		}
+
+		if *typedPanics {
+			*handlerSpec = gocontracts.TypedPanicHandler
+		}
+
+		opts := gocontracts.Options{
+			Remove:      *remove,
+			TypeCheck:   *typeCheck,
+			NameResults: *nameResults,
+			Handler:     *handlerSpec,
+			Coverage:    *coverage,
+			Toggle:      *toggle,
+			Metrics:     *metrics}
 
-		if *inPlace {
-			err := gocontracts.ProcessInPlace(pth, *remove)
+		if flag.NArg() == 1 && flag.Arg(0) == "lsp" {
+			err := lsp.NewServer(os.Stdin, os.Stdout, opts).Serve()
 			if err != nil {
-				_, err = fmt.Fprintf(os.Stderr, err.Error())
+				_, err = fmt.Fprintln(os.Stderr, err.Error())
 				if err != nil {
 					panic(err.Error())
 				}
 				return 1
 			}
-		} else {
-			updated, err := gocontracts.ProcessFile(pth, *remove)
-			if err != nil {
-				_, err = fmt.Fprintf(os.Stderr, err.Error())
+
+			return 0
+		}
+
+		var results []gocontracts.Result
+		var err error
+		if flag.Arg(0) == "gentest" {
+			if *remove {
+				_, err = fmt.Fprintf(os.Stderr, "The command gentest can not be combined with -r\n")
 				if err != nil {
 					panic(err.Error())
 				}
+
 				return 1
 			}
 
-			_, err = fmt.Fprint(os.Stdout, updated)
+			results, err = gocontracts.GenerateTestPackages(flag.Args()[1:])
+		} else {
+			results, err = gocontracts.ProcessPackages(flag.Args(), opts)
+		}
+
+		if err != nil {
+			_, err = fmt.Fprintln(os.Stderr, err.Error())
 			if err != nil {
 				panic(err.Error())
 			}
+			return 1
 		}
 
-		return 0
+		for _, result := range results {
+			if diagnostics, ok := result.Err.(gocontracts.Diagnostics); ok {
+				// Diagnostics already point to the positions in the file.
+				_, err = fmt.Fprintln(os.Stderr, diagnostics.Error())
+				if err != nil {
+					panic(err.Error())
+				}
+
+				retcode = 1
+				continue
+			}
+
+			if result.Err != nil {
+				_, err = fmt.Fprintf(os.Stderr, "%s: %s\n", result.Path, result.Err.Error())
+				if err != nil {
+					panic(err.Error())
+				}
+
+				// Report the errors of the other files as well.
+				retcode = 1
+				continue
+			}
+
+			switch {
+			case *check:
+				for _, name := range result.OutOfSync {
+					_, err = fmt.Fprintf(os.Stdout, "%s: %s\n", result.Path, name)
+					if err != nil {
+						panic(err.Error())
+					}
+				}
+
+				if result.Changed() {
+					if len(result.OutOfSync) == 0 {
+						_, err = fmt.Fprintln(os.Stdout, result.Path)
+						if err != nil {
+							panic(err.Error())
+						}
+					}
+
+					retcode = 1
+				}
+
+			case *showDiff || *inPlace:
+				if *showDiff {
+					_, err = fmt.Fprint(os.Stdout,
+						diff.Unified(result.Path+".orig", result.Path, result.Text, result.Updated))
+					if err != nil {
+						panic(err.Error())
+					}
+				}
+
+				if *inPlace && result.Changed() {
+					err = gocontracts.WriteFile(result.Path, result.Updated)
+					if err != nil {
+						_, err = fmt.Fprintf(os.Stderr, "%s: %s\n", result.Path, err.Error())
+						if err != nil {
+							panic(err.Error())
+						}
+						retcode = 1
+					}
+				}
+
+			case result.Generated:
+				// Only the processed files are printed so that the output remains a single Go file.
+				if result.Changed() {
+					_, err = fmt.Fprintf(os.Stderr, "%s: not written; run with -w to generate it\n", result.Path)
+					if err != nil {
+						panic(err.Error())
+					}
+				}
+
+			default:
+				_, err = fmt.Fprint(os.Stdout, result.Updated)
+				if err != nil {
+					panic(err.Error())
+				}
+			}
+		}
+
+		return
 	}())
 }
+
+// extract prints the contracts of the files given as patterns as JSON and returns the exit code.
+func extract(patterns []string) (retcode int) {
+	extractions, err := gocontracts.ExtractPackages(patterns)
+	if err != nil {
+		_, err = fmt.Fprintln(os.Stderr, err.Error())
+		if err != nil {
+			panic(err.Error())
+		}
+		return 1
+	}
+
+	for _, extraction := range extractions {
+		if extraction.Error != "" {
+			_, err = fmt.Fprintf(os.Stderr, "%s: %s\n", extraction.Path, extraction.Error)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			// The extraction of the other files is printed nevertheless.
+			retcode = 1
+		}
+	}
+
+	// The conditions are more readable without escaping the HTML characters such as ">".
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(extractions)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return
+}
+
+// reportCoverage prints the coverage of the conditions according to the given profiles and returns the exit code.
+func reportCoverage(profiles []string) (retcode int) {
+	if len(profiles) == 0 {
+		_, err := fmt.Fprintf(os.Stderr, "Expected at least one profile to the command coverage, but got none\n")
+		if err != nil {
+			panic(err.Error())
+		}
+		return 1
+	}
+
+	counters, err := gocontracts.ReadCoverageProfiles(profiles)
+	if err == nil {
+		err = gocontracts.ReportCoverage(os.Stdout, counters)
+	}
+
+	if err != nil {
+		_, err = fmt.Fprintln(os.Stderr, err.Error())
+		if err != nil {
+			panic(err.Error())
+		}
+		return 1
+	}
+
+	return 0
+}
@@ -90,7 +90,7 @@ func SomeFunc(x int, y int) (result string, err error) {
 }`
 
 	checkFailure(t, text,
-		"expected a 'switch' statement after the comment \"Pre-conditions\" in function SomeFunc on line 5")
+		"expected a 'switch' statement or a block after the comment \"Pre-conditions\" in function SomeFunc on line 5")
 }
 
 func TestToContract_NoDeferInPostcondition(t *testing.T) {
@@ -123,7 +123,7 @@ func SomeFunc(x int, y int) (result string, err error) {
 }`
 
 	checkFailure(t, text,
-		"expected an 'if' statement after the comment \"Pre-condition\" in function SomeFunc on line 5")
+		"expected an 'if' or a 'for' statement after the comment \"Pre-condition\" in function SomeFunc on line 5")
 }
 
 func TestToContract_NoStatementAfterPrecondition(t *testing.T) {
@@ -312,3 +312,15 @@ func SomeFunc(x int, y int) (result string, err error) {
 	checkFailure(t, text,
 		"duplicate post-condition block found in function SomeFunc on line 11")
 }
+
+func TestToContract_NoDeferInInvariantsOnExit(t *testing.T) {
+	text := `package dummy
+
+func (s *SomeStruct) SomeFunc(x int) {
+	// Invariant on exit
+	panic("hello")
+}`
+
+	checkFailure(t, text,
+		"expected a defer statement after the comment \"Invariant on exit\" in function SomeFunc on line 5")
+}
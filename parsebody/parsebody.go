@@ -6,6 +6,7 @@ import (
 	"go/ast"
 	"go/token"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -19,9 +20,132 @@ type section struct {
 	end token.Pos
 }
 
-// parsePreconditions parses the pre-conditions defined in the function body.
-func parsePreconditions(
-	fset *token.FileSet, fn *ast.FuncDecl, cmtGrp *ast.CommentGroup) (s section, err error) {
+// levelVarRe matches the name of the variable holding the level of the checks. The name is suffixed with
+// a number if the function already uses it.
+var levelVarRe = regexp.MustCompile(`^contractsLevel[0-9]*$`)
+
+// sampledVarRe matches the name of the variable holding the decision whether the conditions are checked on
+// the call. The name is suffixed with a number if the function already uses it.
+var sampledVarRe = regexp.MustCompile(`^sampled[0-9]*$`)
+
+// samplerVarRe matches the name of the variable holding the sampler of the function.
+var samplerVarRe = regexp.MustCompile(`^contractsSample_[a-zA-Z_0-9]+$`)
+
+// isGuard checks whether the expression compares the level of the checks with a level of the contracts package
+// such as "contractsLevel >= contracts.LevelPre", tests the sampling decision or combines both with "&&".
+func isGuard(expr ast.Expr) bool {
+	if ident, ok := expr.(*ast.Ident); ok {
+		return sampledVarRe.MatchString(ident.Name)
+	}
+
+	binExpr, ok := expr.(*ast.BinaryExpr)
+	if ok && binExpr.Op == token.LAND {
+		return isGuard(binExpr.X) && isGuard(binExpr.Y)
+	}
+
+	if !ok || binExpr.Op != token.GEQ {
+		return false
+	}
+
+	ident, ok := binExpr.X.(*ast.Ident)
+	if !ok || !levelVarRe.MatchString(ident.Name) {
+		return false
+	}
+
+	sel, ok := binExpr.Y.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "contracts" && strings.HasPrefix(sel.Sel.Name, "Level")
+}
+
+// guardedStmts gives the statements guarded by the level of the checks or the sampling such as
+// "if contractsLevel >= contracts.LevelPre { ... }" or "if sampled { ... }", or nil if the statement is
+// not guarded.
+func guardedStmts(stmt ast.Stmt) []ast.Stmt {
+	ifStmt, ok := stmt.(*ast.IfStmt)
+	if !ok || ifStmt.Init != nil || ifStmt.Else != nil || !isGuard(ifStmt.Cond) {
+		return nil
+	}
+
+	return ifStmt.Body.List
+}
+
+// isLevelDecl checks whether the statement loads the level of the checks as generated
+// (e.g., "contractsLevel := contracts.CurrentLevel()").
+func isLevelDecl(stmt ast.Stmt) bool {
+	assignStmt, ok := stmt.(*ast.AssignStmt)
+	if !ok || assignStmt.Tok != token.DEFINE || len(assignStmt.Lhs) != 1 || len(assignStmt.Rhs) != 1 {
+		return false
+	}
+
+	ident, ok := assignStmt.Lhs[0].(*ast.Ident)
+	if !ok || !levelVarRe.MatchString(ident.Name) {
+		return false
+	}
+
+	call, ok := assignStmt.Rhs[0].(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "CurrentLevel" {
+		return false
+	}
+
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "contracts"
+}
+
+// isSamplingDecl checks whether the statement decides on the sampling as generated
+// (e.g., "sampled := contractsSample_SomeFunc.Sample()").
+func isSamplingDecl(stmt ast.Stmt) bool {
+	assignStmt, ok := stmt.(*ast.AssignStmt)
+	if !ok || assignStmt.Tok != token.DEFINE || len(assignStmt.Lhs) != 1 || len(assignStmt.Rhs) != 1 {
+		return false
+	}
+
+	ident, ok := assignStmt.Lhs[0].(*ast.Ident)
+	if !ok || !sampledVarRe.MatchString(ident.Name) {
+		return false
+	}
+
+	call, ok := assignStmt.Rhs[0].(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Sample" {
+		return false
+	}
+
+	sampler, ok := sel.X.(*ast.Ident)
+	return ok && samplerVarRe.MatchString(sampler.Name)
+}
+
+// unguarded gives the single statement guarded by the level of the checks or the sampling, or nil if
+// the statement is not guarded.
+func unguarded(stmt ast.Stmt) ast.Stmt {
+	stmts := guardedStmts(stmt)
+	if len(stmts) != 1 {
+		return nil
+	}
+
+	return stmts[0]
+}
+
+// parseChecks parses a block of condition checks (e.g., pre-conditions) defined in the function body.
+//
+// If the comment introduces multiple conditions (plural), a 'switch' statement is expected after the comment.
+// Otherwise, an 'if' statement is expected. Since the quantified conditions are checked in loops,
+// a block is also accepted for multiple conditions and a 'for' statement for a single condition.
+// The statement can be guarded by the level of the checks or the sampling.
+func parseChecks(
+	fset *token.FileSet, fn *ast.FuncDecl, cmtGrp *ast.CommentGroup, plural bool) (s section, err error) {
 
 	s.start = cmtGrp.Pos()
 
@@ -42,29 +166,37 @@ func parsePreconditions(
 		return
 	}
 
-	switch {
-	case strings.HasPrefix(cmtText, "Pre-conditions"):
-		// Expect multiple pre-conditions given the comment and hence a switch
-		_, ok := stmtAfterCmt.(*ast.SwitchStmt)
+	if plural {
+		// Expect multiple conditions given the comment and hence a switch or a block
+		isChecks := func(stmt ast.Stmt) bool {
+			switch stmt.(type) {
+			case *ast.SwitchStmt, *ast.BlockStmt:
+				return true
+			default:
+				return false
+			}
+		}
 
-		if !ok {
+		if !isChecks(stmtAfterCmt) && !isChecks(unguarded(stmtAfterCmt)) {
 			err = fmt.Errorf(
-				"expected a 'switch' statement after the comment %#v in function %s on line %d",
+				"expected a 'switch' statement or a block after the comment %#v in function %s on line %d",
 				cmtText, fn.Name.String(), fset.Position(stmtAfterCmt.Pos()).Line)
 			return
 		}
+	} else {
+		// Expect a single condition given the comment and hence an if or a for
+		var ok bool
+		switch stmtAfterCmt.(type) {
+		case *ast.IfStmt, *ast.RangeStmt:
+			ok = true
+		}
 
-	case strings.HasPrefix(cmtText, "Pre-condition"):
-		// Expect a single pre-condition given the comment and hence a
-		_, ok := stmtAfterCmt.(*ast.IfStmt)
 		if !ok {
 			err = fmt.Errorf(
-				"expected an 'if' statement after the comment %#v in function %s on line %d",
+				"expected an 'if' or a 'for' statement after the comment %#v in function %s on line %d",
 				cmtText, fn.Name.String(), fset.Position(stmtAfterCmt.Pos()).Line)
 			return
 		}
-	default:
-		panic(fmt.Sprintf("Unhandled comment text: %#v", cmtText))
 	}
 
 	s.end = stmtAfterCmt.End()
@@ -72,9 +204,12 @@ func parsePreconditions(
 	return
 }
 
-// parsePostconditions parses the post-conditions defined in the function body.
-func parsePostconditions(
-	fset *token.FileSet, fn *ast.FuncDecl, cmtGrp *ast.CommentGroup) (s section, err error) {
+// parseDeferredChecks parses a deferred block of condition checks (e.g., post-conditions) defined
+// in the function body. The defer statement can be guarded by the level of the checks or the sampling.
+// The guarded block can precede the defer statement with the preamble and the capturing of the old values
+// which are given as the folded section.
+func parseDeferredChecks(
+	fset *token.FileSet, fn *ast.FuncDecl, cmtGrp *ast.CommentGroup) (s section, folded section, err error) {
 	s.start = cmtGrp.Pos()
 
 	cmtText := strings.Trim(cmtGrp.Text(), "\n \t")
@@ -94,14 +229,62 @@ func parsePostconditions(
 		return
 	}
 
-	deferStmt, ok := stmtAfterCmt.(*ast.DeferStmt)
+	_, ok := stmtAfterCmt.(*ast.DeferStmt)
+	if stmts := guardedStmts(stmtAfterCmt); !ok && len(stmts) > 0 {
+		last := stmts[len(stmts)-1]
+		_, ok = last.(*ast.DeferStmt)
+
+		if ok && len(stmts) > 1 {
+			folded = section{start: stmtAfterCmt.(*ast.IfStmt).Body.Lbrace, end: last.Pos()}
+		}
+	}
+
 	if !ok {
 		err = fmt.Errorf("expected a defer statement after the comment %#v in function %s on line %d",
 			cmtText, fn.Name.String(), fset.Position(stmtAfterCmt.Pos()).Line)
 		return
 	}
 
-	s.end = deferStmt.End()
+	s.end = stmtAfterCmt.End()
+	return
+}
+
+// oldVarRe matches the names of the variables holding the old values.
+var oldVarRe = regexp.MustCompile(`^old[0-9]+$`)
+
+// isSnapshot checks that the statement is a short variable declaration of the old values
+// as generated by gocontracts.
+func isSnapshot(stmt ast.Stmt) bool {
+	assignStmt, ok := stmt.(*ast.AssignStmt)
+	if !ok || assignStmt.Tok != token.DEFINE {
+		return false
+	}
+
+	for _, lhs := range assignStmt.Lhs {
+		ident, ok := lhs.(*ast.Ident)
+		if !ok || !oldVarRe.MatchString(ident.Name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseDeclaration parses the block of a single generated declaration in the function body such as capturing
+// the old values, loading the level of the checks or deciding on the sampling. If the statement following
+// the comment is not the expected declaration, the comment belongs to the code of the function and
+// the returned section is empty.
+func parseDeclaration(fn *ast.FuncDecl, cmtGrp *ast.CommentGroup, expected func(ast.Stmt) bool) (s section) {
+	for _, stmt := range fn.Body.List {
+		if stmt.Pos() > cmtGrp.Pos() {
+			if expected(stmt) {
+				s = section{start: cmtGrp.Pos(), end: stmt.End()}
+			}
+
+			break
+		}
+	}
+
 	return
 }
 
@@ -133,18 +316,56 @@ func validatePreambleSection(fset *token.FileSet, fn *ast.FuncDecl, preamble sec
 }
 
 type parsedPositions struct {
+	// Level of the checks loaded on entry
+	level section
+
+	// Decision whether the conditions are checked on the call
+	sampling section
+
 	// Pre-conditions
 	pre section
 
+	// Invariants checked on entry of the method
+	invEntry section
+
+	// Invariants checked on exit of the method
+	invExit section
+
 	preamble section
 
+	// Snapshot of the old values
+	snapshot section
+
 	// Post-conditions
 	post section
+
+	// Preamble and old values guarded together with the post-conditions
+	folded section
+}
+
+// sections lists the sections which appear in the function sorted by their start.
+func (p parsedPositions) sections() (sections []section) {
+	sections = make([]section, 0, 8)
+
+	for _, s := range []section{p.level, p.sampling, p.pre, p.invEntry, p.invExit, p.preamble, p.snapshot, p.post} {
+		if s.start != token.NoPos {
+			sections = append(sections, s)
+		}
+	}
+
+	sort.Slice(sections, func(i, j int) bool { return sections[i].start < sections[j].start })
+
+	return
 }
 
-var preconditionRe = regexp.MustCompile(`^(Precondition|Pre-condition)s?\s*:?\s*$`)
+var levelRe = regexp.MustCompile(`^Level\s+of\s+the\s+checks\s*:?\s*$`)
+var samplingRe = regexp.MustCompile(`^Sampling\s*:?\s*$`)
+var preconditionRe = regexp.MustCompile(`^(Precondition|Pre-condition)(s?)(\s*\(error\))?\s*:?\s*$`)
+var invariantsOnEntryRe = regexp.MustCompile(`^Invariant(s?)\s+on\s+entry\s*:?\s*$`)
+var invariantsOnExitRe = regexp.MustCompile(`^Invariants?\s+on\s+exit\s*:?\s*$`)
 var preambleStartsRe = regexp.MustCompile(`^Preamble\s+starts.?\s*$`)
 var preambleEndsRe = regexp.MustCompile(`^Preamble\s+ends.?\s*$`)
+var snapshotRe = regexp.MustCompile(`^Old\s+values?\s*:?\s*$`)
 var postconditionRe = regexp.MustCompile(`^(Postcondition|Post-condition)s?\s*:?\s*$`)
 
 // parseContract parses the contract blocks from the function body.
@@ -154,9 +375,43 @@ func parseContract(
 	bodyCmtMap ast.CommentMap) (p parsedPositions, err error) {
 
 	for _, cmtGrp := range bodyCmtMap.Comments() {
+		// The comments of the preamble and the old values guarded together with the post-conditions
+		// belong to the block of post-conditions.
+		if p.folded.start != token.NoPos && cmtGrp.Pos() > p.folded.start && cmtGrp.End() <= p.folded.end {
+			continue
+		}
+
 		cmtText := strings.Trim(cmtGrp.Text(), "\n \t")
 
 		switch {
+		case levelRe.MatchString(cmtText):
+			level := parseDeclaration(fn, cmtGrp, isLevelDecl)
+			if level.start == token.NoPos {
+				continue
+			}
+
+			if p.level.start != token.NoPos {
+				err = fmt.Errorf("duplicate block loading the level of the checks found in function %s on line %d",
+					fn.Name.String(), fset.Position(cmtGrp.Pos()).Line)
+				return
+			}
+
+			p.level = level
+
+		case samplingRe.MatchString(cmtText):
+			sampling := parseDeclaration(fn, cmtGrp, isSamplingDecl)
+			if sampling.start == token.NoPos {
+				break
+			}
+
+			if p.sampling.start != token.NoPos {
+				err = fmt.Errorf("duplicate sampling block found in function %s on line %d",
+					fn.Name.String(), fset.Position(cmtGrp.Pos()).Line)
+				return
+			}
+
+			p.sampling = sampling
+
 		case preconditionRe.MatchString(cmtText):
 			if p.pre.start != token.NoPos {
 				err = fmt.Errorf("duplicate pre-condition block found in function %s on line %d",
@@ -164,7 +419,35 @@ func parseContract(
 				return
 			}
 
-			p.pre, err = parsePreconditions(fset, fn, cmtGrp)
+			plural := preconditionRe.FindStringSubmatch(cmtText)[2] == "s"
+
+			p.pre, err = parseChecks(fset, fn, cmtGrp, plural)
+			if err != nil {
+				return
+			}
+
+		case invariantsOnEntryRe.MatchString(cmtText):
+			if p.invEntry.start != token.NoPos {
+				err = fmt.Errorf("duplicate block of invariants on entry found in function %s on line %d",
+					fn.Name.String(), fset.Position(cmtGrp.Pos()).Line)
+				return
+			}
+
+			plural := invariantsOnEntryRe.FindStringSubmatch(cmtText)[1] == "s"
+
+			p.invEntry, err = parseChecks(fset, fn, cmtGrp, plural)
+			if err != nil {
+				return
+			}
+
+		case invariantsOnExitRe.MatchString(cmtText):
+			if p.invExit.start != token.NoPos {
+				err = fmt.Errorf("duplicate block of invariants on exit found in function %s on line %d",
+					fn.Name.String(), fset.Position(cmtGrp.Pos()).Line)
+				return
+			}
+
+			p.invExit, _, err = parseDeferredChecks(fset, fn, cmtGrp)
 			if err != nil {
 				return
 			}
@@ -203,6 +486,20 @@ func parseContract(
 
 			p.preamble.end = cmtGrp.Pos()
 
+		case snapshotRe.MatchString(cmtText):
+			snapshot := parseDeclaration(fn, cmtGrp, isSnapshot)
+			if snapshot.start == token.NoPos {
+				continue
+			}
+
+			if p.snapshot.start != token.NoPos {
+				err = fmt.Errorf("duplicate block of old values found in function %s on line %d",
+					fn.Name.String(), fset.Position(cmtGrp.Pos()).Line)
+				return
+			}
+
+			p.snapshot = snapshot
+
 		case postconditionRe.MatchString(cmtText):
 			if p.post.start != token.NoPos {
 				err = fmt.Errorf("duplicate post-condition block found in function %s on line %d",
@@ -210,7 +507,7 @@ func parseContract(
 				return
 			}
 
-			p.post, err = parsePostconditions(fset, fn, cmtGrp)
+			p.post, p.folded, err = parseDeferredChecks(fset, fn, cmtGrp)
 			if err != nil {
 				return
 			}
@@ -220,6 +517,15 @@ func parseContract(
 		}
 	}
 
+	// The old values, the level of the checks and the sampling decision only serve the checks so that they
+	// belong to the code of a function without them.
+	if p.pre.start == token.NoPos && p.invEntry.start == token.NoPos && p.invExit.start == token.NoPos &&
+		p.post.start == token.NoPos {
+		p.snapshot = section{}
+		p.level = section{}
+		p.sampling = section{}
+	}
+
 	err = validatePreambleSection(fset, fn, p.preamble)
 	if err != nil {
 		return
@@ -252,16 +558,7 @@ func findNextNodePos(bodyCmtMap ast.CommentMap, body *ast.BlockStmt, contractEnd
 }
 
 func validateNoBlockOverlap(fset *token.FileSet, fn *ast.FuncDecl, p parsedPositions) (err error) {
-	sections := make([]section, 0, 3)
-	if p.pre.start != token.NoPos {
-		sections = append(sections, p.pre)
-	}
-	if p.preamble.start != token.NoPos {
-		sections = append(sections, p.preamble)
-	}
-	if p.post.start != token.NoPos {
-		sections = append(sections, p.post)
-	}
+	sections := p.sections()
 
 	if len(sections) > 1 {
 		// Quadratic time complexity is fine as long as there are few sections.
@@ -282,28 +579,13 @@ func validateNoBlockOverlap(fset *token.FileSet, fn *ast.FuncDecl, p parsedPosit
 }
 
 func (p parsedPositions) asSection() (s section) {
-	if p.pre.start != token.NoPos {
-		s.start = p.pre.start
-		s.end = p.pre.end
-	}
-
-	if p.preamble.start != token.NoPos {
-		if s.start == token.NoPos || s.start > p.preamble.start {
-			s.start = p.preamble.start
+	for _, other := range p.sections() {
+		if s.start == token.NoPos || s.start > other.start {
+			s.start = other.start
 		}
 
-		if s.end == token.NoPos || s.end < p.preamble.end {
-			s.end = p.preamble.end
-		}
-	}
-
-	if p.post.start != token.NoPos {
-		if s.start == token.NoPos || s.start > p.post.start {
-			s.start = p.post.start
-		}
-
-		if s.end == token.NoPos || s.end < p.post.end {
-			s.end = p.post.end
+		if s.end == token.NoPos || s.end < other.end {
+			s.end = other.end
 		}
 	}
 
@@ -324,16 +606,7 @@ func validateNoStmts(fset *token.FileSet, fn *ast.FuncDecl, p parsedPositions) (
 	}
 
 	// Check that there are no statements between the blocks
-	sections := make([]section, 0, 3)
-	if p.pre.start != token.NoPos {
-		sections = append(sections, p.pre)
-	}
-	if p.preamble.start != token.NoPos {
-		sections = append(sections, p.preamble)
-	}
-	if p.post.start != token.NoPos {
-		sections = append(sections, p.post)
-	}
+	sections := p.sections()
 
 	if len(sections) > 1 {
 		for i := 1; i < len(sections); i++ {
@@ -367,6 +640,14 @@ type Contract struct {
 	NextNodePos token.Pos
 }
 
+// LiteralDecl represents the function literal assigned to the variable (e.g., "var Parse = func(s string) int {...}")
+// as a function declaration named after the variable so that the contract in its body can be searched with ToContract.
+//
+// The declaration shares the signature and the body of the literal.
+func LiteralDecl(name *ast.Ident, doc *ast.CommentGroup, lit *ast.FuncLit) *ast.FuncDecl {
+	return &ast.FuncDecl{Doc: doc, Name: name, Type: lit.Type, Body: lit.Body}
+}
+
 // ToContract searches for the start and end of the contract in the function body.
 func ToContract(
 	fset *token.FileSet, fn *ast.FuncDecl, bodyCmtMap ast.CommentMap) (c Contract, err error) {
@@ -0,0 +1,152 @@
+package parsebody_test
+
+import (
+	"testing"
+
+	"github.com/Parquery/gocontracts/parsebody"
+)
+
+func TestToContract_Guarded_WithNextOnSeparateLine(t *testing.T) {
+	text := `package dummy
+
+func (s *SomeStruct) SomeFunc(x int) {
+	// Level of the checks
+	contractsLevel := contracts.CurrentLevel()
+
+	// Pre-conditions
+	if contractsLevel >= contracts.LevelPre {
+		switch {
+		case !(x > 0):
+			panic("Violated: x > 0")
+		case !(x < 100):
+			panic("Violated: x < 100")
+		default:
+			// Pass
+		}
+	}
+
+	// Post-condition
+	if contractsLevel >= contracts.LevelAll {
+		defer func() {
+			if !(s.x > 0) {
+				panic("Violated: s.x > 0")
+			}
+		}()
+	}
+
+	return
+}`
+
+	expected := parsebody.Contract{Start: 56, End: 463, NextNodePos: 466}
+	checkContract(t, text, expected)
+}
+
+func TestToContract_Toggled_WithFoldedPostconditions(t *testing.T) {
+	text := `package dummy
+
+func SomeFunc(x int) {
+	// Level of the checks
+	contractsLevel := contracts.CurrentLevel()
+
+	// Post-condition
+	if contractsLevel >= contracts.LevelAll {
+		// Preamble starts.
+		y := x
+		// Preamble ends.
+
+		// Old values
+		old1 := x
+
+		defer func() {
+			if !(x > old1+y) {
+				panic("Violated: x > old(x)+y")
+			}
+		}()
+	}
+
+	x++
+}`
+
+	expected := parsebody.Contract{Start: 40, End: 339, NextNodePos: 342}
+	checkContract(t, text, expected)
+}
+
+func TestToContract_Toggled_ShadowedLevel(t *testing.T) {
+	text := `package dummy
+
+func SomeFunc(contractsLevel int) {
+	// Level of the checks
+	contractsLevel1 := contracts.CurrentLevel()
+
+	// Pre-condition
+	if contractsLevel1 >= contracts.LevelPre {
+		if !(contractsLevel > 0) {
+			panic("Violated: contractsLevel > 0")
+		}
+	}
+}`
+
+	expected := parsebody.Contract{Start: 53, End: 260}
+	checkContract(t, text, expected)
+}
+
+func TestToContract_LevelOfTheChecksInCode(t *testing.T) {
+	text := `package dummy
+
+func SomeFunc(x int) {
+	// Level of the checks
+	level := x / 10
+
+	validate(level)
+}`
+
+	expected := parsebody.Contract{NextNodePos: 40}
+	checkContract(t, text, expected)
+}
+
+func TestToContract_LevelWithoutChecks(t *testing.T) {
+	text := `package dummy
+
+func SomeFunc(x int) {
+	// Level of the checks
+	contractsLevel := contracts.CurrentLevel()
+
+	report(contractsLevel)
+}`
+
+	expected := parsebody.Contract{NextNodePos: 40}
+	checkContract(t, text, expected)
+}
+
+func TestToContract_Sampled_NoNext(t *testing.T) {
+	text := `package dummy
+
+func SomeFunc(x int) {
+	// Sampling
+	sampled := contractsSample_SomeFunc.Sample()
+
+	// Pre-condition
+	if sampled {
+		if !(x > 0) {
+			panic("Violated: x > 0")
+		}
+	}
+}`
+
+	expected := parsebody.Contract{Start: 40, End: 181}
+	checkContract(t, text, expected)
+}
+
+func TestToContract_SamplingInCode(t *testing.T) {
+	text := `package dummy
+
+func Pick(n int) int {
+	// Sampling
+	idx := rand.Intn(n)
+
+	return idx
+}`
+
+	expected := parsebody.Contract{NextNodePos: 40}
+	checkContract(t, text, expected)
+}
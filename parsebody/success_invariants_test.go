@@ -0,0 +1,54 @@
+package parsebody_test
+
+import (
+	"testing"
+
+	"github.com/Parquery/gocontracts/parsebody"
+)
+
+func TestToContract_Invariants_WithNextOnSeparateLine(t *testing.T) {
+	text := `package dummy
+
+func (s *SomeStruct) SomeFunc(x int) {
+	// Invariant on entry
+	if !(s.x > 0) {
+		panic("Violated: s.x > 0")
+	}
+
+	// Invariant on exit
+	defer func() {
+		if !(s.x > 0) {
+			panic("Violated: s.x > 0")
+		}
+	}()
+
+	return
+}`
+
+	expected := parsebody.Contract{Start: 56, End: 222, NextNodePos: 225}
+	checkContract(t, text, expected)
+}
+
+func TestToContract_PreconditionsAndInvariants_NoNext(t *testing.T) {
+	text := `package dummy
+
+func (s *SomeStruct) SomeFunc(x int) {
+	// Pre-condition
+	if !(x > 0) {
+		panic("Violated: x > 0")
+	}
+
+	// Invariants on entry
+	switch {
+	case !(s.x > 0):
+		panic("Violated: s.x > 0")
+	case !(s.y > 0):
+		panic("Violated: s.y > 0")
+	default:
+		// Pass
+	}
+}`
+
+	expected := parsebody.Contract{Start: 56, End: 269}
+	checkContract(t, text, expected)
+}
@@ -0,0 +1,58 @@
+package parsebody_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/Parquery/gocontracts/parsebody"
+)
+
+func TestToContract_Literal(t *testing.T) {
+	text := `package dummy
+
+var (
+	// SomeFunc does something.
+	SomeFunc = func(x int) (result int) {
+		// Pre-condition
+		if !(x > 0) {
+			panic("Violated: x > 0")
+		}
+
+		// Post-condition
+		defer func() {
+			if !(result > x) {
+				panic("Violated: result > x")
+			}
+		}()
+
+		return x + 1
+	}
+)`
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, "", text, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	spec := node.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec)
+	fn := parsebody.LiteralDecl(spec.Names[0], spec.Doc, spec.Values[0].(*ast.FuncLit))
+
+	if fn.Name.Name != "SomeFunc" || fn.Doc == nil || fn.Body != spec.Values[0].(*ast.FuncLit).Body {
+		t.Fatalf("unexpected declaration of the literal: %#v", fn)
+	}
+
+	cmtMap := ast.NewCommentMap(fset, node, node.Comments)
+
+	got, err := parsebody.ToContract(fset, fn, cmtMap.Filter(fn.Body))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := parsebody.Contract{Start: 92, End: 261, NextNodePos: 265}
+	if got != expected {
+		t.Errorf("expected %#v, got %#v", expected, got)
+	}
+}
@@ -36,3 +36,29 @@ func SomeFunc(x int, y int) (result string, err error) {}`
 	expected := parsebody.Contract{}
 	checkContract(t, text, expected)
 }
+
+func TestToContract_NoContract_OldValuesInCode(t *testing.T) {
+	text := `package dummy
+
+func SomeFunc(a []int) {
+	// Old values
+	x := a[0]
+	fmt.Println(x)
+}`
+
+	expected := parsebody.Contract{NextNodePos: 42}
+	checkContract(t, text, expected)
+}
+
+func TestToContract_NoContract_OldValuesWithoutPostcondition(t *testing.T) {
+	text := `package dummy
+
+func SomeFunc(a []int) {
+	// Old values
+	old1 := a[0]
+	fmt.Println(old1)
+}`
+
+	expected := parsebody.Contract{NextNodePos: 42}
+	checkContract(t, text, expected)
+}
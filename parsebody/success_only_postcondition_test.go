@@ -88,3 +88,24 @@ func SomeFunc(x int, y int) (result string, err error) {
 	expected := parsebody.Contract{Start: 74, End: 305, NextNodePos: 308}
 	checkContract(t, text, expected)
 }
+
+func TestToContract_OldValuesAndPostcondition_WithNextOnSeparateLine(t *testing.T) {
+	text := `package dummy
+
+func SomeFunc(a []int) {
+	// Old values
+	old1 := a[0]
+
+	// Post-condition
+	defer func() {
+		if !(a[0] == old1 + 1) {
+			panic("Violated: a[0] == old(a[0]) + 1")
+		}
+	}()
+
+	a[0]++
+}`
+
+	expected := parsebody.Contract{Start: 42, End: 185, NextNodePos: 188}
+	checkContract(t, text, expected)
+}
@@ -80,3 +80,24 @@ func SomeFunc(x int, y int) (result string, err error) {
 	expected := parsebody.Contract{Start: 74, End: 273, NextNodePos: 276}
 	checkContract(t, text, expected)
 }
+
+func TestToContract_OnlyPreconditionsReturningErrors(t *testing.T) {
+	text := `package dummy
+
+func SomeFunc(x int, y int) (result string, err error) {
+	// Pre-conditions (error)
+	switch {
+	case !(x > 0):
+		return "", errors.New("Violated: x > 0")
+	case !(y > 3):
+		return "", errors.New("Violated: y > 3")
+	default:
+		// Pass
+	}
+
+	return
+}`
+
+	expected := parsebody.Contract{Start: 74, End: 250, NextNodePos: 253}
+	checkContract(t, text, expected)
+}
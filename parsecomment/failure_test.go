@@ -148,3 +148,71 @@ SomeFunc ensures:
 			"The error was: 4:10: expected operand, found '{' "+
 			"(and 7 more errors)")
 }
+
+func TestToInvariants_InvalidName(t *testing.T) {
+	text := `SomeStruct defines a struct.
+
+AnotherStruct invariants:
+ * s.x > 0`
+
+	_, err := parsecomment.ToInvariants("SomeStruct", strings.Split(text, "\n"))
+
+	expected := "expected type name \"SomeStruct\" in invariants block, but got \"AnotherStruct\""
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected error %#v, got %v", expected, err)
+	}
+}
+
+func TestToInvariants_MultipleBlocks(t *testing.T) {
+	text := `SomeStruct defines a struct.
+
+SomeStruct invariants:
+ * s.x > 0
+
+SomeStruct invariants:
+ * s.x < 100`
+
+	_, err := parsecomment.ToInvariants("SomeStruct", strings.Split(text, "\n"))
+
+	expected := "multiple invariants blocks"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected error %#v, got %v", expected, err)
+	}
+}
+
+func TestToContract_ErrorInPostconditionBlock(t *testing.T) {
+	checkFailure(t, "SomeFunc", `SomeFunc ensures (error):
+ * x > 0`,
+		"failed to parse the post-condition block: unexpected option \"error\" in \"error\"; "+
+			"only pre-conditions can be returned as errors")
+}
+
+func TestToContract_MultipleFamiliesInBlock(t *testing.T) {
+	checkFailure(t, "SomeFunc", `SomeFunc requires (test, utest):
+ * x > 0`,
+		"failed to parse the pre-condition block: "+
+			"expected at most one family in the options of the block, but got \"test, utest\"")
+}
+
+func TestToContract_InvalidBlockOption(t *testing.T) {
+	checkFailure(t, "SomeFunc", `SomeFunc requires (some test):
+ * x > 0`,
+		"failed to parse the pre-condition block: "+
+			"expected the options of the block as \"error\" or a family name, but got \"some test\"")
+}
+
+func TestToContract_InvalidSample(t *testing.T) {
+	checkFailure(t, "SomeFunc", `SomeFunc contracts: sample 1/0`,
+		"expected a positive number of calls in the sampling marker \"sample 1/0\"")
+}
+
+func TestToContract_SampleWithoutOne(t *testing.T) {
+	checkFailure(t, "SomeFunc", `SomeFunc contracts: sample 2/5`,
+		"expected the sampling marker as \"sample 1/N\", but got \"sample 2/5\"")
+}
+
+func TestToContract_MultipleChecks(t *testing.T) {
+	checkFailure(t, "SomeFunc", `SomeFunc contracts: doc-only
+SomeFunc contracts: checked`,
+		"multiple contracts markers")
+}
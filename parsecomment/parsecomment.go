@@ -4,6 +4,7 @@ package parsecomment
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/Parquery/gocontracts/dedent"
@@ -11,14 +12,78 @@ import (
 )
 
 var requiresRe = regexp.MustCompile(
-	`^\s*([a-zA-Z_][a-zA-Z_0-9]*)\s+requires\s*:\s*$`)
+	`^\s*([a-zA-Z_][a-zA-Z_0-9]*)\s+requires(\s*\(([^)]*)\))?\s*:\s*$`)
 
 var ensuresRe = regexp.MustCompile(
-	`^\s*([a-zA-Z_][a-zA-Z_0-9]*)\s+ensures\s*:\s*$`)
+	`^\s*([a-zA-Z_][a-zA-Z_0-9]*)\s+ensures(\s*\(([^)]*)\))?\s*:\s*$`)
 
 var preambleRe = regexp.MustCompile(
 	`^\s*([a-zA-Z_][a-zA-Z_0-9]*)('s)?\s+preamble\s*:\s*$`)
 
+var invariantsRe = regexp.MustCompile(
+	`^\s*([a-zA-Z_][a-zA-Z_0-9]*)\s+invariants(\s*\(([^)]*)\))?\s*:\s*$`)
+
+var contractsRe = regexp.MustCompile(
+	`^\s*([a-zA-Z_][a-zA-Z_0-9]*)\s+contracts\s*:\s*(doc-only|checked|sample\s+[0-9]+/[0-9]+)\s*$`)
+
+var sampleRe = regexp.MustCompile(`^sample\s+([0-9]+)/([0-9]+)$`)
+
+var familyNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z_0-9]*$`)
+
+// blockOptions define the options given in parentheses after the header of a block
+// (e.g., "SomeFunc requires (error, test):").
+type blockOptions struct {
+	// returnsError is set if the pre-conditions are to be returned as errors.
+	returnsError bool
+
+	// family of the conditions in the block which do not specify their own family
+	family string
+}
+
+// parseBlockOptions parses the comma-separated options of a block. The option "error" is only allowed if
+// errorAllowed is set.
+func parseBlockOptions(text string, errorAllowed bool) (opts blockOptions, err error) {
+	if strings.Trim(text, " \t") == "" {
+		return
+	}
+
+	for _, part := range strings.Split(text, ",") {
+		option := strings.Trim(part, " \t")
+
+		switch {
+		case option == "error" && errorAllowed:
+			opts.returnsError = true
+
+		case option == "error":
+			err = fmt.Errorf("unexpected option \"error\" in %#v; only pre-conditions can be returned as errors",
+				text)
+			return
+
+		case !familyNameRe.MatchString(option):
+			err = fmt.Errorf("expected the options of the block as \"error\" or a family name, but got %#v", text)
+			return
+
+		case opts.family != "":
+			err = fmt.Errorf("expected at most one family in the options of the block, but got %#v", text)
+			return
+
+		default:
+			opts.family = option
+		}
+	}
+
+	return
+}
+
+// withFamily sets the family of the condition unless it specifies its own.
+func withFamily(cond parsecond.Condition, family string) parsecond.Condition {
+	if cond.Family == "" {
+		cond.Family = family
+	}
+
+	return cond
+}
+
 // Line tokens are obtained by tokenizing each line of
 // the function description as a whole.
 
@@ -29,6 +94,9 @@ type lineToken interface {
 type requiresToken struct {
 	aText string
 	name  string
+
+	// options are given in parentheses (e.g., "error" in "SomeFunc requires (error):").
+	options string
 }
 
 func (r *requiresToken) text() string {
@@ -36,8 +104,9 @@ func (r *requiresToken) text() string {
 }
 
 type ensuresToken struct {
-	aText string
-	name  string
+	aText   string
+	name    string
+	options string
 }
 
 func (e *ensuresToken) text() string {
@@ -53,6 +122,29 @@ func (p *preambleToken) text() string {
 	return p.aText
 }
 
+type invariantsToken struct {
+	aText   string
+	name    string
+	options string
+}
+
+func (i *invariantsToken) text() string {
+	return i.aText
+}
+
+type contractsToken struct {
+	aText string
+	name  string
+
+	// checks is the marker after the colon (e.g., "doc-only" in "SomeFunc contracts: doc-only" or
+	// "sample 1/1000" in "SomeFunc contracts: sample 1/1000").
+	checks string
+}
+
+func (c *contractsToken) text() string {
+	return c.aText
+}
+
 type textToken struct {
 	aText string
 }
@@ -61,19 +153,23 @@ func (u *textToken) text() string {
 	return u.aText
 }
 
-func tokenizeComment(commentLines []string) (tokens []lineToken) {
+// tokenizeComment splits the documentation of the named function or type into tokens.
+//
+// A line is a contracts marker only if it gives the name and a known marker so that the prose which happens to
+// look alike (e.g., "Foo contracts: see the design doc") is kept as text.
+func tokenizeComment(name string, commentLines []string) (tokens []lineToken) {
 	tokens = make([]lineToken, 0, len(commentLines))
 
 	for _, line := range commentLines {
 		mtchs := requiresRe.FindStringSubmatch(line)
 		if len(mtchs) > 0 {
-			tokens = append(tokens, &requiresToken{aText: line, name: mtchs[1]})
+			tokens = append(tokens, &requiresToken{aText: line, name: mtchs[1], options: mtchs[3]})
 			continue
 		}
 
 		mtchs = ensuresRe.FindStringSubmatch(line)
 		if len(mtchs) > 0 {
-			tokens = append(tokens, &ensuresToken{aText: line, name: mtchs[1]})
+			tokens = append(tokens, &ensuresToken{aText: line, name: mtchs[1], options: mtchs[3]})
 			continue
 		}
 
@@ -83,6 +179,18 @@ func tokenizeComment(commentLines []string) (tokens []lineToken) {
 			continue
 		}
 
+		mtchs = invariantsRe.FindStringSubmatch(line)
+		if len(mtchs) > 0 {
+			tokens = append(tokens, &invariantsToken{aText: line, name: mtchs[1], options: mtchs[3]})
+			continue
+		}
+
+		mtchs = contractsRe.FindStringSubmatch(line)
+		if len(mtchs) > 0 && mtchs[1] == name {
+			tokens = append(tokens, &contractsToken{aText: line, name: mtchs[1], checks: mtchs[2]})
+			continue
+		}
+
 		tokens = append(tokens, &textToken{aText: line})
 		continue
 	}
@@ -90,16 +198,122 @@ func tokenizeComment(commentLines []string) (tokens []lineToken) {
 	return
 }
 
+// Checks define whether the conditions of a function are checked in the code regardless of
+// whether the checks are generated or removed in general.
+type Checks int
+
+const (
+	// DefaultChecks are generated or removed together with the checks of the other functions.
+	DefaultChecks Checks = iota
+
+	// DocOnly marks the contracts which are only documented; their checks are always removed
+	// (given as "SomeFunc contracts: doc-only").
+	DocOnly
+
+	// AlwaysChecked marks the contracts which are always checked; their checks are kept even if the checks of
+	// the other functions are removed (given as "SomeFunc contracts: checked").
+	AlwaysChecked
+)
+
+// String gives the marker of the checks as written in the documentation. The default checks have no marker.
+func (c Checks) String() string {
+	switch c {
+	case DefaultChecks:
+		return ""
+	case DocOnly:
+		return "doc-only"
+	case AlwaysChecked:
+		return "checked"
+	default:
+		panic(fmt.Sprintf("unhandled checks: %d", int(c)))
+	}
+}
+
+// parseChecks parses the marker of the checks.
+//
+// The marker "sample 1/N" gives the default checks which are only evaluated on every N-th call as sample.
+func parseChecks(marker string) (checks Checks, sample int, err error) {
+	for _, c := range []Checks{DocOnly, AlwaysChecked} {
+		if marker == c.String() {
+			checks = c
+			return
+		}
+	}
+
+	mtchs := sampleRe.FindStringSubmatch(marker)
+	if len(mtchs) == 0 {
+		err = fmt.Errorf("expected the contracts to be marked as %#v, %#v or \"sample 1/N\", but got %#v",
+			DocOnly.String(), AlwaysChecked.String(), marker)
+		return
+	}
+
+	if mtchs[1] != "1" {
+		err = fmt.Errorf("expected the sampling marker as \"sample 1/N\", but got %#v", marker)
+		return
+	}
+
+	sample, err = strconv.Atoi(mtchs[2])
+	if err != nil || sample < 1 {
+		err = fmt.Errorf("expected a positive number of calls in the sampling marker %#v", marker)
+	}
+
+	return
+}
+
+// checksOf parses the marker of the checks among the tokens.
+func checksOf(tokens []lineToken) (checks Checks, sample int, err error) {
+	count := 0
+	for _, token := range tokens {
+		t, ok := token.(*contractsToken)
+		if !ok {
+			continue
+		}
+
+		count++
+		if count > 1 {
+			err = fmt.Errorf("multiple contracts markers")
+			return
+		}
+
+		checks, sample, err = parseChecks(t.checks)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ToChecks parses only the marker of the checks from the function's documentation so that the checks can be
+// kept without parsing the contracts of all the functions.
+func ToChecks(name string, commentLines []string) (checks Checks, err error) {
+	checks, _, err = checksOf(tokenizeComment(name, commentLines))
+	return
+}
+
 // Contract bundles the conditions and the preamble of the function's contract.
 type Contract struct {
 	Pres     []parsecond.Condition
 	Posts    []parsecond.Condition
 	Preamble string
+
+	// PreambleLine is the index of the comment line where the preamble starts.
+	PreambleLine int
+
+	// ReturnErrors indicates that the violated pre-conditions are returned as errors instead of panicking.
+	ReturnErrors bool
+
+	// Checks define whether the conditions are checked regardless of the other functions.
+	Checks Checks
+
+	// Sample is N if the conditions are checked only on every N-th call (given as
+	// "SomeFunc contracts: sample 1/N"). If zero, the conditions are checked on every call.
+	Sample int
 }
 
 // ToContract parses the contract from the function's documentation.
 func ToContract(name string, commentLines []string) (c Contract, err error) {
-	tokens := tokenizeComment(commentLines)
+	tokens := tokenizeComment(name, commentLines)
 
 	requiresCount := 0
 	ensuresCount := 0
@@ -130,6 +344,11 @@ func ToContract(name string, commentLines []string) (c Contract, err error) {
 		return
 	}
 
+	c.Checks, c.Sample, err = checksOf(tokens)
+	if err != nil {
+		return
+	}
+
 	const (
 		stateText     = 0
 		stateRequires = 1
@@ -141,10 +360,14 @@ func ToContract(name string, commentLines []string) (c Contract, err error) {
 	c.Posts = make([]parsecond.Condition, 0, 5)
 
 	preambleLines := make([]string, 0, 5)
+	preambleStarted := false
+
+	// family of the current block
+	family := ""
 
 	state := stateText
 
-	for _, token := range tokens {
+	for i, token := range tokens {
 		switch t := token.(type) {
 		case *requiresToken:
 			if name != t.name {
@@ -155,6 +378,15 @@ func ToContract(name string, commentLines []string) (c Contract, err error) {
 				return
 			}
 
+			var opts blockOptions
+			opts, err = parseBlockOptions(t.options, true)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the pre-condition block: %s", err.Error())
+				return
+			}
+
+			c.ReturnErrors = opts.returnsError
+			family = opts.family
 			state = stateRequires
 			continue
 
@@ -167,6 +399,14 @@ func ToContract(name string, commentLines []string) (c Contract, err error) {
 				return
 			}
 
+			var opts blockOptions
+			opts, err = parseBlockOptions(t.options, false)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the post-condition block: %s", err.Error())
+				return
+			}
+
+			family = opts.family
 			state = stateEnsures
 			continue
 
@@ -181,6 +421,16 @@ func ToContract(name string, commentLines []string) (c Contract, err error) {
 			state = statePreamble
 			continue
 
+		case *invariantsToken:
+			// Invariants belong to types and are not part of the function's contract.
+			state = stateText
+			continue
+
+		case *contractsToken:
+			// The marker has been already parsed and ends the block.
+			state = stateText
+			continue
+
 		case *textToken:
 			switch state {
 			case stateText:
@@ -200,7 +450,8 @@ func ToContract(name string, commentLines []string) (c Contract, err error) {
 						return
 					}
 					if cond != nil {
-						c.Pres = append(c.Pres, *cond)
+						cond.Line = i
+						c.Pres = append(c.Pres, withFamily(*cond, family))
 					} else {
 						// Unmatched condition ends a pre-condition block.
 						state = stateText
@@ -221,7 +472,8 @@ func ToContract(name string, commentLines []string) (c Contract, err error) {
 						return
 					}
 					if cond != nil {
-						c.Posts = append(c.Posts, *cond)
+						cond.Line = i
+						c.Posts = append(c.Posts, withFamily(*cond, family))
 					} else {
 						// Unmatched condition ends a post-condition block.
 						state = stateText
@@ -236,6 +488,12 @@ func ToContract(name string, commentLines []string) (c Contract, err error) {
 					// Un-indented non-empty line ends a preamble block.
 					state = stateText
 				} else {
+					if len(strings.Trim(token.text(), " \t")) > 0 && !preambleStarted {
+						// Leading empty lines are trimmed from the preamble.
+						c.PreambleLine = i
+						preambleStarted = true
+					}
+
 					preambleLines = append(preambleLines, token.text())
 				}
 
@@ -257,3 +515,81 @@ func ToContract(name string, commentLines []string) (c Contract, err error) {
 
 	return
 }
+
+// ToInvariants parses the invariants from the type's documentation.
+//
+// All the other blocks (pre-conditions, post-conditions and preambles) are ignored.
+func ToInvariants(name string, commentLines []string) (invariants []parsecond.Condition, err error) {
+	tokens := tokenizeComment(name, commentLines)
+
+	invariantsCount := 0
+	for _, token := range tokens {
+		if _, ok := token.(*invariantsToken); ok {
+			invariantsCount++
+		}
+	}
+
+	if invariantsCount > 1 {
+		err = fmt.Errorf("multiple invariants blocks")
+		return
+	}
+
+	invariants = make([]parsecond.Condition, 0, 5)
+
+	inBlock := false
+	family := ""
+
+	for i, token := range tokens {
+		switch t := token.(type) {
+		case *invariantsToken:
+			if name != t.name {
+				err = fmt.Errorf(
+					"expected type name %#v in invariants block, but got %#v",
+					name, t.name)
+				return
+			}
+
+			var opts blockOptions
+			opts, err = parseBlockOptions(t.options, false)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the invariants block: %s", err.Error())
+				return
+			}
+
+			family = opts.family
+			inBlock = true
+
+		case *textToken:
+			if !inBlock {
+				continue
+			}
+
+			if len(strings.Trim(token.text(), " \t")) == 0 {
+				// Empty line ends an invariants block.
+				inBlock = false
+				continue
+			}
+
+			var cond *parsecond.Condition
+			cond, err = parsecond.ToCondition(token.text())
+			if err != nil {
+				err = fmt.Errorf("failed to parse an invariant: %s", err.Error())
+				return
+			}
+
+			if cond != nil {
+				cond.Line = i
+				invariants = append(invariants, withFamily(*cond, family))
+			} else {
+				// Unmatched condition ends an invariants block.
+				inBlock = false
+			}
+
+		default:
+			// Any other block ends an invariants block.
+			inBlock = false
+		}
+	}
+
+	return
+}
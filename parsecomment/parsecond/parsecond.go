@@ -12,6 +12,12 @@ import (
 
 // Condition defines a pre- or a post-condition of the function's contract.
 type Condition struct {
+	// Family of the condition, if any, e.g., "test" given
+	//	[test] x > 0
+	//
+	// The conditions of a family are checked only if the family is enabled.
+	Family string
+
 	Label string
 
 	// InitStr corresponds to initialization statement in conditions,
@@ -26,11 +32,186 @@ type Condition struct {
 
 	CondStr string
 	Cond    ast.Expr
+
+	// Quantifier is set if the condition needs to hold for all or for some elements of a collection,
+	// e.g., given
+	//	forall i, x in items: x > 0
+	//
+	// , CondStr and Cond refer to the condition on a single element:
+	//	x > 0
+	Quantifier *Quantifier
+
+	// Line is the index of the comment line from which the condition was parsed.
+	// It is set by the parsers of the comments, ToCondition leaves it at zero.
+	Line int
+
+	// File is the path of the file where the condition was documented if it differs from the file of
+	// the function (e.g., for the conditions inherited from an interface). It is left empty by the parsers.
+	File string
+}
+
+// Quantifier defines how a condition is quantified over the elements of a collection
+// (a slice, an array, a string, a map or a channel).
+type Quantifier struct {
+	// Exists is set if the condition needs to hold for at least one element ("exists").
+	// Otherwise, the condition needs to hold for all the elements ("forall").
+	Exists bool
+
+	// Key is the variable bound to the index or the key of the element.
+	Key string
+
+	// Value is the variable bound to the element. It is empty if only the key is bound.
+	Value string
+
+	CollectionStr string
+	Collection    ast.Expr
+}
+
+// String gives the quantifier as written in the documentation, e.g., "forall i, x in items".
+func (q Quantifier) String() string {
+	kind := "forall"
+	if q.Exists {
+		kind = "exists"
+	}
+
+	if q.Value == "" {
+		return fmt.Sprintf("%s %s in %s", kind, q.Key, q.CollectionStr)
+	}
+
+	return fmt.Sprintf("%s %s, %s in %s", kind, q.Key, q.Value, q.CollectionStr)
+}
+
+// refersTo checks whether the expression refers to the variable.
+//
+// The selected fields and methods (e.g., "x" in "s.x") are not considered references.
+func refersTo(expr ast.Expr, name string) (refers bool) {
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			refers = refers || refersTo(n.X, name)
+			return false
+		case *ast.Ident:
+			refers = refers || n.Name == name
+		}
+
+		return !refers
+	})
+
+	return
+}
+
+// RangeClause gives the range clause of the "for" statement iterating over the collection of
+// the quantified condition, e.g., "i, x := range items".
+//
+// The variables which are neither referred to in the condition nor listed in keep are replaced with
+// blank identifiers so that the generated code compiles.
+func (c Condition) RangeClause(keep ...string) string {
+	q := c.Quantifier
+
+	used := func(name string) bool {
+		if name == "" || name == "_" {
+			return false
+		}
+
+		for _, k := range keep {
+			if k == name {
+				return true
+			}
+		}
+
+		return refersTo(c.Cond, name)
+	}
+
+	switch {
+	case used(q.Value) && used(q.Key):
+		return fmt.Sprintf("%s, %s := range %s", q.Key, q.Value, q.CollectionStr)
+	case used(q.Value):
+		return fmt.Sprintf("_, %s := range %s", q.Value, q.CollectionStr)
+	case used(q.Key):
+		return fmt.Sprintf("%s := range %s", q.Key, q.CollectionStr)
+	default:
+		return fmt.Sprintf("range %s", q.CollectionStr)
+	}
+}
+
+// Text gives the condition as written in the documentation without the label.
+func (c Condition) Text() string {
+	text := c.CondStr
+	if c.InitStr != "" {
+		text = fmt.Sprintf("%s; %s", c.InitStr, text)
+	}
+
+	if c.Quantifier != nil {
+		text = fmt.Sprintf("%s: %s", c.Quantifier.String(), text)
+	}
+
+	return text
+}
+
+// ExprStr gives the condition as a Go boolean expression without the initialization.
+//
+// The quantified conditions are expressed as function literals which are immediately invoked.
+func (c Condition) ExprStr() string {
+	q := c.Quantifier
+	if q == nil {
+		return c.CondStr
+	}
+
+	if q.Exists {
+		return fmt.Sprintf("func() bool { for %s { if %s { return true } }; return false }()",
+			c.RangeClause(), c.CondStr)
+	}
+
+	return fmt.Sprintf("func() bool { for %s { if !(%s) { return false } }; return true }()",
+		c.RangeClause(), c.CondStr)
 }
 
 var bulletRe = regexp.MustCompile(`^\s*\*\s*(.*)\s*$`)
+var familyRe = regexp.MustCompile(`^\[\s*([a-zA-Z_][a-zA-Z_0-9]*)\s*\]\s+(.*)$`)
 var labelWithCondRe = regexp.MustCompile(
 	`^([a-zA-Z0-9_;.\-=' ]+\s*:)([ \t^=]?.*)$`)
+var quantifierRe = regexp.MustCompile(
+	`^(forall|exists)\s+([a-zA-Z_][a-zA-Z_0-9]*)(\s*,\s*([a-zA-Z_][a-zA-Z_0-9]*))?\s+in\s+(.*)$`)
+
+// toQuantifier tries to parse the quantifier at the start of the text.
+//
+// If the text is not quantified, q is nil. Otherwise, rest is the quantified condition.
+func toQuantifier(text string) (q *Quantifier, rest string, err error) {
+	mtchs := quantifierRe.FindStringSubmatch(text)
+	if len(mtchs) == 0 {
+		return
+	}
+
+	q = &Quantifier{
+		Exists: mtchs[1] == "exists",
+		Key:    mtchs[2],
+		Value:  mtchs[4]}
+
+	// The collection can contain colons itself (e.g., "items[1:]") so we take the first colon
+	// preceded by a valid expression.
+	tail := mtchs[5]
+	for i := 0; i < len(tail); i++ {
+		if tail[i] != ':' {
+			continue
+		}
+
+		collectionStr := strings.Trim(tail[:i], " \t")
+
+		collection, parseErr := parser.ParseExpr(collectionStr)
+		if parseErr != nil {
+			continue
+		}
+
+		q.CollectionStr = collectionStr
+		q.Collection = collection
+		rest = strings.Trim(tail[i+1:], " \t")
+		return
+	}
+
+	err = fmt.Errorf("expected the quantified condition as %#v, but got: %#v",
+		"forall|exists key[, value] in collection: condition", text)
+	return
+}
 
 // ToCondition tries to parse the condition from text.
 //
@@ -50,22 +231,50 @@ func ToCondition(text string) (cond *Condition, err error) {
 
 	content := mtchs[1]
 
+	var family string
+	if mtchs = familyRe.FindStringSubmatch(content); len(mtchs) > 0 {
+		family = mtchs[1]
+		content = mtchs[2]
+	}
+
 	////
 	// Parse the content of the bullet as condition
 	////
 
-	mtchs = labelWithCondRe.FindStringSubmatch(content)
+	// The quantifier needs to be parsed before the label since it would be mistaken for one
+	// (e.g., "exists k in m: k != 0").
+	quantifier, quantified, err := toQuantifier(content)
+	if err != nil {
+		return
+	}
 
 	var label string
 	var parsable string
-	if len(mtchs) == 0 {
-		parsable = content
-	} else {
-		label = strings.TrimSuffix(
-			strings.Trim(mtchs[1], " \t"),
-			":")
+	switch {
+	case quantifier != nil:
+		parsable = quantified
+
+	default:
+		mtchs = labelWithCondRe.FindStringSubmatch(content)
+
+		if len(mtchs) == 0 {
+			parsable = content
+		} else {
+			label = strings.TrimSuffix(
+				strings.Trim(mtchs[1], " \t"),
+				":")
+
+			parsable = strings.Trim(mtchs[2], " \t")
+		}
 
-		parsable = strings.Trim(mtchs[2], " \t")
+		quantifier, quantified, err = toQuantifier(parsable)
+		if err != nil {
+			return
+		}
+
+		if quantifier != nil {
+			parsable = quantified
+		}
 	}
 
 	////
@@ -118,11 +327,19 @@ func main() {
 		return
 	}
 
+	if quantifier != nil && initStr != "" {
+		err = fmt.Errorf("unexpected initialization %#v in the quantified condition %#v; "+
+			"only a boolean expression can be quantified", initStr, content)
+		return
+	}
+
 	cond = &Condition{
-		Label:   label,
-		InitStr: initStr,
-		CondStr: condStr,
-		Cond:    expr}
+		Family:     family,
+		Label:      label,
+		InitStr:    initStr,
+		CondStr:    condStr,
+		Cond:       expr,
+		Quantifier: quantifier}
 
 	return
 }
@@ -28,6 +28,12 @@ func conditionEquals(
 				expected.InitStr, got.InitStr))
 	}
 
+	if expected.Family != got.Family {
+		msgs = append(msgs,
+			fmt.Sprintf("expected family %#v, got %#v",
+				expected.Family, got.Family))
+	}
+
 	if expected.CondStr != got.CondStr {
 		msgs = append(msgs,
 			fmt.Sprintf("expected condition string %#v, got %#v",
@@ -110,6 +116,22 @@ func TestParseCondition(t *testing.T) {
 			text:        "* x < 100",
 			description: "whitespace-prefix agnostic",
 		},
+		{
+			expected: parsecond.Condition{
+				Family:  "test",
+				Label:   "some label",
+				CondStr: "x < 100",
+			},
+			text:        " * [test] some label: x < 100",
+			description: "family, label, condition string",
+		},
+		{
+			expected: parsecond.Condition{
+				CondStr: "[2]int{1, 2}[x] < 100",
+			},
+			text:        " * [2]int{1, 2}[x] < 100",
+			description: "array literal is not a family",
+		},
 	}
 
 	for _, cs := range cases {
@@ -165,3 +187,102 @@ The error was: 4:10: expected operand, found '{' (and 7 more errors)`
 }
 
 // TODO(marko): add more unit tests to cover up; other than that: ready to publish!tog
+
+func TestParseCondition_Quantifier(t *testing.T) {
+	type testCase struct {
+		text       string
+		label      string
+		quantifier parsecond.Quantifier
+		condStr    string
+		exprStr    string
+	}
+
+	cases := []testCase{
+		{
+			text:       " * forall i, x in items: x > 0",
+			quantifier: parsecond.Quantifier{Key: "i", Value: "x", CollectionStr: "items"},
+			condStr:    "x > 0",
+			exprStr:    "func() bool { for _, x := range items { if !(x > 0) { return false } }; return true }()",
+		},
+		{
+			text:       " * exists k in m: k == \"\"",
+			quantifier: parsecond.Quantifier{Exists: true, Key: "k", CollectionStr: "m"},
+			condStr:    "k == \"\"",
+			exprStr:    "func() bool { for k := range m { if k == \"\" { return true } }; return false }()",
+		},
+		{
+			text:       " * some label: forall _, x in items[1:]: x.y > 0",
+			label:      "some label",
+			quantifier: parsecond.Quantifier{Key: "_", Value: "x", CollectionStr: "items[1:]"},
+			condStr:    "x.y > 0",
+			exprStr: "func() bool { for _, x := range items[1:] { if !(x.y > 0) { return false } }; " +
+				"return true }()",
+		},
+		{
+			text:       " * exists i in items: s.i > 0",
+			quantifier: parsecond.Quantifier{Exists: true, Key: "i", CollectionStr: "items"},
+			condStr:    "s.i > 0",
+			exprStr:    "func() bool { for range items { if s.i > 0 { return true } }; return false }()",
+		},
+	}
+
+	for _, cs := range cases {
+		got, err := parsecond.ToCondition(cs.text)
+		if err != nil {
+			t.Fatalf("Failed to parse the condition %#v: %s", cs.text, err.Error())
+		}
+
+		if got == nil || got.Quantifier == nil {
+			t.Fatalf("Expected a quantified condition from %#v, but got: %#v", cs.text, got)
+		}
+
+		q := *got.Quantifier
+		q.Collection = nil
+		if q != cs.quantifier {
+			t.Errorf("Expected the quantifier %#v from %#v, got %#v", cs.quantifier, cs.text, q)
+		}
+
+		if got.Label != cs.label {
+			t.Errorf("Expected the label %#v from %#v, got %#v", cs.label, cs.text, got.Label)
+		}
+
+		if got.CondStr != cs.condStr {
+			t.Errorf("Expected the condition %#v from %#v, got %#v", cs.condStr, cs.text, got.CondStr)
+		}
+
+		if exprStr := got.ExprStr(); exprStr != cs.exprStr {
+			t.Errorf("Expected the expression %#v from %#v, got %#v", cs.exprStr, cs.text, exprStr)
+		}
+
+		expectedText := strings.TrimPrefix(strings.TrimPrefix(cs.text, " * "), cs.label+": ")
+		if text := got.Text(); text != expectedText {
+			t.Errorf("Expected the text %#v from %#v, got %#v", expectedText, cs.text, text)
+		}
+	}
+}
+
+func TestParseCondition_QuantifierWithoutColon(t *testing.T) {
+	_, err := parsecond.ToCondition("* forall i in items")
+	expected := "expected the quantified condition as " +
+		"\"forall|exists key[, value] in collection: condition\", but got: \"forall i in items\""
+
+	switch {
+	case err == nil:
+		t.Fatalf("Expected an error, but got nil")
+	case err.Error() != expected:
+		t.Fatalf("Expected an error %#v,\n\tbut got %#v", expected, err.Error())
+	}
+}
+
+func TestParseCondition_QuantifierWithInitialization(t *testing.T) {
+	_, err := parsecond.ToCondition("* forall k in keys: _, ok := m[k]; ok")
+	expected := "unexpected initialization \"_, ok := m[k]\" in the quantified condition " +
+		"\"forall k in keys: _, ok := m[k]; ok\"; only a boolean expression can be quantified"
+
+	switch {
+	case err == nil:
+		t.Fatalf("Expected an error, but got nil")
+	case err.Error() != expected:
+		t.Fatalf("Expected an error %#v,\n\tbut got %#v", expected, err.Error())
+	}
+}
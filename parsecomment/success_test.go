@@ -194,3 +194,234 @@ bla bla bla`, "\n")
 
 	checkContract(t, exp, got)
 }
+
+func TestToInvariants(t *testing.T) {
+	lines := strings.Split(
+		`SomeStruct defines a struct.
+
+SomeStruct requires:
+ * x > 0
+
+SomeStruct invariants:
+ * s.x >= 0
+ * not too large: s.x < 100
+
+Some text here.`, "\n")
+
+	got, err := parsecomment.ToInvariants("SomeStruct", lines)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	exp := expectedContract{
+		pres: []expectedCondition{
+			{condStr: "s.x >= 0"},
+			{condStr: "s.x < 100", label: "not too large"},
+		},
+	}
+
+	checkContract(t, exp, parsecomment.Contract{Pres: got})
+}
+
+func TestToContract_IgnoresInvariants(t *testing.T) {
+	lines := strings.Split(
+		`SomeFunc does something.
+
+SomeFunc invariants:
+ * s.x >= 0
+
+SomeFunc requires:
+ * x > 0`, "\n")
+
+	got, err := parsecomment.ToContract("SomeFunc", lines)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	exp := expectedContract{
+		pres: []expectedCondition{
+			{condStr: "x > 0"},
+		},
+	}
+
+	checkContract(t, exp, got)
+}
+
+func TestToContract_Lines(t *testing.T) {
+	lines := strings.Split(
+		`SomeFunc does something.
+
+SomeFunc requires:
+ * x > 0
+
+SomeFunc preamble:
+
+  y := x + 1
+
+SomeFunc ensures:
+ * y > 1
+ * result > 0`, "\n")
+
+	got, err := parsecomment.ToContract("SomeFunc", lines)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(got.Pres) != 1 || got.Pres[0].Line != 3 {
+		t.Errorf("expected the pre-condition on the line index 3, got %#v", got.Pres)
+	}
+
+	if got.PreambleLine != 7 {
+		t.Errorf("expected the preamble to start on the line index 7, got %d", got.PreambleLine)
+	}
+
+	if len(got.Posts) != 2 || got.Posts[0].Line != 10 || got.Posts[1].Line != 11 {
+		t.Errorf("expected the post-conditions on the line indices 10 and 11, got %#v", got.Posts)
+	}
+}
+
+func TestToContract_ReturnErrors(t *testing.T) {
+	lines := strings.Split(
+		`SomeFunc does something.
+
+SomeFunc requires (error):
+ * x > 0`, "\n")
+
+	got, err := parsecomment.ToContract("SomeFunc", lines)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !got.ReturnErrors {
+		t.Fatal("expected the pre-conditions to be returned as errors")
+	}
+
+	checkContract(t, expectedContract{pres: []expectedCondition{{condStr: "x > 0"}}}, got)
+
+	got, err = parsecomment.ToContract("SomeFunc", []string{"SomeFunc requires:", " * x > 0"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if got.ReturnErrors {
+		t.Fatal("expected the pre-conditions not to be returned as errors")
+	}
+}
+
+func TestToContract_Families(t *testing.T) {
+	lines := strings.Split(
+		`SomeFunc does something.
+
+SomeFunc requires (error, test):
+ * x > 0
+ * [utest] y > 0
+
+SomeFunc ensures:
+ * result > 0
+ * [test] result < 100`, "\n")
+
+	got, err := parsecomment.ToContract("SomeFunc", lines)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !got.ReturnErrors {
+		t.Fatal("expected the pre-conditions to be returned as errors")
+	}
+
+	families := []string{}
+	for _, c := range append(got.Pres, got.Posts...) {
+		families = append(families, c.Family)
+	}
+
+	expected := []string{"test", "utest", "", "test"}
+	if strings.Join(families, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected the families %#v, got %#v", expected, families)
+	}
+}
+
+func TestToInvariants_Family(t *testing.T) {
+	lines := strings.Split(
+		`SomeStruct defines a struct.
+
+SomeStruct invariants (test):
+ * s.x > 0`, "\n")
+
+	invariants, err := parsecomment.ToInvariants("SomeStruct", lines)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(invariants) != 1 || invariants[0].Family != "test" {
+		t.Fatalf("expected a single invariant of the family \"test\", got %#v", invariants)
+	}
+}
+
+func TestToContract_Checks(t *testing.T) {
+	lines := strings.Split(
+		`SomeFunc does something.
+
+SomeFunc requires:
+ * x > 0
+SomeFunc contracts: doc-only
+SomeFunc ensures:
+ * result > 0`, "\n")
+
+	got, err := parsecomment.ToContract("SomeFunc", lines)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if got.Checks != parsecomment.DocOnly {
+		t.Fatalf("expected the contracts to be documented only, got %#v", got.Checks)
+	}
+
+	checkContract(t, expectedContract{
+		pres:  []expectedCondition{{condStr: "x > 0"}},
+		posts: []expectedCondition{{condStr: "result > 0"}}}, got)
+
+	checks, err := parsecomment.ToChecks("SomeFunc", []string{"SomeFunc contracts: checked"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if checks != parsecomment.AlwaysChecked {
+		t.Fatalf("expected the contracts to be always checked, got %#v", checks)
+	}
+
+	checks, err = parsecomment.ToChecks("SomeFunc", []string{"SomeFunc does something."})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if checks != parsecomment.DefaultChecks {
+		t.Fatalf("expected the default checks, got %#v", checks)
+	}
+}
+
+func TestToContract_Sample(t *testing.T) {
+	got, err := parsecomment.ToContract("SomeFunc", []string{
+		"SomeFunc requires:", " * x > 0", "", "SomeFunc contracts: sample 1/1000"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if got.Checks != parsecomment.DefaultChecks || got.Sample != 1000 {
+		t.Fatalf("expected the default checks sampled on every 1000th call, got %#v and %d", got.Checks, got.Sample)
+	}
+}
+
+func TestToContract_ContractsProse(t *testing.T) {
+	for _, line := range []string{
+		"SomeFunc contracts: see the design doc", "SomeFunc contracts: none", "AnotherFunc contracts: doc-only"} {
+
+		got, err := parsecomment.ToContract("SomeFunc", []string{line, "", "SomeFunc requires:", " * x > 0"})
+		if err != nil {
+			t.Fatalf("expected %#v to be kept as text, got an error: %s", line, err.Error())
+		}
+
+		if got.Checks != parsecomment.DefaultChecks || got.Sample != 0 || len(got.Pres) != 1 {
+			t.Fatalf("expected %#v to be kept as text, got %#v", line, got)
+		}
+	}
+}